@@ -71,6 +71,111 @@ type ActiveMQArtemisSpec struct {
 	// Specifies the template for various resources that the operator controls
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Resource Templates"
 	ResourceTemplates []ResourceTemplate `json:"resourceTemplates,omitempty"`
+	// Specifies primary/backup pairs for high availability, the brokers of the deployment plan are paired by ordinal
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="High Availability"
+	HA *HAType `json:"ha,omitempty"`
+	// Diverts that route messages sent to one address to another, configured with broker properties
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Diverts"
+	Diverts []DivertType `json:"diverts,omitempty"`
+	// Core bridges that forward the messages of a queue to another address or broker, configured with broker properties
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Bridges"
+	Bridges []BridgeType `json:"bridges,omitempty"`
+}
+
+type DivertType struct {
+	// Name of the divert, it is a broker property key and can't contain '.'
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Name string `json:"name"`
+	// The address whose messages are diverted
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Address",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Address string `json:"address"`
+	// The address the messages are diverted to
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Forwarding Address",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	ForwardingAddress string `json:"forwardingAddress"`
+	// Whether the messages only go to the forwarding address instead of to both addresses. Default is false
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Exclusive",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	Exclusive *bool `json:"exclusive,omitempty"`
+	// Only the messages that match the filter are diverted
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Filter",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Filter string `json:"filter,omitempty"`
+	// Diverts with the same routing name are exclusive among each other, only one of them diverts a message
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Routing Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	RoutingName string `json:"routingName,omitempty"`
+	// The routing type of the diverted messages, STRIP, PASS, ANYCAST or MULTICAST. The broker default is STRIP
+	//+kubebuilder:validation:Enum=STRIP;PASS;ANYCAST;MULTICAST
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Routing Type",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	RoutingType string `json:"routingType,omitempty"`
+	// Transforms the diverted messages
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Transformer"
+	Transformer *TransformerType `json:"transformer,omitempty"`
+}
+
+type BridgeType struct {
+	// Name of the bridge, it is a broker property key and can't contain '.'
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Name string `json:"name"`
+	// The queue the bridge consumes from
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Queue Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	QueueName string `json:"queueName"`
+	// The address the messages are forwarded to, by default they keep their address
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Forwarding Address",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	ForwardingAddress string `json:"forwardingAddress,omitempty"`
+	// Names of the connectors of the broker, from .Spec.Connectors or brokerProperties, to the broker the messages are forwarded to
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Static Connectors"
+	StaticConnectors []string `json:"staticConnectors"`
+	// Only the messages that match the filter are forwarded
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Filter",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Filter string `json:"filter,omitempty"`
+	// The routing type of the forwarded messages, STRIP, PASS, ANYCAST or MULTICAST. The broker default is PASS
+	//+kubebuilder:validation:Enum=STRIP;PASS;ANYCAST;MULTICAST
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Routing Type",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	RoutingType string `json:"routingType,omitempty"`
+	// Whether the bridge fails over to the backup of the target broker. The broker default is false
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="HA",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	HA *bool `json:"ha,omitempty"`
+	// Milliseconds between attempts to reconnect to the target broker, the broker default is 2000
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Retry Interval",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	RetryInterval *int64 `json:"retryInterval,omitempty"`
+	// Attempts to reconnect to the target broker before giving up, -1 (the broker default) retries forever
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Reconnect Attempts",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	ReconnectAttempts *int32 `json:"reconnectAttempts,omitempty"`
+	// Whether a duplicate id is added to the forwarded messages so the target broker drops duplicates. The broker default is true
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Use Duplicate Detection",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	UseDuplicateDetection *bool `json:"useDuplicateDetection,omitempty"`
+	// Transforms the forwarded messages
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Transformer"
+	Transformer *TransformerType `json:"transformer,omitempty"`
+}
+
+type TransformerType struct {
+	// The class name of the transformer, it has to be on the broker classpath
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Class Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	ClassName string `json:"className"`
+	// Properties the transformer is initialised with
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Properties"
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type HAPolicy string
+
+const (
+	// the backup keeps a copy of the primary journal over the network
+	HAPolicyReplication HAPolicy = "replication"
+	// the primary and the backup use the same journal on a shared volume
+	HAPolicySharedStore HAPolicy = "shared-store"
+)
+
+type HAType struct {
+	// How a backup gets the messages of its primary, replication or shared-store. Default is replication
+	//+kubebuilder:validation:Enum=replication;shared-store
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Policy",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Policy HAPolicy `json:"policy,omitempty"`
+	// Whether a primary takes over again from its backup when it comes back. Default is true
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Allow Failback",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	AllowFailback *bool `json:"allowFailback,omitempty"`
+	// Name of an existing ReadWriteMany persistent volume claim that holds the journals of every pair. Required by the shared-store policy
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Shared Store Claim Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	SharedStoreClaimName string `json:"sharedStoreClaimName,omitempty"`
 }
 
 type AddressSettingsType struct {
@@ -493,6 +598,27 @@ type StorageType struct {
 	// The storageClassName to be used in PVC
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Storage Class Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
 	StorageClassName string `json:"storageClassName,omitempty"`
+	// Separate volume for the journal directory
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Journal Storage"
+	Journal *DirectoryStorageType `json:"journal,omitempty"`
+	// Separate volume for the paging directory
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Paging Storage"
+	Paging *DirectoryStorageType `json:"paging,omitempty"`
+	// Separate volume for the bindings directory
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Bindings Storage"
+	Bindings *DirectoryStorageType `json:"bindings,omitempty"`
+	// Separate volume for the large messages directory
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Large Messages Storage"
+	LargeMessages *DirectoryStorageType `json:"largeMessages,omitempty"`
+}
+
+type DirectoryStorageType struct {
+	// The storage size, defaults to the size of the data volume
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Size",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Size string `json:"size,omitempty"`
+	// The storageClassName to be used in PVC, defaults to the storageClassName of the data volume
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Storage Class Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	StorageClassName string `json:"storageClassName,omitempty"`
 }
 
 // +kubebuilder:validation:Enum=ingress;route
@@ -734,6 +860,19 @@ type ActiveMQArtemisStatus struct {
 
 	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Upgrade Status"
 	Upgrade UpgradeStatus `json:"upgrade,omitempty"`
+
+	// Current state of the primary/backup pairs
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="High Availability Pairs Status"
+	HA []HAPairStatus `json:"ha,omitempty"`
+}
+
+type HAPairStatus struct {
+	// Name of the pair, pair-<index>
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Name",xDescriptors="urn:alm:descriptor:text"
+	Name string `json:"name"`
+	// The pod of the pair that is currently live, empty when neither broker of the pair reports as live
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Live",xDescriptors="urn:alm:descriptor:text"
+	Live string `json:"live,omitempty"`
 }
 
 type VersionStatus struct {
@@ -824,6 +963,9 @@ const (
 	ValidConditionFailedInvalidExposeMode      = "InvalidExposeMode"
 	ValidConditionFailedInvalidIngressSettings = "InvalidIngressSettings"
 	ValidConditionInvalidCertSecretReason      = "InvalidCertSecret"
+	ValidConditionFailedInvalidHA              = "InvalidHA"
+	ValidConditionFailedInvalidRouting         = "InvalidRouting"
+	ValidConditionFailedInvalidStorage         = "InvalidStorage"
 
 	ReadyConditionType      = "Ready"
 	ReadyConditionReason    = "ResourceReady"
@@ -842,4 +984,9 @@ const (
 	BrokerVersionAlignedConditionType           = "BrokerVersionAligned"
 	BrokerVersionAlignedConditionMatchReason    = "VersionMatch"
 	BrokerVersionAlignedConditionMismatchReason = "VersionMismatch"
+
+	StorageExpandedConditionType               = "StorageExpanded"
+	StorageExpandedConditionCompleteReason     = "VolumesExpanded"
+	StorageExpandedConditionInProgressReason   = "ExpansionInProgress"
+	StorageExpandedConditionNotSupportedReason = "ExpansionNotSupported"
 )
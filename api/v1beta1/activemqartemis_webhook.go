@@ -17,7 +17,13 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -27,6 +33,32 @@ import (
 // log is for logging in this package.
 var activemqartemislog = logf.Log.WithName("activemqartemis-webhookv1beta1")
 
+// ActiveMQArtemisSpecValidator applies the checks that drive the Valid status
+// condition at admission time. The checks live with the controller, so the
+// implementation is registered from there when the webhooks are set up.
+// +kubebuilder:object:generate=false
+type ActiveMQArtemisSpecValidator interface {
+	// Validate returns an error for a spec that can never be reconciled and
+	// warnings for problems that depend on the state of the cluster
+	Validate(cr *ActiveMQArtemis) (warnings admission.Warnings, err error)
+}
+
+var activemqartemisSpecValidator ActiveMQArtemisSpecValidator
+
+// AllowDestructiveUpdateAnnotation, when set to "true" by an update,
+// acknowledges that the update may lose broker data and lets it through the
+// checks in ValidateUpdate. The acknowledgement is one-shot: an annotation
+// already set on the old object does not count, so it has to be removed and
+// set again for the next destructive update.
+const AllowDestructiveUpdateAnnotation = "broker.amq.io/allow-destructive-update"
+
+// the capacity used for the broker volume claim when storage.size is not set
+const defaultStorageSize = "2Gi"
+
+func SetActiveMQArtemisSpecValidator(validator ActiveMQArtemisSpecValidator) {
+	activemqartemisSpecValidator = validator
+}
+
 func (r *ActiveMQArtemis) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
@@ -55,16 +87,35 @@ var _ webhook.Validator = &ActiveMQArtemis{}
 func (r *ActiveMQArtemis) ValidateCreate() (warnings admission.Warnings, err error) {
 	activemqartemislog.V(1).Info("validate create", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object creation.
-	return nil, nil
+	return r.validateSpec()
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *ActiveMQArtemis) ValidateUpdate(old runtime.Object) (warnings admission.Warnings, err error) {
 	activemqartemislog.V(1).Info("validate update", "name", r.Name)
 
-	// TODO(user): fill in your validation logic upon object update.
-	return nil, nil
+	oldCr, ok := old.(*ActiveMQArtemis)
+	if !ok {
+		return nil, fmt.Errorf("expected an ActiveMQArtemis but got a %T", old)
+	}
+
+	invalid, destructive, warnings := r.validateUpdateFrom(oldCr)
+	if len(destructive) > 0 && !acknowledgesDestructiveUpdate(oldCr, r) {
+		invalid = append(invalid, destructive...)
+	}
+	if len(invalid) > 0 {
+		return warnings, apierrors.NewInvalid(GroupVersion.WithKind("ActiveMQArtemis").GroupKind(), r.Name, invalid)
+	}
+	if len(destructive) > 0 {
+		activemqartemislog.Info("accepting destructive update", "name", r.Name, "changes", destructive.ToAggregate().Error())
+		for _, change := range destructive {
+			warnings = append(warnings, fmt.Sprintf("%s acknowledged: %s", AllowDestructiveUpdateAnnotation, change.Error()))
+		}
+		warnings = append(warnings, fmt.Sprintf("remove the %s annotation, it only acknowledges the update that sets it", AllowDestructiveUpdateAnnotation))
+	}
+
+	specWarnings, err := r.validateSpec()
+	return append(warnings, specWarnings...), err
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -74,3 +125,140 @@ func (r *ActiveMQArtemis) ValidateDelete() (warnings admission.Warnings, err err
 	// TODO(user): fill in your validation logic upon object deletion.
 	return nil, nil
 }
+
+func (r *ActiveMQArtemis) validateSpec() (warnings admission.Warnings, err error) {
+	if activemqartemisSpecValidator == nil {
+		activemqartemislog.V(1).Info("no spec validator registered, skipping", "name", r.Name)
+		return nil, nil
+	}
+	return activemqartemisSpecValidator.Validate(r)
+}
+
+// acknowledgesDestructiveUpdate is true when the update sets the annotation,
+// leaving it in place does not acknowledge later updates
+func acknowledgesDestructiveUpdate(old *ActiveMQArtemis, new *ActiveMQArtemis) bool {
+	return new.Annotations[AllowDestructiveUpdateAnnotation] == "true" && old.Annotations[AllowDestructiveUpdateAnnotation] != "true"
+}
+
+// validateUpdateFrom compares the spec with the one it replaces and reports the
+// changes that can't be applied to the existing volumes, whatever the
+// annotation says, and the changes that would lose data
+func (r *ActiveMQArtemis) validateUpdateFrom(old *ActiveMQArtemis) (invalid field.ErrorList, destructive field.ErrorList, warnings admission.Warnings) {
+	path := field.NewPath("spec", "deploymentPlan")
+	oldPlan := &old.Spec.DeploymentPlan
+	newPlan := &r.Spec.DeploymentPlan
+
+	if oldPlan.PersistenceEnabled != newPlan.PersistenceEnabled {
+		if oldPlan.PersistenceEnabled {
+			destructive = append(destructive, field.Forbidden(path.Child("persistenceEnabled"), "disabling persistence discards the broker volumes and the messages stored in them"))
+		} else {
+			destructive = append(destructive, field.Forbidden(path.Child("persistenceEnabled"), "enabling persistence restarts the brokers on new volumes, messages in the current journal are lost"))
+		}
+	}
+
+	if normalizedJournalType(oldPlan.JournalType) != normalizedJournalType(newPlan.JournalType) {
+		destructive = append(destructive, field.Forbidden(path.Child("journalType"), fmt.Sprintf("changing the journal type from %s to %s restarts the brokers with a different journal", normalizedJournalType(oldPlan.JournalType), normalizedJournalType(newPlan.JournalType))))
+	}
+
+	haPath := field.NewPath("spec", "ha")
+	if haPolicyOrNone(old.Spec.HA) != haPolicyOrNone(r.Spec.HA) {
+		destructive = append(destructive, field.Forbidden(haPath, fmt.Sprintf("changing high availability from %s to %s gives brokers a new role, a backup replaces its journal with the one of its primary", haPolicyOrNone(old.Spec.HA), haPolicyOrNone(r.Spec.HA))))
+	} else if r.Spec.HA != nil && old.Spec.HA.SharedStoreClaimName != r.Spec.HA.SharedStoreClaimName {
+		destructive = append(destructive, field.Forbidden(haPath.Child("sharedStoreClaimName"), "changing the shared store restarts the pairs on a different journal"))
+	}
+
+	if !oldPlan.PersistenceEnabled || !newPlan.PersistenceEnabled {
+		return invalid, destructive, warnings
+	}
+
+	storagePath := path.Child("storage")
+	invalid, warnings = validateVolumeUpdate(storagePath, &oldPlan.Storage, &newPlan.Storage, nil, nil, invalid, warnings)
+
+	for _, directory := range []struct {
+		name     string
+		old, new *DirectoryStorageType
+	}{
+		{"journal", oldPlan.Storage.Journal, newPlan.Storage.Journal},
+		{"bindings", oldPlan.Storage.Bindings, newPlan.Storage.Bindings},
+		{"paging", oldPlan.Storage.Paging, newPlan.Storage.Paging},
+		{"largeMessages", oldPlan.Storage.LargeMessages, newPlan.Storage.LargeMessages},
+	} {
+		if directory.old == nil && directory.new == nil {
+			continue
+		}
+		directoryPath := storagePath.Child(directory.name)
+		if directory.old == nil || directory.new == nil {
+			destructive = append(destructive, field.Forbidden(directoryPath, "moving a broker directory on or off a separate volume leaves its current contents behind"))
+			continue
+		}
+		invalid, warnings = validateVolumeUpdate(directoryPath, &oldPlan.Storage, &newPlan.Storage, directory.old, directory.new, invalid, warnings)
+	}
+
+	return invalid, destructive, warnings
+}
+
+// validateVolumeUpdate checks the size and class of the data volume, or of a
+// separate directory volume when old and new directory storage are given. The
+// claims of the existing brokers keep their class and can't shrink, so these
+// changes are invalid rather than destructive
+func validateVolumeUpdate(path *field.Path, oldStorage *StorageType, newStorage *StorageType, oldDirectory *DirectoryStorageType, newDirectory *DirectoryStorageType, invalid field.ErrorList, warnings admission.Warnings) (field.ErrorList, admission.Warnings) {
+	oldSizeValue, oldClass := oldStorage.Size, oldStorage.StorageClassName
+	newSizeValue, newClass := newStorage.Size, newStorage.StorageClassName
+	if oldDirectory != nil && newDirectory != nil {
+		if oldDirectory.Size != "" {
+			oldSizeValue = oldDirectory.Size
+		}
+		if oldDirectory.StorageClassName != "" {
+			oldClass = oldDirectory.StorageClassName
+		}
+		if newDirectory.Size != "" {
+			newSizeValue = newDirectory.Size
+		}
+		if newDirectory.StorageClassName != "" {
+			newClass = newDirectory.StorageClassName
+		}
+	}
+
+	if oldClass != newClass {
+		invalid = append(invalid, field.Forbidden(path.Child("storageClassName"), "the storage class of existing broker volumes can't be changed"))
+	}
+
+	oldSize, oldErr := resource.ParseQuantity(storageSizeOrDefault(oldSizeValue))
+	newSize, newErr := resource.ParseQuantity(storageSizeOrDefault(newSizeValue))
+	if newErr != nil {
+		invalid = append(invalid, field.Invalid(path.Child("size"), newSizeValue, newErr.Error()))
+	} else if oldErr == nil {
+		switch newSize.Cmp(oldSize) {
+		case -1:
+			invalid = append(invalid, field.Forbidden(path.Child("size"), fmt.Sprintf("broker volumes can't shrink from %s to %s", oldSize.String(), newSize.String())))
+		case 1:
+			warnings = append(warnings, fmt.Sprintf("%s: broker volumes are expanded from %s to %s only if their storage class allows volume expansion", path.Child("size"), oldSize.String(), newSize.String()))
+		}
+	}
+
+	return invalid, warnings
+}
+
+func haPolicyOrNone(ha *HAType) string {
+	if ha == nil {
+		return "none"
+	}
+	if ha.Policy == "" {
+		return string(HAPolicyReplication)
+	}
+	return string(ha.Policy)
+}
+
+func normalizedJournalType(journalType string) string {
+	if strings.ToLower(journalType) == "aio" {
+		return "aio"
+	}
+	return "nio"
+}
+
+func storageSizeOrDefault(size string) string {
+	if size == "" {
+		return defaultStorageSize
+	}
+	return size
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newPersistentCr() *ActiveMQArtemis {
+	return &ActiveMQArtemis{
+		ObjectMeta: metav1.ObjectMeta{Name: "broker", Namespace: "test"},
+		Spec: ActiveMQArtemisSpec{
+			DeploymentPlan: DeploymentPlanType{
+				PersistenceEnabled: true,
+				JournalType:        "aio",
+				Storage:            StorageType{Size: "4Gi", StorageClassName: "standard"},
+			},
+		},
+	}
+}
+
+func TestValidateUpdateAcceptsSafeChange(t *testing.T) {
+	old := newPersistentCr()
+	cr := old.DeepCopy()
+	cr.Spec.DeploymentPlan.Size = &[]int32{3}[0]
+
+	warnings, err := cr.ValidateUpdate(old)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestValidateUpdateRejectsDestructiveChanges(t *testing.T) {
+	for name, mutate := range map[string]func(*ActiveMQArtemis){
+		"persistenceDisabled": func(cr *ActiveMQArtemis) { cr.Spec.DeploymentPlan.PersistenceEnabled = false },
+		"journalType":         func(cr *ActiveMQArtemis) { cr.Spec.DeploymentPlan.JournalType = "nio" },
+		"storageClassName":    func(cr *ActiveMQArtemis) { cr.Spec.DeploymentPlan.Storage.StorageClassName = "fast" },
+		"storageShrink":       func(cr *ActiveMQArtemis) { cr.Spec.DeploymentPlan.Storage.Size = "1Gi" },
+		"storageInvalid":      func(cr *ActiveMQArtemis) { cr.Spec.DeploymentPlan.Storage.Size = "lots" },
+	} {
+		old := newPersistentCr()
+		cr := old.DeepCopy()
+		mutate(cr)
+
+		_, err := cr.ValidateUpdate(old)
+		assert.True(t, apierrors.IsInvalid(err), "%s: expected an invalid error, got %v", name, err)
+	}
+}
+
+func TestValidateUpdateTreatsEmptyValuesAsDefaults(t *testing.T) {
+	old := newPersistentCr()
+	old.Spec.DeploymentPlan.JournalType = ""
+	old.Spec.DeploymentPlan.Storage.Size = ""
+	cr := old.DeepCopy()
+	cr.Spec.DeploymentPlan.JournalType = "NIO"
+	cr.Spec.DeploymentPlan.Storage.Size = "2Gi"
+
+	warnings, err := cr.ValidateUpdate(old)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+}
+
+func TestValidateUpdateWarnsOnStorageGrowth(t *testing.T) {
+	old := newPersistentCr()
+	cr := old.DeepCopy()
+	cr.Spec.DeploymentPlan.Storage.Size = "8Gi"
+
+	warnings, err := cr.ValidateUpdate(old)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "spec.deploymentPlan.storage.size")
+}
+
+func TestValidateUpdateIgnoresStorageWithoutPersistence(t *testing.T) {
+	old := newPersistentCr()
+	old.Spec.DeploymentPlan.PersistenceEnabled = false
+	cr := old.DeepCopy()
+	cr.Spec.DeploymentPlan.Storage.Size = "1Gi"
+	cr.Spec.DeploymentPlan.Storage.StorageClassName = "fast"
+
+	_, err := cr.ValidateUpdate(old)
+	assert.NoError(t, err)
+}
+
+func TestValidateUpdateAllowsAcknowledgedDestructiveChange(t *testing.T) {
+	old := newPersistentCr()
+	cr := old.DeepCopy()
+	cr.Spec.DeploymentPlan.PersistenceEnabled = false
+	cr.Annotations = map[string]string{AllowDestructiveUpdateAnnotation: "true"}
+
+	warnings, err := cr.ValidateUpdate(old)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], AllowDestructiveUpdateAnnotation)
+	assert.Contains(t, warnings[1], "remove the "+AllowDestructiveUpdateAnnotation)
+}
+
+func TestValidateUpdateRejectsVolumeChangesDespiteTheAnnotation(t *testing.T) {
+	for name, mutate := range map[string]func(*ActiveMQArtemis){
+		"storageClassName": func(cr *ActiveMQArtemis) { cr.Spec.DeploymentPlan.Storage.StorageClassName = "fast" },
+		"storageShrink":    func(cr *ActiveMQArtemis) { cr.Spec.DeploymentPlan.Storage.Size = "1Gi" },
+		"storageInvalid":   func(cr *ActiveMQArtemis) { cr.Spec.DeploymentPlan.Storage.Size = "lots" },
+	} {
+		old := newPersistentCr()
+		cr := old.DeepCopy()
+		cr.Annotations = map[string]string{AllowDestructiveUpdateAnnotation: "true"}
+		mutate(cr)
+
+		_, err := cr.ValidateUpdate(old)
+		assert.True(t, apierrors.IsInvalid(err), "%s: expected an invalid error, got %v", name, err)
+	}
+}
+
+func TestValidateUpdateAcknowledgesOnlyTheUpdateSettingTheAnnotation(t *testing.T) {
+	old := newPersistentCr()
+	old.Annotations = map[string]string{AllowDestructiveUpdateAnnotation: "true"}
+	cr := old.DeepCopy()
+	cr.Spec.DeploymentPlan.JournalType = "nio"
+
+	_, err := cr.ValidateUpdate(old)
+	assert.True(t, apierrors.IsInvalid(err))
+}
+
+func TestValidateUpdateRejectsMovingDirectoryVolume(t *testing.T) {
+	old := newPersistentCr()
+	cr := old.DeepCopy()
+	cr.Spec.DeploymentPlan.Storage.Journal = &DirectoryStorageType{Size: "1Gi", StorageClassName: "ssd"}
+
+	_, err := cr.ValidateUpdate(old)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "spec.deploymentPlan.storage.journal")
+}
+
+func TestValidateUpdateChecksDirectoryVolumeWithDefaults(t *testing.T) {
+	old := newPersistentCr()
+	old.Spec.DeploymentPlan.Storage.Paging = &DirectoryStorageType{}
+
+	cr := old.DeepCopy()
+	cr.Spec.DeploymentPlan.Storage.Paging.StorageClassName = "standard"
+	cr.Spec.DeploymentPlan.Storage.Paging.Size = "8Gi"
+	warnings, err := cr.ValidateUpdate(old)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "spec.deploymentPlan.storage.paging.size")
+
+	cr = old.DeepCopy()
+	cr.Spec.DeploymentPlan.Storage.Paging.StorageClassName = "bulk"
+	_, err = cr.ValidateUpdate(old)
+	assert.True(t, apierrors.IsInvalid(err))
+}
+
+func TestValidateUpdateRejectsHAPolicyChange(t *testing.T) {
+	old := newPersistentCr()
+	cr := old.DeepCopy()
+	cr.Spec.HA = &HAType{}
+
+	_, err := cr.ValidateUpdate(old)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "from none to replication")
+
+	old.Spec.HA = &HAType{Policy: HAPolicyReplication}
+	_, err = cr.ValidateUpdate(old)
+	assert.NoError(t, err)
+
+	cr.Spec.HA.Policy = HAPolicySharedStore
+	_, err = cr.ValidateUpdate(old)
+	assert.True(t, apierrors.IsInvalid(err))
+	assert.Contains(t, err.Error(), "spec.ha")
+}
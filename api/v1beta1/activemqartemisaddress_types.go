@@ -52,6 +52,92 @@ type ActiveMQArtemisAddressSpec struct {
 	// Apply to the broker crs in the current namespace. A value of * or empty string means applying to all broker crs. Default apply to all broker crs
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Apply To Broker CR Names"
 	ApplyToCrNames []string `json:"applyToCrNames,omitempty"`
+	// Apply to the broker crs selected by labels, in the current namespace or in the namespaces selected by a namespace selector. Can't be used with applyToCrNames
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Broker Selector"
+	BrokerSelector *BrokerSelectorType `json:"brokerSelector,omitempty"`
+	// How the address or queue gets to the brokers. Jolokia, the default, creates it on the running brokers through the management api. BrokerProperties configures it in the broker properties of the target brokers so that they start with it
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Delivery Mode",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	DeliveryMode *AddressDeliveryMode `json:"deliveryMode,omitempty"`
+	// More addresses, each with any number of queues, applied along with addressName and queueName when they are set
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Addresses"
+	Addresses []AddressEntryType `json:"addresses,omitempty"`
+	// Name of a ConfigMap in the namespace of the CR with more addresses, its addresses.yaml key holds a list of addresses in the format of the addresses field
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Addresses ConfigMap",xDescriptors={"urn:alm:descriptor:io.kubernetes:ConfigMap"}
+	AddressesConfigMap string `json:"addressesConfigMap,omitempty"`
+	// The number of addresses and queues applied to the brokers in one go, the rest follow in the next batches (default 50)
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Batch Size",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	BatchSize *int32 `json:"batchSize,omitempty"`
+	// How the queues are removed from the brokers when the CR is deleted with removeFromBrokerOnDelete, the CR is kept until they are
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Delete Policy"
+	DeletePolicy *AddressDeletePolicyType `json:"deletePolicy,omitempty"`
+}
+
+type AddressDeletePolicyType struct {
+	// Force removes the queues whatever they hold, the default. OnlyIfEmpty waits for them to be empty, MoveMessagesTo moves their messages to the moveMessagesTo address first and WaitForConsumersToDrain waits for their consumers to take their messages, up to the timeout
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Policy",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Policy AddressDeletePolicy `json:"policy,omitempty"`
+	// The address the messages are moved to with MoveMessagesTo, it needs a queue on the brokers
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Move Messages To",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	MoveMessagesTo string `json:"moveMessagesTo,omitempty"`
+	// How long WaitForConsumersToDrain and MoveMessagesTo wait for the queues to be empty before they are removed anyway, e.g. 30m (default 10m)
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Timeout",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Force;OnlyIfEmpty;MoveMessagesTo;WaitForConsumersToDrain
+type AddressDeletePolicy string
+
+var AddressDeletePolicies = struct {
+	Force                   AddressDeletePolicy
+	OnlyIfEmpty             AddressDeletePolicy
+	MoveMessagesTo          AddressDeletePolicy
+	WaitForConsumersToDrain AddressDeletePolicy
+}{
+	Force:                   "Force",
+	OnlyIfEmpty:             "OnlyIfEmpty",
+	MoveMessagesTo:          "MoveMessagesTo",
+	WaitForConsumersToDrain: "WaitForConsumersToDrain",
+}
+
+type AddressEntryType struct {
+	// The Address Name
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Address Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	AddressName string `json:"addressName"`
+	// The Routing Type of the address and its queues
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Routing Type",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	RoutingType *string `json:"routingType,omitempty"`
+	// The queues of the address
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Queues"
+	Queues []QueueEntryType `json:"queues,omitempty"`
+}
+
+type QueueEntryType struct {
+	// The Queue Name
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Queue Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	QueueName string `json:"queueName"`
+	// Specify the queue configuration
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Queue Configuration"
+	QueueConfiguration *QueueConfigurationType `json:"queueConfiguration,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Jolokia;BrokerProperties
+type AddressDeliveryMode string
+
+var AddressDeliveryModes = struct {
+	Jolokia          AddressDeliveryMode
+	BrokerProperties AddressDeliveryMode
+}{
+	Jolokia:          "Jolokia",
+	BrokerProperties: "BrokerProperties",
+}
+
+type BrokerSelectorType struct {
+	// Label selector of the broker crs, all the broker crs of the selected namespaces when not set
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Label Selector",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:selector:broker.amq.io:v1beta1:ActiveMQArtemis"}
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	// Label selector of the namespaces of the broker crs, the current namespace when not set. Only the namespaces the operator allows with ADDRESS_TARGET_NAMESPACES are selected
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Namespace Selector",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:selector:core:v1:Namespace"}
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
 }
 
 type QueueConfigurationType struct {
@@ -137,10 +223,103 @@ type QueueConfigurationType struct {
 
 // ActiveMQArtemisAddressStatus defines the observed state of ActiveMQArtemisAddress
 type ActiveMQArtemisAddressStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+
+	// Current state of the resource
+	// Conditions represent the latest available observations of an object's state
+	//+optional
+	//+patchMergeKey=type
+	//+patchStrategy=merge
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions",xDescriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,2,rep,name=conditions"`
+
+	// The result of applying the address or queue to each target broker
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Brokers"
+	Brokers []AddressBrokerStatus `json:"brokers,omitempty"`
+
+	// The number of target brokers the address or queue is applied to
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Applied Count"
+	AppliedCount int32 `json:"appliedCount"`
+
+	// The number of target brokers
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Broker Count"
+	BrokerCount int32 `json:"brokerCount"`
+
+	// The result of each address and queue when the CR has more than addressName and queueName
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Entries"
+	Entries []AddressEntryStatus `json:"entries,omitempty"`
+
+	// The progress of the removal from the brokers once the CR is deleted
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Deletion"
+	Deletion *AddressDeletionStatus `json:"deletion,omitempty"`
+}
+
+type AddressDeletionStatus struct {
+	// The delete policy being applied
+	Policy AddressDeletePolicy `json:"policy"`
+	// Waiting until the policy is satisfied or Failed, retried until the queues are removed
+	State string `json:"state"`
+	// What the removal waits for or the error
+	Message string `json:"message,omitempty"`
+	// When the removal started
+	StartTime metav1.Time `json:"startTime"`
+	// Messages left in the queues on the brokers
+	MessageCount int64 `json:"messageCount"`
+	// Consumers left on the queues on the brokers
+	ConsumerCount int64 `json:"consumerCount"`
 }
 
+type AddressEntryStatus struct {
+	// The Address Name
+	AddressName string `json:"addressName"`
+	// The Queue Name, empty for an address without queues
+	QueueName string `json:"queueName,omitempty"`
+	// Applied when applied to all the target brokers, Failed or Pending
+	Result string `json:"result"`
+	// The number of target brokers the address or queue is applied to
+	AppliedCount int32 `json:"appliedCount"`
+	// The broker error code of the first failure
+	ErrorCode string `json:"errorCode,omitempty"`
+	// The error of the first failure
+	Message string `json:"message,omitempty"`
+}
+
+type AddressBrokerStatus struct {
+	// Name of the ActiveMQArtemis CR of the broker
+	CrName string `json:"crName"`
+	// Ordinal of the broker
+	Ordinal string `json:"ordinal"`
+	// Applied, Failed or, when delivered with broker properties, Pending
+	Result string `json:"result"`
+	// The broker error code of the failure, e.g. AMQ229019, or AMQ_UNKNOWN
+	ErrorCode string `json:"errorCode,omitempty"`
+	// The error returned by the broker
+	Message string `json:"message,omitempty"`
+	// When the address or queue was last applied to the broker
+	LastAttemptTime metav1.Time `json:"lastAttemptTime"`
+	// Messages in the queue, or in all the queues of the address
+	MessageCount *int64 `json:"messageCount,omitempty"`
+	// Consumers of the queue
+	ConsumerCount *int64 `json:"consumerCount,omitempty"`
+}
+
+const (
+	AddressBrokerApplied = "Applied"
+	AddressBrokerFailed  = "Failed"
+	// the broker properties are not yet loaded by the broker
+	AddressBrokerPending = "Pending"
+
+	AddressAppliedConditionType            = "Applied"
+	AddressAppliedConditionSuccessReason   = "AppliedToAllBrokers"
+	AddressAppliedConditionFailedReason    = "ApplyFailed"
+	AddressAppliedConditionNoBrokersReason = "NoTargetBrokers"
+	AddressAppliedConditionPendingReason   = "Pending"
+
+	ValidConditionInvalidAddressReason = "InvalidAddress"
+
+	AddressDeletionWaiting = "Waiting"
+	AddressDeletionFailed  = "Failed"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:storageversion
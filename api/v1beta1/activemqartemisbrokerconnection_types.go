@@ -0,0 +1,169 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ActiveMQArtemisBrokerConnectionSpec defines the desired state of ActiveMQArtemisBrokerConnection
+type ActiveMQArtemisBrokerConnectionSpec struct {
+
+	// Name of the ActiveMQArtemis CR, in the same namespace, whose brokers open the connection
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Source CR Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	SourceCrName string `json:"sourceCrName"`
+
+	// The broker or brokers the connection is opened to
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Target"
+	Target BrokerConnectionTargetType `json:"target"`
+
+	// What is mirrored to the target
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Mirror"
+	Mirror MirrorType `json:"mirror,omitempty"`
+
+	// Milliseconds between attempts to reconnect to the target, the broker default is 5000
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Retry Interval",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	RetryInterval *int32 `json:"retryInterval,omitempty"`
+
+	// Attempts to reconnect to the target before giving up, -1 (the broker default) retries forever
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Reconnect Attempts",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	ReconnectAttempts *int32 `json:"reconnectAttempts,omitempty"`
+}
+
+type BrokerConnectionTargetType struct {
+	// Name of the ActiveMQArtemis CR, in the same namespace, to connect to. Each source broker connects to the target broker with the same ordinal
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="CR Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	CrName string `json:"crName,omitempty"`
+
+	// Name of the target CR acceptor to connect to, it must accept AMQP. Required with crName
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Acceptor Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	AcceptorName string `json:"acceptorName,omitempty"`
+
+	// The host:port of a broker that is not managed by a CR in this namespace, used instead of crName
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Endpoint",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Name of a secret with the user and password keys for the target. Defaults to the credentials secret of the target CR
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Credentials Secret",xDescriptors={"urn:alm:descriptor:io.kubernetes:Secret"}
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+
+	// Whether to connect with TLS. For a target CR it follows sslEnabled of the acceptor
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="SSL Enabled",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	SSLEnabled bool `json:"sslEnabled,omitempty"`
+
+	// Name of a secret holding the trust store for the target, either a trust-manager bundle or a client.ts with its trustStorePassword. Required for TLS
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Trust Secret",xDescriptors={"urn:alm:descriptor:io.kubernetes:Secret"}
+	TrustSecret string `json:"trustSecret,omitempty"`
+
+	// Type of the trust store, PEM is used for a bundle and the default otherwise is JKS
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Trust Store Type",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	TrustStoreType string `json:"trustStoreType,omitempty"`
+}
+
+type MirrorType struct {
+	// Whether acknowledgements are mirrored, the broker default is true
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Message Acknowledgements",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	MessageAcknowledgements *bool `json:"messageAcknowledgements,omitempty"`
+
+	// Whether queues created on the source are created on the target, the broker default is true
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Queue Creation",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	QueueCreation *bool `json:"queueCreation,omitempty"`
+
+	// Whether queues removed from the source are removed from the target, the broker default is true
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Queue Removal",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	QueueRemoval *bool `json:"queueRemoval,omitempty"`
+
+	// Comma separated address prefixes that are mirrored, a prefix starting with ! is excluded. All addresses are mirrored by default
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Address Filter",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	AddressFilter string `json:"addressFilter,omitempty"`
+
+	// Whether a send to the source waits for the target to store the message, the broker default is false
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Sync",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	Sync *bool `json:"sync,omitempty"`
+}
+
+// ActiveMQArtemisBrokerConnectionStatus defines the observed state of ActiveMQArtemisBrokerConnection
+type ActiveMQArtemisBrokerConnectionStatus struct {
+
+	// Current state of the resource
+	// Conditions represent the latest available observations of an object's state
+	//+optional
+	//+patchMergeKey=type
+	//+patchStrategy=merge
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions",xDescriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,2,rep,name=conditions"`
+
+	// The connection of each source broker
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Brokers"
+	Brokers []BrokerConnectionStatus `json:"brokers,omitempty"`
+}
+
+type BrokerConnectionStatus struct {
+	// Ordinal of the source broker
+	Ordinal string `json:"ordinal"`
+	// The host:port the broker connects to
+	Target string `json:"target,omitempty"`
+	// Started, Stopped, NoTarget when the target CR has no broker with this ordinal, NotConfigured or Unknown
+	State string `json:"state"`
+	// Messages waiting on the source to be mirrored
+	PendingMessages *int64 `json:"pendingMessages,omitempty"`
+}
+
+const (
+	BrokerConnectionStarted       = "Started"
+	BrokerConnectionStopped       = "Stopped"
+	BrokerConnectionNoTarget      = "NoTarget"
+	BrokerConnectionNotConfigured = "NotConfigured"
+	BrokerConnectionUnknown       = "Unknown"
+
+	ConnectedConditionType             = "Connected"
+	ConnectedConditionStartedReason    = "AllConnectionsStarted"
+	ConnectedConditionNotStartedReason = "ConnectionsNotStarted"
+	ConnectedConditionUnknownReason    = "UnableToRetrieveStatus"
+
+	ValidConditionSourceNotFoundReason = "SourceNotFound"
+	ValidConditionInvalidTargetReason  = "InvalidTarget"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:storageversion
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=activemqartemisbrokerconnections,shortName=aabc
+//+operator-sdk:csv:customresourcedefinitions:resources={{"Secret", "v1"}}
+
+// Mirrors the messages of the brokers of one ActiveMQArtemis CR to another broker over AMQP
+// +operator-sdk:csv:customresourcedefinitions:displayName="ActiveMQ Artemis Broker Connection"
+type ActiveMQArtemisBrokerConnection struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ActiveMQArtemisBrokerConnectionSpec   `json:"spec,omitempty"`
+	Status ActiveMQArtemisBrokerConnectionStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ActiveMQArtemisBrokerConnectionList contains a list of ActiveMQArtemisBrokerConnection
+type ActiveMQArtemisBrokerConnectionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ActiveMQArtemisBrokerConnection `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ActiveMQArtemisBrokerConnection{}, &ActiveMQArtemisBrokerConnectionList{})
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ActiveMQArtemisFederationSpec defines the desired state of ActiveMQArtemisFederation
+type ActiveMQArtemisFederationSpec struct {
+
+	// Apply to the broker crs in the current namespace. A value of * or empty string means applying to all broker crs. Default apply to all broker crs
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Apply To CR Names"
+	ApplyToCrNames []string `json:"applyToCrNames,omitempty"`
+
+	// Brokers the federated brokers consume messages from
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Upstreams"
+	Upstreams []FederationUpstreamType `json:"upstreams,omitempty"`
+
+	// Brokers that are told to consume messages from the federated brokers
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Downstreams"
+	Downstreams []FederationDownstreamType `json:"downstreams,omitempty"`
+
+	// Policies that federate addresses, the messages sent to a matching address upstream are also sent to it downstream
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Address Policies"
+	AddressPolicies []FederationAddressPolicyType `json:"addressPolicies,omitempty"`
+
+	// Policies that federate queues, the consumers of a matching queue downstream also consume from it upstream
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Queue Policies"
+	QueuePolicies []FederationQueuePolicyType `json:"queuePolicies,omitempty"`
+}
+
+type FederationUpstreamType struct {
+	// Name of the link, unique in the federation
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Name string `json:"name"`
+
+	// Name of the ActiveMQArtemis CR, in the same namespace, whose brokers are linked to
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="CR Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	CrName string `json:"crName,omitempty"`
+
+	// Name of the acceptor of the CR to connect to, it must accept CORE. Required with crName
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Acceptor Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	AcceptorName string `json:"acceptorName,omitempty"`
+
+	// Name of a Service, in the same namespace, to connect to, used instead of crName
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Service Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	ServiceName string `json:"serviceName,omitempty"`
+
+	// Port of the Service to connect to, defaults to the first port of the Service
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Service Port",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	ServicePort int32 `json:"servicePort,omitempty"`
+
+	// The host:port of brokers that are not managed in this namespace, used instead of crName
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Endpoints"
+	Endpoints []string `json:"endpoints,omitempty"`
+
+	// Name of a secret with the user and password keys for the link. Defaults to the credentials secret of the CR
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Credentials Secret",xDescriptors={"urn:alm:descriptor:io.kubernetes:Secret"}
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+
+	// Whether to connect with TLS. For a CR it follows sslEnabled of the acceptor
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="SSL Enabled",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	SSLEnabled bool `json:"sslEnabled,omitempty"`
+
+	// Name of a secret holding the trust store for the link, either a trust-manager bundle or a client.ts with its trustStorePassword. Required for TLS
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Trust Secret",xDescriptors={"urn:alm:descriptor:io.kubernetes:Secret"}
+	TrustSecret string `json:"trustSecret,omitempty"`
+
+	// Type of the trust store, PEM is used for a bundle and the default otherwise is JKS
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Trust Store Type",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	TrustStoreType string `json:"trustStoreType,omitempty"`
+
+	// Names of the address and queue policies used over the link, all of them by default
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Policy Refs"
+	PolicyRefs []string `json:"policyRefs,omitempty"`
+
+	// Attempts to reconnect before giving up, -1 retries forever
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Reconnect Attempts",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	ReconnectAttempts *int32 `json:"reconnectAttempts,omitempty"`
+}
+
+type FederationDownstreamType struct {
+	FederationUpstreamType `json:",inline"`
+
+	// Name of the acceptor of the federated brokers that the downstream brokers connect back to, it must accept CORE
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Upstream Acceptor Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	UpstreamAcceptorName string `json:"upstreamAcceptorName"`
+}
+
+type FederationAddressPolicyType struct {
+	// Name of the policy, unique in the federation
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Name string `json:"name"`
+
+	// Match patterns of the addresses that are federated
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Includes"
+	Includes []string `json:"includes,omitempty"`
+
+	// Match patterns of the addresses that are not federated
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Excludes"
+	Excludes []string `json:"excludes,omitempty"`
+
+	// Number of links a message may cross, the broker default is 0 for no limit
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Max Hops",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	MaxHops *int32 `json:"maxHops,omitempty"`
+
+	// Whether the upstream queue is deleted once the link is down, the broker default is false
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Auto Delete",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	AutoDelete *bool `json:"autoDelete,omitempty"`
+
+	// Milliseconds the link is down before the upstream queue is deleted
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Auto Delete Delay",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	AutoDeleteDelay *int64 `json:"autoDeleteDelay,omitempty"`
+
+	// Messages the upstream queue may hold and still be deleted
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Auto Delete Message Count",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	AutoDeleteMessageCount *int64 `json:"autoDeleteMessageCount,omitempty"`
+
+	// Whether divert bindings are treated as demand for the address, the broker default is false
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Enable Divert Bindings",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	EnableDivertBindings *bool `json:"enableDivertBindings,omitempty"`
+}
+
+type FederationQueuePolicyType struct {
+	// Name of the policy, unique in the federation
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Name string `json:"name"`
+
+	// The queues that are federated
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Includes"
+	Includes []FederationQueueMatchType `json:"includes,omitempty"`
+
+	// The queues that are not federated
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Excludes"
+	Excludes []FederationQueueMatchType `json:"excludes,omitempty"`
+
+	// Whether queues that are themselves federated are federated again, the broker default is false
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Include Federated",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	IncludeFederated *bool `json:"includeFederated,omitempty"`
+
+	// Added to the priority of the downstream consumers when they consume upstream, the broker default is -1
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Priority Adjustment",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	PriorityAdjustment *int32 `json:"priorityAdjustment,omitempty"`
+}
+
+type FederationQueueMatchType struct {
+	// Match pattern of the address of the queue
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Address Match",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	AddressMatch string `json:"addressMatch,omitempty"`
+
+	// Match pattern of the queue name
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Queue Match",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	QueueMatch string `json:"queueMatch,omitempty"`
+}
+
+// ActiveMQArtemisFederationStatus defines the observed state of ActiveMQArtemisFederation
+type ActiveMQArtemisFederationStatus struct {
+
+	// Current state of the resource, with a LinkUp-<name> condition for each upstream and downstream
+	// Conditions represent the latest available observations of an object's state
+	//+optional
+	//+patchMergeKey=type
+	//+patchStrategy=merge
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions",xDescriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,2,rep,name=conditions"`
+
+	// Names of the ActiveMQArtemis CRs whose brokers are federated
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Federated CRs"
+	FederatedCrs []string `json:"federatedCrs,omitempty"`
+}
+
+const (
+	LinkUpConditionTypePrefix          = "LinkUp-"
+	LinkUpConditionConnectedReason     = "Connected"
+	LinkUpConditionNotConnectedReason  = "NotConnected"
+	LinkUpConditionNotObservableReason = "NotObservable"
+	LinkUpConditionUnknownReason       = "UnableToRetrieveStatus"
+
+	ValidConditionInvalidNameReason   = "InvalidName"
+	ValidConditionInvalidLinkReason   = "InvalidLink"
+	ValidConditionInvalidPolicyReason = "InvalidPolicy"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:storageversion
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=activemqartemisfederations,shortName=aaf
+//+operator-sdk:csv:customresourcedefinitions:resources={{"Secret", "v1"}}
+
+// Federates addresses and queues of the brokers of ActiveMQArtemis CRs with other brokers
+// +operator-sdk:csv:customresourcedefinitions:displayName="ActiveMQ Artemis Federation"
+type ActiveMQArtemisFederation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ActiveMQArtemisFederationSpec   `json:"spec,omitempty"`
+	Status ActiveMQArtemisFederationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ActiveMQArtemisFederationList contains a list of ActiveMQArtemisFederation
+type ActiveMQArtemisFederationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ActiveMQArtemisFederation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ActiveMQArtemisFederation{}, &ActiveMQArtemisFederationList{})
+}
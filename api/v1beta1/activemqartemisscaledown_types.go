@@ -35,14 +35,116 @@ type ActiveMQArtemisScaledownSpec struct {
 	// Specifies the minimum/maximum amount of compute resources required/allowed
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Resource Requirements",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:resourceRequirements"}
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Customizes the drain pods, they otherwise take the image, tolerations, node selector, pull secrets and security contexts of the broker pods
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Drain Pod Template"
+	DrainPodTemplate *DrainPodTemplateType `json:"drainPodTemplate,omitempty"`
+	// Seconds a drain pod can run before it is stopped and its attempt fails, default no deadline
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Drain Deadline Seconds",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	DrainDeadlineSeconds *int64 `json:"drainDeadlineSeconds,omitempty"`
+	// How many times a failed drain is retried before the drain fails and the volumes of the ordinal are kept, default 3
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Drain Retries",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	DrainRetries *int32 `json:"drainRetries,omitempty"`
+	// Where the messages go when the statefulset is scaled down to zero, without it the volumes are left untouched then
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Drain Target"
+	DrainTarget *DrainTargetType `json:"drainTarget,omitempty"`
+}
+
+type DrainTargetType struct {
+	// Name of an ActiveMQArtemis CR in the namespace whose ready brokers take the messages
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Broker Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	BrokerName string `json:"brokerName,omitempty"`
+	// Address of a broker outside the namespace that takes the messages on a CORE acceptor, used when brokerName is not set. An IP address or a fully qualified host name, the operator resolves a host name to its first address when it starts a drain pod
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Host",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Host string `json:"host,omitempty"`
+	// Port of the CORE acceptor of the host, default 61616
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Port",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	Port int32 `json:"port,omitempty"`
+	// Name of a secret with the AMQ_CLUSTER_USER and AMQ_CLUSTER_PASSWORD keys the drain pods connect to the host with. Defaults to the cluster credentials of the drained deployment
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Credentials Secret",xDescriptors={"urn:alm:descriptor:io.kubernetes:Secret"}
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+type DrainPodTemplateType struct {
+	// Image of the drain pods, default the image of the broker pods
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Image",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Image string `json:"image,omitempty"`
+	// Compute resources of the drain pods, replaces the resources of the spec
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Resource Requirements",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:resourceRequirements"}
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Tolerations of the drain pods, added to the tolerations of the broker pods
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Tolerations"
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Node selector of the drain pods, merged over the node selector of the broker pods
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Node Selector",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:selector"}
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Security context of the drain pods, replaces the one of the broker pods
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Pod Security Context"
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+	// Security context of the drain container, replaces the one of the broker container
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Container Security Context"
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
+	// Environment variables of the drain container, a variable the operator sets is replaced
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Env"
+	Env []corev1.EnvVar `json:"env,omitempty"`
 }
 
 // ActiveMQArtemisScaledownStatus defines the observed state of ActiveMQArtemisScaledown
 type ActiveMQArtemisScaledownStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Current state of the resource
+	// Conditions represent the latest available observations of an object's state
+	//+optional
+	//+patchMergeKey=type
+	//+patchStrategy=merge
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions",xDescriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,2,rep,name=conditions"`
+
+	// The ordinals removed by a scale down whose messages are migrated to the remaining brokers
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Drains"
+	Drains []ScaledownDrainStatus `json:"drains,omitempty"`
+}
+
+type ScaledownDrainStatus struct {
+	// Ordinal of the removed broker
+	Ordinal int32 `json:"ordinal"`
+	// Name of the drain pod that migrates the messages of the ordinal
+	PodName string `json:"podName"`
+	// Phase of the drain pod
+	Phase corev1.PodPhase `json:"phase,omitempty"`
+	// When the drain pod was created
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// When the drain pod finished
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+	// The most messages the drain pod held, read through jolokia
+	MessagesToMigrate *int64 `json:"messagesToMigrate,omitempty"`
+	// How many of the messages the drain pod moved to the remaining brokers
+	MessagesMigrated *int64 `json:"messagesMigrated,omitempty"`
+	// The broker the drain pod migrates the messages to
+	Target string `json:"target,omitempty"`
+	// How many drain pods were started for the ordinal
+	Attempts int32 `json:"attempts,omitempty"`
+	// Pending while the drain waits for a broker to drain to, Draining, Retrying after a failed attempt, Succeeded once the volumes of the ordinal are removed, or Failed once the retries are used up
+	Result string `json:"result"`
+	// Why the last attempt failed
+	Message string `json:"message,omitempty"`
 }
 
+const (
+	ScaledownDrainPending   = "Pending"
+	ScaledownDrainDraining  = "Draining"
+	ScaledownDrainRetrying  = "Retrying"
+	ScaledownDrainSucceeded = "Succeeded"
+	ScaledownDrainFailed    = "Failed"
+
+	MigrationCompleteConditionType             = "MigrationComplete"
+	MigrationCompleteConditionCompleteReason   = "AllOrdinalsDrained"
+	MigrationCompleteConditionInProgressReason = "DrainInProgress"
+	MigrationCompleteConditionFailedReason     = "DrainFailed"
+	MigrationCompleteConditionNoTargetReason   = "NoDrainTarget"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:storageversion
 //+kubebuilder:subresource:status
@@ -40,6 +40,44 @@ type ActiveMQArtemisSecuritySpec struct {
 	// Apply this security config to the broker crs in the current namespace. A value of * or empty string means applying to all broker crs. Default apply to all broker crs
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Apply to Broker CR Names"
 	ApplyToCrNames []string `json:"applyToCrNames,omitempty"`
+	// How the security config gets to the brokers. InitContainer, the default, renders it with yacfg in the init container, the brokers pick up a change when they next restart. Secrets renders it in the operator into secrets the brokers mount, they reload the users, roles and security settings without a restart
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Delivery Mode",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	DeliveryMode *SecurityDeliveryMode `json:"deliveryMode,omitempty"`
+	// Limits on the connections and queues of a user on each broker, a change applies when the brokers restart
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Resource Limits"
+	ResourceLimits []ResourceLimitType `json:"resourceLimits,omitempty"`
+	// Keeps the passwords out of the files the brokers read. The users of the properties login modules get a hashed password, the LDAP bind passwords and the management connector store passwords are masked with the codec of the brokers
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Password Masking"
+	PasswordMasking *PasswordMaskingType `json:"passwordMasking,omitempty"`
+}
+
+type PasswordMaskingType struct {
+	// Name of the secret holding the key of the codec in its codec-key entry, the operator generates the key when the entry is missing. Default <cr name>-password-codec
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Key Secret",xDescriptors={"urn:alm:descriptor:io.kubernetes:Secret"}
+	KeySecret string `json:"keySecret,omitempty"`
+}
+
+type ResourceLimitType struct {
+	// Name of the user the limits apply to
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="User",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	User string `json:"user"`
+	// How many connections the user can have open on a broker, -1 for no limit
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Max Connections",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	MaxConnections *int32 `json:"maxConnections,omitempty"`
+	// How many queues the user can create on a broker, -1 for no limit
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Max Queues",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	MaxQueues *int32 `json:"maxQueues,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=InitContainer;Secrets
+type SecurityDeliveryMode string
+
+var SecurityDeliveryModes = struct {
+	InitContainer SecurityDeliveryMode
+	Secrets       SecurityDeliveryMode
+}{
+	InitContainer: "InitContainer",
+	Secrets:       "Secrets",
 }
 
 type LoginModulesType struct {
@@ -52,6 +90,12 @@ type LoginModulesType struct {
 	// Specifies the Keycloak login modules
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Keycloak Login Modules"
 	KeycloakLoginModules []KeycloakLoginModuleType `json:"keycloakLoginModules,omitempty"`
+	// Specifies the LDAP login modules
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="LDAP Login Modules"
+	LdapLoginModules []LdapLoginModuleType `json:"ldapLoginModules,omitempty"`
+	// Specifies the client certificate login modules, for the broker domain
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Certificate Login Modules"
+	CertificateLoginModules []CertificateLoginModuleType `json:"certificateLoginModules,omitempty"`
 }
 
 type PropertiesLoginModuleType struct {
@@ -225,6 +269,90 @@ type KeycloakModuleConfigurationType struct {
 	Scope *string `json:"scope,omitempty"`
 }
 
+type LdapLoginModuleType struct {
+	// Name for LDAPLoginModule
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Name string `json:"name,omitempty"`
+	// URL of the directory server, ldap://host:port or ldaps://host:port
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Connection URL",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	ConnectionURL string `json:"connectionURL,omitempty"`
+	// DN to bind with to search the directory, the search binds anonymously without it
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Bind DN",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	BindDN string `json:"bindDN,omitempty"`
+	// Name of a secret with the password of the bind DN under the password key
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Bind Password Secret",xDescriptors={"urn:alm:descriptor:io.kubernetes:Secret"}
+	BindPasswordSecret string `json:"bindPasswordSecret,omitempty"`
+	// DN of the entry the user search starts from
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="User Base",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	UserBase string `json:"userBase,omitempty"`
+	// Filter matching the entry of a user, {0} is replaced with the user name. For example (uid={0})
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="User Search Matching",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	UserSearchMatching string `json:"userSearchMatching,omitempty"`
+	// Whether the user search goes down the whole subtree of the user base, the default is false
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="User Search Subtree",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	UserSearchSubtree *bool `json:"userSearchSubtree,omitempty"`
+	// DN of the entry the role search starts from
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Role Base",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	RoleBase string `json:"roleBase,omitempty"`
+	// Attribute of a role entry holding the role name. For example cn
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Role Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	RoleName string `json:"roleName,omitempty"`
+	// Filter matching the role entries of a user, {0} is replaced with the DN of the user and {1} with the user name. For example (member={0})
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Role Search Matching",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	RoleSearchMatching string `json:"roleSearchMatching,omitempty"`
+	// Whether the role search goes down the whole subtree of the role base, the default is false
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Role Search Subtree",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	RoleSearchSubtree *bool `json:"roleSearchSubtree,omitempty"`
+	// How referrals are handled, one of ignore, follow or throw. The default is ignore
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Referral",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Referral *string `json:"referral,omitempty"`
+	// Milliseconds to wait for a connection to the directory
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Connection Timeout",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	ConnectionTimeout *int64 `json:"connectionTimeout,omitempty"`
+	// Milliseconds to wait for a response of the directory
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Read Timeout",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	ReadTimeout *int64 `json:"readTimeout,omitempty"`
+	// Name of a secret holding the trust store for an ldaps:// URL, either a trust-manager bundle or a client.ts with its trustStorePassword
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Trust Secret",xDescriptors={"urn:alm:descriptor:io.kubernetes:Secret"}
+	TrustSecret string `json:"trustSecret,omitempty"`
+	// Type of the trust store, PEM is used for a bundle and the default otherwise is JKS
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Trust Store Type",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	TrustStoreType string `json:"trustStoreType,omitempty"`
+	// Whether the login module logs debug information
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Debug",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	Debug *bool `json:"debug,omitempty"`
+}
+
+type CertificateLoginModuleType struct {
+	// Name for TextFileCertificateLoginModule
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Name string `json:"name,omitempty"`
+	// Users identified by the subject DN of their client certificate
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Users"
+	Users []CertificateUserType `json:"users,omitempty"`
+	// Name of a secret with a users.properties key mapping users to subject DNs and a roles.properties key mapping roles to users, used instead of users. The brokers reload its changes
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Mappings Secret",xDescriptors={"urn:alm:descriptor:io.kubernetes:Secret"}
+	MappingsSecret string `json:"mappingsSecret,omitempty"`
+	// Whether the login module logs debug information
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Debug",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	Debug *bool `json:"debug,omitempty"`
+}
+
+type CertificateUserType struct {
+	// User name the client certificate authenticates as
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Name",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Name string `json:"name,omitempty"`
+	// Subject DN of the client certificate of the user, for example CN=orders,O=Example
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Subject DN",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	SubjectDN string `json:"subjectDN,omitempty"`
+	// Regular expression matching the subject DNs of the client certificates of the user, used instead of subjectDN
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Subject DN Regex",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	SubjectDNRegex string `json:"subjectDNRegex,omitempty"`
+	// Roles of the user
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Roles"
+	Roles []string `json:"roles,omitempty"`
+}
+
 type KeyValueType struct {
 	// The regular expression to match the Redirect URI
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Key",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
@@ -403,8 +531,72 @@ type RoleAccessType struct {
 type ActiveMQArtemisSecurityStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Current state of the resource
+	// Conditions represent the latest available observations of an object's state
+	//+optional
+	//+patchMergeKey=type
+	//+patchStrategy=merge
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions",xDescriptors="urn:alm:descriptor:io.kubernetes.conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,2,rep,name=conditions"`
+
+	// The broker CRs the security config applies to
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Matched Brokers"
+	MatchedBrokers []string `json:"matchedBrokers,omitempty"`
+
+	// Whether each broker of the matched CRs runs the current security config
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Brokers"
+	Brokers []SecurityBrokerStatus `json:"brokers,omitempty"`
+
+	// Checksum of the current security config
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Config Checksum"
+	ConfigChecksum string `json:"configChecksum,omitempty"`
+
+	// When the security config last changed, the brokers started before it run an older config until they restart
+	//+operator-sdk:csv:customresourcedefinitions:type=status,displayName="Config Change Time"
+	ConfigChangeTime *metav1.Time `json:"configChangeTime,omitempty"`
+}
+
+type SecurityBrokerStatus struct {
+	// Name of the ActiveMQArtemis CR of the broker
+	CrName string `json:"crName"`
+	// Ordinal of the broker
+	Ordinal string `json:"ordinal"`
+	// Applied once the broker runs the current config, Pending while it restarts to pick it up, or Failed
+	Result string `json:"result"`
+	// What the broker waits for or the error
+	Message string `json:"message,omitempty"`
+	// What the users with resource limits use on the broker, read through jolokia once the config is applied
+	ResourceUsage []ResourceUsageType `json:"resourceUsage,omitempty"`
 }
 
+type ResourceUsageType struct {
+	// Name of the user
+	User string `json:"user"`
+	// Connections the user has open on the broker
+	Connections int64 `json:"connections"`
+	// Queues the user created on the broker
+	Queues int64 `json:"queues"`
+}
+
+const (
+	SecurityBrokerApplied = "Applied"
+	SecurityBrokerPending = "Pending"
+	SecurityBrokerFailed  = "Failed"
+
+	SecurityAppliedConditionType            = "Applied"
+	SecurityAppliedConditionSuccessReason   = "AppliedToAllBrokers"
+	SecurityAppliedConditionPendingReason   = "Pending"
+	SecurityAppliedConditionFailedReason    = "ApplyFailed"
+	SecurityAppliedConditionNoBrokersReason = "NoTargetBrokers"
+	// another security CR applies to a matched broker CR
+	SecurityAppliedConditionConflictReason = "Conflict"
+
+	ValidConditionInvalidLoginModuleReason    = "InvalidLoginModule"
+	ValidConditionInvalidSecurityDomainReason = "InvalidSecurityDomain"
+	ValidConditionInvalidResourceLimitReason  = "InvalidResourceLimit"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:storageversion
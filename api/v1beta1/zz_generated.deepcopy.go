@@ -101,7 +101,7 @@ func (in *ActiveMQArtemisAddress) DeepCopyInto(out *ActiveMQArtemisAddress) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisAddress.
@@ -187,6 +187,33 @@ func (in *ActiveMQArtemisAddressSpec) DeepCopyInto(out *ActiveMQArtemisAddressSp
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.BrokerSelector != nil {
+		in, out := &in.BrokerSelector, &out.BrokerSelector
+		*out = new(BrokerSelectorType)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeliveryMode != nil {
+		in, out := &in.DeliveryMode, &out.DeliveryMode
+		*out = new(AddressDeliveryMode)
+		**out = **in
+	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]AddressEntryType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BatchSize != nil {
+		in, out := &in.BatchSize, &out.BatchSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DeletePolicy != nil {
+		in, out := &in.DeletePolicy, &out.DeletePolicy
+		*out = new(AddressDeletePolicyType)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisAddressSpec.
@@ -202,6 +229,30 @@ func (in *ActiveMQArtemisAddressSpec) DeepCopy() *ActiveMQArtemisAddressSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ActiveMQArtemisAddressStatus) DeepCopyInto(out *ActiveMQArtemisAddressStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Brokers != nil {
+		in, out := &in.Brokers, &out.Brokers
+		*out = make([]AddressBrokerStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]AddressEntryStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Deletion != nil {
+		in, out := &in.Deletion, &out.Deletion
+		*out = new(AddressDeletionStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisAddressStatus.
@@ -214,6 +265,255 @@ func (in *ActiveMQArtemisAddressStatus) DeepCopy() *ActiveMQArtemisAddressStatus
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveMQArtemisBrokerConnection) DeepCopyInto(out *ActiveMQArtemisBrokerConnection) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisBrokerConnection.
+func (in *ActiveMQArtemisBrokerConnection) DeepCopy() *ActiveMQArtemisBrokerConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(ActiveMQArtemisBrokerConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ActiveMQArtemisBrokerConnection) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveMQArtemisBrokerConnectionList) DeepCopyInto(out *ActiveMQArtemisBrokerConnectionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ActiveMQArtemisBrokerConnection, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisBrokerConnectionList.
+func (in *ActiveMQArtemisBrokerConnectionList) DeepCopy() *ActiveMQArtemisBrokerConnectionList {
+	if in == nil {
+		return nil
+	}
+	out := new(ActiveMQArtemisBrokerConnectionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ActiveMQArtemisBrokerConnectionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveMQArtemisBrokerConnectionSpec) DeepCopyInto(out *ActiveMQArtemisBrokerConnectionSpec) {
+	*out = *in
+	out.Target = in.Target
+	in.Mirror.DeepCopyInto(&out.Mirror)
+	if in.RetryInterval != nil {
+		in, out := &in.RetryInterval, &out.RetryInterval
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReconnectAttempts != nil {
+		in, out := &in.ReconnectAttempts, &out.ReconnectAttempts
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisBrokerConnectionSpec.
+func (in *ActiveMQArtemisBrokerConnectionSpec) DeepCopy() *ActiveMQArtemisBrokerConnectionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ActiveMQArtemisBrokerConnectionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveMQArtemisBrokerConnectionStatus) DeepCopyInto(out *ActiveMQArtemisBrokerConnectionStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Brokers != nil {
+		in, out := &in.Brokers, &out.Brokers
+		*out = make([]BrokerConnectionStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisBrokerConnectionStatus.
+func (in *ActiveMQArtemisBrokerConnectionStatus) DeepCopy() *ActiveMQArtemisBrokerConnectionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ActiveMQArtemisBrokerConnectionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveMQArtemisFederation) DeepCopyInto(out *ActiveMQArtemisFederation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisFederation.
+func (in *ActiveMQArtemisFederation) DeepCopy() *ActiveMQArtemisFederation {
+	if in == nil {
+		return nil
+	}
+	out := new(ActiveMQArtemisFederation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ActiveMQArtemisFederation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveMQArtemisFederationList) DeepCopyInto(out *ActiveMQArtemisFederationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ActiveMQArtemisFederation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisFederationList.
+func (in *ActiveMQArtemisFederationList) DeepCopy() *ActiveMQArtemisFederationList {
+	if in == nil {
+		return nil
+	}
+	out := new(ActiveMQArtemisFederationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ActiveMQArtemisFederationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveMQArtemisFederationSpec) DeepCopyInto(out *ActiveMQArtemisFederationSpec) {
+	*out = *in
+	if in.ApplyToCrNames != nil {
+		in, out := &in.ApplyToCrNames, &out.ApplyToCrNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Upstreams != nil {
+		in, out := &in.Upstreams, &out.Upstreams
+		*out = make([]FederationUpstreamType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Downstreams != nil {
+		in, out := &in.Downstreams, &out.Downstreams
+		*out = make([]FederationDownstreamType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AddressPolicies != nil {
+		in, out := &in.AddressPolicies, &out.AddressPolicies
+		*out = make([]FederationAddressPolicyType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.QueuePolicies != nil {
+		in, out := &in.QueuePolicies, &out.QueuePolicies
+		*out = make([]FederationQueuePolicyType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisFederationSpec.
+func (in *ActiveMQArtemisFederationSpec) DeepCopy() *ActiveMQArtemisFederationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ActiveMQArtemisFederationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveMQArtemisFederationStatus) DeepCopyInto(out *ActiveMQArtemisFederationStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FederatedCrs != nil {
+		in, out := &in.FederatedCrs, &out.FederatedCrs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisFederationStatus.
+func (in *ActiveMQArtemisFederationStatus) DeepCopy() *ActiveMQArtemisFederationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ActiveMQArtemisFederationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ActiveMQArtemisList) DeepCopyInto(out *ActiveMQArtemisList) {
 	*out = *in
@@ -252,7 +552,7 @@ func (in *ActiveMQArtemisScaledown) DeepCopyInto(out *ActiveMQArtemisScaledown)
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisScaledown.
@@ -309,6 +609,26 @@ func (in *ActiveMQArtemisScaledownList) DeepCopyObject() runtime.Object {
 func (in *ActiveMQArtemisScaledownSpec) DeepCopyInto(out *ActiveMQArtemisScaledownSpec) {
 	*out = *in
 	in.Resources.DeepCopyInto(&out.Resources)
+	if in.DrainPodTemplate != nil {
+		in, out := &in.DrainPodTemplate, &out.DrainPodTemplate
+		*out = new(DrainPodTemplateType)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DrainDeadlineSeconds != nil {
+		in, out := &in.DrainDeadlineSeconds, &out.DrainDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.DrainRetries != nil {
+		in, out := &in.DrainRetries, &out.DrainRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DrainTarget != nil {
+		in, out := &in.DrainTarget, &out.DrainTarget
+		*out = new(DrainTargetType)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisScaledownSpec.
@@ -324,6 +644,20 @@ func (in *ActiveMQArtemisScaledownSpec) DeepCopy() *ActiveMQArtemisScaledownSpec
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ActiveMQArtemisScaledownStatus) DeepCopyInto(out *ActiveMQArtemisScaledownStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Drains != nil {
+		in, out := &in.Drains, &out.Drains
+		*out = make([]ScaledownDrainStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisScaledownStatus.
@@ -342,7 +676,7 @@ func (in *ActiveMQArtemisSecurity) DeepCopyInto(out *ActiveMQArtemisSecurity) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisSecurity.
@@ -406,6 +740,23 @@ func (in *ActiveMQArtemisSecuritySpec) DeepCopyInto(out *ActiveMQArtemisSecurity
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.DeliveryMode != nil {
+		in, out := &in.DeliveryMode, &out.DeliveryMode
+		*out = new(SecurityDeliveryMode)
+		**out = **in
+	}
+	if in.ResourceLimits != nil {
+		in, out := &in.ResourceLimits, &out.ResourceLimits
+		*out = make([]ResourceLimitType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PasswordMasking != nil {
+		in, out := &in.PasswordMasking, &out.PasswordMasking
+		*out = new(PasswordMaskingType)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisSecuritySpec.
@@ -421,6 +772,29 @@ func (in *ActiveMQArtemisSecuritySpec) DeepCopy() *ActiveMQArtemisSecuritySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ActiveMQArtemisSecurityStatus) DeepCopyInto(out *ActiveMQArtemisSecurityStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MatchedBrokers != nil {
+		in, out := &in.MatchedBrokers, &out.MatchedBrokers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Brokers != nil {
+		in, out := &in.Brokers, &out.Brokers
+		*out = make([]SecurityBrokerStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConfigChangeTime != nil {
+		in, out := &in.ConfigChangeTime, &out.ConfigChangeTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisSecurityStatus.
@@ -473,6 +847,25 @@ func (in *ActiveMQArtemisSpec) DeepCopyInto(out *ActiveMQArtemisSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.HA != nil {
+		in, out := &in.HA, &out.HA
+		*out = new(HAType)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Diverts != nil {
+		in, out := &in.Diverts, &out.Diverts
+		*out = make([]DivertType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Bridges != nil {
+		in, out := &in.Bridges, &out.Bridges
+		*out = make([]BridgeType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisSpec.
@@ -503,6 +896,11 @@ func (in *ActiveMQArtemisStatus) DeepCopyInto(out *ActiveMQArtemisStatus) {
 	}
 	out.Version = in.Version
 	out.Upgrade = in.Upgrade
+	if in.HA != nil {
+		in, out := &in.HA, &out.HA
+		*out = make([]HAPairStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActiveMQArtemisStatus.
@@ -530,6 +928,110 @@ func (in *ActiveMQArtemisUpgrades) DeepCopy() *ActiveMQArtemisUpgrades {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddressBrokerStatus) DeepCopyInto(out *AddressBrokerStatus) {
+	*out = *in
+	in.LastAttemptTime.DeepCopyInto(&out.LastAttemptTime)
+	if in.MessageCount != nil {
+		in, out := &in.MessageCount, &out.MessageCount
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ConsumerCount != nil {
+		in, out := &in.ConsumerCount, &out.ConsumerCount
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddressBrokerStatus.
+func (in *AddressBrokerStatus) DeepCopy() *AddressBrokerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AddressBrokerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddressDeletePolicyType) DeepCopyInto(out *AddressDeletePolicyType) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddressDeletePolicyType.
+func (in *AddressDeletePolicyType) DeepCopy() *AddressDeletePolicyType {
+	if in == nil {
+		return nil
+	}
+	out := new(AddressDeletePolicyType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddressDeletionStatus) DeepCopyInto(out *AddressDeletionStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddressDeletionStatus.
+func (in *AddressDeletionStatus) DeepCopy() *AddressDeletionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AddressDeletionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddressEntryStatus) DeepCopyInto(out *AddressEntryStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddressEntryStatus.
+func (in *AddressEntryStatus) DeepCopy() *AddressEntryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AddressEntryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddressEntryType) DeepCopyInto(out *AddressEntryType) {
+	*out = *in
+	if in.RoutingType != nil {
+		in, out := &in.RoutingType, &out.RoutingType
+		*out = new(string)
+		**out = **in
+	}
+	if in.Queues != nil {
+		in, out := &in.Queues, &out.Queues
+		*out = make([]QueueEntryType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddressEntryType.
+func (in *AddressEntryType) DeepCopy() *AddressEntryType {
+	if in == nil {
+		return nil
+	}
+	out := new(AddressEntryType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AddressSettingType) DeepCopyInto(out *AddressSettingType) {
 	*out = *in
@@ -937,48 +1439,128 @@ func (in *AllowedListEntryType) DeepCopyInto(out *AllowedListEntryType) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowedListEntryType.
-func (in *AllowedListEntryType) DeepCopy() *AllowedListEntryType {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowedListEntryType.
+func (in *AllowedListEntryType) DeepCopy() *AllowedListEntryType {
+	if in == nil {
+		return nil
+	}
+	out := new(AllowedListEntryType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthorisationConfigType) DeepCopyInto(out *AuthorisationConfigType) {
+	*out = *in
+	if in.AllowedList != nil {
+		in, out := &in.AllowedList, &out.AllowedList
+		*out = make([]AllowedListEntryType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DefaultAccess != nil {
+		in, out := &in.DefaultAccess, &out.DefaultAccess
+		*out = make([]DefaultAccessType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RoleAccess != nil {
+		in, out := &in.RoleAccess, &out.RoleAccess
+		*out = make([]RoleAccessType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthorisationConfigType.
+func (in *AuthorisationConfigType) DeepCopy() *AuthorisationConfigType {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthorisationConfigType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BridgeType) DeepCopyInto(out *BridgeType) {
+	*out = *in
+	if in.StaticConnectors != nil {
+		in, out := &in.StaticConnectors, &out.StaticConnectors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HA != nil {
+		in, out := &in.HA, &out.HA
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RetryInterval != nil {
+		in, out := &in.RetryInterval, &out.RetryInterval
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ReconnectAttempts != nil {
+		in, out := &in.ReconnectAttempts, &out.ReconnectAttempts
+		*out = new(int32)
+		**out = **in
+	}
+	if in.UseDuplicateDetection != nil {
+		in, out := &in.UseDuplicateDetection, &out.UseDuplicateDetection
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Transformer != nil {
+		in, out := &in.Transformer, &out.Transformer
+		*out = new(TransformerType)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BridgeType.
+func (in *BridgeType) DeepCopy() *BridgeType {
+	if in == nil {
+		return nil
+	}
+	out := new(BridgeType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BrokerConnectionStatus) DeepCopyInto(out *BrokerConnectionStatus) {
+	*out = *in
+	if in.PendingMessages != nil {
+		in, out := &in.PendingMessages, &out.PendingMessages
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BrokerConnectionStatus.
+func (in *BrokerConnectionStatus) DeepCopy() *BrokerConnectionStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(AllowedListEntryType)
+	out := new(BrokerConnectionStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AuthorisationConfigType) DeepCopyInto(out *AuthorisationConfigType) {
+func (in *BrokerConnectionTargetType) DeepCopyInto(out *BrokerConnectionTargetType) {
 	*out = *in
-	if in.AllowedList != nil {
-		in, out := &in.AllowedList, &out.AllowedList
-		*out = make([]AllowedListEntryType, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.DefaultAccess != nil {
-		in, out := &in.DefaultAccess, &out.DefaultAccess
-		*out = make([]DefaultAccessType, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.RoleAccess != nil {
-		in, out := &in.RoleAccess, &out.RoleAccess
-		*out = make([]RoleAccessType, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthorisationConfigType.
-func (in *AuthorisationConfigType) DeepCopy() *AuthorisationConfigType {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BrokerConnectionTargetType.
+func (in *BrokerConnectionTargetType) DeepCopy() *BrokerConnectionTargetType {
 	if in == nil {
 		return nil
 	}
-	out := new(AuthorisationConfigType)
+	out := new(BrokerConnectionTargetType)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -1032,6 +1614,78 @@ func (in *BrokerSecuritySettingType) DeepCopy() *BrokerSecuritySettingType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BrokerSelectorType) DeepCopyInto(out *BrokerSelectorType) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BrokerSelectorType.
+func (in *BrokerSelectorType) DeepCopy() *BrokerSelectorType {
+	if in == nil {
+		return nil
+	}
+	out := new(BrokerSelectorType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateLoginModuleType) DeepCopyInto(out *CertificateLoginModuleType) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]CertificateUserType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Debug != nil {
+		in, out := &in.Debug, &out.Debug
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateLoginModuleType.
+func (in *CertificateLoginModuleType) DeepCopy() *CertificateLoginModuleType {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateLoginModuleType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateUserType) DeepCopyInto(out *CertificateUserType) {
+	*out = *in
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateUserType.
+func (in *CertificateUserType) DeepCopy() *CertificateUserType {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateUserType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConnectorConfigType) DeepCopyInto(out *ConnectorConfigType) {
 	*out = *in
@@ -1221,7 +1875,7 @@ func (in *DeploymentPlanType) DeepCopyInto(out *DeploymentPlanType) {
 		**out = **in
 	}
 	in.Resources.DeepCopyInto(&out.Resources)
-	out.Storage = in.Storage
+	in.Storage.DeepCopyInto(&out.Storage)
 	if in.TopologySpreadConstraints != nil {
 		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
 		*out = make([]v1.TopologySpreadConstraint, len(*in))
@@ -1319,61 +1973,313 @@ func (in *DeploymentPlanType) DeepCopyInto(out *DeploymentPlanType) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.ExtraVolumeClaimTemplates != nil {
-		in, out := &in.ExtraVolumeClaimTemplates, &out.ExtraVolumeClaimTemplates
-		*out = make([]VolumeClaimTemplate, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.ExtraVolumeClaimTemplates != nil {
+		in, out := &in.ExtraVolumeClaimTemplates, &out.ExtraVolumeClaimTemplates
+		*out = make([]VolumeClaimTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentPlanType.
+func (in *DeploymentPlanType) DeepCopy() *DeploymentPlanType {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentPlanType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DirectoryStorageType) DeepCopyInto(out *DirectoryStorageType) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DirectoryStorageType.
+func (in *DirectoryStorageType) DeepCopy() *DirectoryStorageType {
+	if in == nil {
+		return nil
+	}
+	out := new(DirectoryStorageType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DivertType) DeepCopyInto(out *DivertType) {
+	*out = *in
+	if in.Exclusive != nil {
+		in, out := &in.Exclusive, &out.Exclusive
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Transformer != nil {
+		in, out := &in.Transformer, &out.Transformer
+		*out = new(TransformerType)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DivertType.
+func (in *DivertType) DeepCopy() *DivertType {
+	if in == nil {
+		return nil
+	}
+	out := new(DivertType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrainPodTemplateType) DeepCopyInto(out *DrainPodTemplateType) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PodSecurityContext != nil {
+		in, out := &in.PodSecurityContext, &out.PodSecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ContainerSecurityContext != nil {
+		in, out := &in.ContainerSecurityContext, &out.ContainerSecurityContext
+		*out = new(v1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrainPodTemplateType.
+func (in *DrainPodTemplateType) DeepCopy() *DrainPodTemplateType {
+	if in == nil {
+		return nil
+	}
+	out := new(DrainPodTemplateType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrainTargetType) DeepCopyInto(out *DrainTargetType) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrainTargetType.
+func (in *DrainTargetType) DeepCopy() *DrainTargetType {
+	if in == nil {
+		return nil
+	}
+	out := new(DrainTargetType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalConfigStatus) DeepCopyInto(out *ExternalConfigStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalConfigStatus.
+func (in *ExternalConfigStatus) DeepCopy() *ExternalConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtraMountsType) DeepCopyInto(out *ExtraMountsType) {
+	*out = *in
+	if in.ConfigMaps != nil {
+		in, out := &in.ConfigMaps, &out.ConfigMaps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Secrets != nil {
+		in, out := &in.Secrets, &out.Secrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtraMountsType.
+func (in *ExtraMountsType) DeepCopy() *ExtraMountsType {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtraMountsType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationAddressPolicyType) DeepCopyInto(out *FederationAddressPolicyType) {
+	*out = *in
+	if in.Includes != nil {
+		in, out := &in.Includes, &out.Includes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Excludes != nil {
+		in, out := &in.Excludes, &out.Excludes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxHops != nil {
+		in, out := &in.MaxHops, &out.MaxHops
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AutoDelete != nil {
+		in, out := &in.AutoDelete, &out.AutoDelete
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AutoDeleteDelay != nil {
+		in, out := &in.AutoDeleteDelay, &out.AutoDeleteDelay
+		*out = new(int64)
+		**out = **in
+	}
+	if in.AutoDeleteMessageCount != nil {
+		in, out := &in.AutoDeleteMessageCount, &out.AutoDeleteMessageCount
+		*out = new(int64)
+		**out = **in
+	}
+	if in.EnableDivertBindings != nil {
+		in, out := &in.EnableDivertBindings, &out.EnableDivertBindings
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationAddressPolicyType.
+func (in *FederationAddressPolicyType) DeepCopy() *FederationAddressPolicyType {
+	if in == nil {
+		return nil
 	}
+	out := new(FederationAddressPolicyType)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeploymentPlanType.
-func (in *DeploymentPlanType) DeepCopy() *DeploymentPlanType {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationDownstreamType) DeepCopyInto(out *FederationDownstreamType) {
+	*out = *in
+	in.FederationUpstreamType.DeepCopyInto(&out.FederationUpstreamType)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationDownstreamType.
+func (in *FederationDownstreamType) DeepCopy() *FederationDownstreamType {
 	if in == nil {
 		return nil
 	}
-	out := new(DeploymentPlanType)
+	out := new(FederationDownstreamType)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExternalConfigStatus) DeepCopyInto(out *ExternalConfigStatus) {
+func (in *FederationQueueMatchType) DeepCopyInto(out *FederationQueueMatchType) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalConfigStatus.
-func (in *ExternalConfigStatus) DeepCopy() *ExternalConfigStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationQueueMatchType.
+func (in *FederationQueueMatchType) DeepCopy() *FederationQueueMatchType {
 	if in == nil {
 		return nil
 	}
-	out := new(ExternalConfigStatus)
+	out := new(FederationQueueMatchType)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExtraMountsType) DeepCopyInto(out *ExtraMountsType) {
+func (in *FederationQueuePolicyType) DeepCopyInto(out *FederationQueuePolicyType) {
 	*out = *in
-	if in.ConfigMaps != nil {
-		in, out := &in.ConfigMaps, &out.ConfigMaps
+	if in.Includes != nil {
+		in, out := &in.Includes, &out.Includes
+		*out = make([]FederationQueueMatchType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Excludes != nil {
+		in, out := &in.Excludes, &out.Excludes
+		*out = make([]FederationQueueMatchType, len(*in))
+		copy(*out, *in)
+	}
+	if in.IncludeFederated != nil {
+		in, out := &in.IncludeFederated, &out.IncludeFederated
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PriorityAdjustment != nil {
+		in, out := &in.PriorityAdjustment, &out.PriorityAdjustment
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationQueuePolicyType.
+func (in *FederationQueuePolicyType) DeepCopy() *FederationQueuePolicyType {
+	if in == nil {
+		return nil
+	}
+	out := new(FederationQueuePolicyType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FederationUpstreamType) DeepCopyInto(out *FederationUpstreamType) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.Secrets != nil {
-		in, out := &in.Secrets, &out.Secrets
+	if in.PolicyRefs != nil {
+		in, out := &in.PolicyRefs, &out.PolicyRefs
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ReconnectAttempts != nil {
+		in, out := &in.ReconnectAttempts, &out.ReconnectAttempts
+		*out = new(int32)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtraMountsType.
-func (in *ExtraMountsType) DeepCopy() *ExtraMountsType {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationUpstreamType.
+func (in *FederationUpstreamType) DeepCopy() *FederationUpstreamType {
 	if in == nil {
 		return nil
 	}
-	out := new(ExtraMountsType)
+	out := new(FederationUpstreamType)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -1403,6 +2309,41 @@ func (in *GuestLoginModuleType) DeepCopy() *GuestLoginModuleType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HAPairStatus) DeepCopyInto(out *HAPairStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HAPairStatus.
+func (in *HAPairStatus) DeepCopy() *HAPairStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HAPairStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HAType) DeepCopyInto(out *HAType) {
+	*out = *in
+	if in.AllowFailback != nil {
+		in, out := &in.AllowFailback, &out.AllowFailback
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HAType.
+func (in *HAType) DeepCopy() *HAType {
+	if in == nil {
+		return nil
+	}
+	out := new(HAType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeyValueType) DeepCopyInto(out *KeyValueType) {
 	*out = *in
@@ -1668,6 +2609,51 @@ func (in *KeycloakModuleConfigurationType) DeepCopy() *KeycloakModuleConfigurati
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LdapLoginModuleType) DeepCopyInto(out *LdapLoginModuleType) {
+	*out = *in
+	if in.UserSearchSubtree != nil {
+		in, out := &in.UserSearchSubtree, &out.UserSearchSubtree
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RoleSearchSubtree != nil {
+		in, out := &in.RoleSearchSubtree, &out.RoleSearchSubtree
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Referral != nil {
+		in, out := &in.Referral, &out.Referral
+		*out = new(string)
+		**out = **in
+	}
+	if in.ConnectionTimeout != nil {
+		in, out := &in.ConnectionTimeout, &out.ConnectionTimeout
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ReadTimeout != nil {
+		in, out := &in.ReadTimeout, &out.ReadTimeout
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Debug != nil {
+		in, out := &in.Debug, &out.Debug
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LdapLoginModuleType.
+func (in *LdapLoginModuleType) DeepCopy() *LdapLoginModuleType {
+	if in == nil {
+		return nil
+	}
+	out := new(LdapLoginModuleType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LoginModuleReferenceType) DeepCopyInto(out *LoginModuleReferenceType) {
 	*out = *in
@@ -1727,6 +2713,20 @@ func (in *LoginModulesType) DeepCopyInto(out *LoginModulesType) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LdapLoginModules != nil {
+		in, out := &in.LdapLoginModules, &out.LdapLoginModules
+		*out = make([]LdapLoginModuleType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CertificateLoginModules != nil {
+		in, out := &in.CertificateLoginModules, &out.CertificateLoginModules
+		*out = make([]CertificateLoginModuleType, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoginModulesType.
@@ -1761,6 +2761,41 @@ func (in *ManagementSecuritySettingsType) DeepCopy() *ManagementSecuritySettings
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirrorType) DeepCopyInto(out *MirrorType) {
+	*out = *in
+	if in.MessageAcknowledgements != nil {
+		in, out := &in.MessageAcknowledgements, &out.MessageAcknowledgements
+		*out = new(bool)
+		**out = **in
+	}
+	if in.QueueCreation != nil {
+		in, out := &in.QueueCreation, &out.QueueCreation
+		*out = new(bool)
+		**out = **in
+	}
+	if in.QueueRemoval != nil {
+		in, out := &in.QueueRemoval, &out.QueueRemoval
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Sync != nil {
+		in, out := &in.Sync, &out.Sync
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorType.
+func (in *MirrorType) DeepCopy() *MirrorType {
+	if in == nil {
+		return nil
+	}
+	out := new(MirrorType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectMeta) DeepCopyInto(out *ObjectMeta) {
 	*out = *in
@@ -1790,6 +2825,21 @@ func (in *ObjectMeta) DeepCopy() *ObjectMeta {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PasswordMaskingType) DeepCopyInto(out *PasswordMaskingType) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PasswordMaskingType.
+func (in *PasswordMaskingType) DeepCopy() *PasswordMaskingType {
+	if in == nil {
+		return nil
+	}
+	out := new(PasswordMaskingType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PermissionType) DeepCopyInto(out *PermissionType) {
 	*out = *in
@@ -2002,6 +3052,51 @@ func (in *QueueConfigurationType) DeepCopy() *QueueConfigurationType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueueEntryType) DeepCopyInto(out *QueueEntryType) {
+	*out = *in
+	if in.QueueConfiguration != nil {
+		in, out := &in.QueueConfiguration, &out.QueueConfiguration
+		*out = new(QueueConfigurationType)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueueEntryType.
+func (in *QueueEntryType) DeepCopy() *QueueEntryType {
+	if in == nil {
+		return nil
+	}
+	out := new(QueueEntryType)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceLimitType) DeepCopyInto(out *ResourceLimitType) {
+	*out = *in
+	if in.MaxConnections != nil {
+		in, out := &in.MaxConnections, &out.MaxConnections
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxQueues != nil {
+		in, out := &in.MaxQueues, &out.MaxQueues
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceLimitType.
+func (in *ResourceLimitType) DeepCopy() *ResourceLimitType {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceLimitType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceSelector) DeepCopyInto(out *ResourceSelector) {
 	*out = *in
@@ -2070,6 +3165,21 @@ func (in *ResourceTemplate) DeepCopy() *ResourceTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceUsageType) DeepCopyInto(out *ResourceUsageType) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceUsageType.
+func (in *ResourceUsageType) DeepCopy() *ResourceUsageType {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceUsageType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RoleAccessType) DeepCopyInto(out *RoleAccessType) {
 	*out = *in
@@ -2102,6 +3212,59 @@ func (in *RoleAccessType) DeepCopy() *RoleAccessType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaledownDrainStatus) DeepCopyInto(out *ScaledownDrainStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+	if in.MessagesToMigrate != nil {
+		in, out := &in.MessagesToMigrate, &out.MessagesToMigrate
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MessagesMigrated != nil {
+		in, out := &in.MessagesMigrated, &out.MessagesMigrated
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaledownDrainStatus.
+func (in *ScaledownDrainStatus) DeepCopy() *ScaledownDrainStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaledownDrainStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecurityBrokerStatus) DeepCopyInto(out *SecurityBrokerStatus) {
+	*out = *in
+	if in.ResourceUsage != nil {
+		in, out := &in.ResourceUsage, &out.ResourceUsage
+		*out = make([]ResourceUsageType, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityBrokerStatus.
+func (in *SecurityBrokerStatus) DeepCopy() *SecurityBrokerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SecurityBrokerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecurityDomainsType) DeepCopyInto(out *SecurityDomainsType) {
 	*out = *in
@@ -2145,6 +3308,26 @@ func (in *SecuritySettingsType) DeepCopy() *SecuritySettingsType {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageType) DeepCopyInto(out *StorageType) {
 	*out = *in
+	if in.Journal != nil {
+		in, out := &in.Journal, &out.Journal
+		*out = new(DirectoryStorageType)
+		**out = **in
+	}
+	if in.Paging != nil {
+		in, out := &in.Paging, &out.Paging
+		*out = new(DirectoryStorageType)
+		**out = **in
+	}
+	if in.Bindings != nil {
+		in, out := &in.Bindings, &out.Bindings
+		*out = new(DirectoryStorageType)
+		**out = **in
+	}
+	if in.LargeMessages != nil {
+		in, out := &in.LargeMessages, &out.LargeMessages
+		*out = new(DirectoryStorageType)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageType.
@@ -2157,6 +3340,28 @@ func (in *StorageType) DeepCopy() *StorageType {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransformerType) DeepCopyInto(out *TransformerType) {
+	*out = *in
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransformerType.
+func (in *TransformerType) DeepCopy() *TransformerType {
+	if in == nil {
+		return nil
+	}
+	out := new(TransformerType)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UpgradeStatus) DeepCopyInto(out *UpgradeStatus) {
 	*out = *in
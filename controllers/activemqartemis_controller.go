@@ -35,6 +35,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/artemiscloud/activemq-artemis-operator/pkg/resources"
 	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/certutil"
@@ -77,6 +78,21 @@ func (r *ActiveMQArtemisReconciler) UpdatePodForSecurity(securityHandlerNamespac
 	return err
 }
 
+// ReconcileBroker queues a reconcile of a broker CR, if it exists, so that it
+// picks up config that comes from other CRs
+func (r *ActiveMQArtemisReconciler) ReconcileBroker(brokerNamespacedName types.NamespacedName) error {
+	existing := &brokerv1beta1.ActiveMQArtemis{}
+	if err := r.Client.Get(context.TODO(), brokerNamespacedName, existing); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	r.log.V(1).Info("force reconcile", "CR", brokerNamespacedName)
+	r.events <- event.GenericEvent{Object: existing}
+	return nil
+}
+
 func (r *ActiveMQArtemisReconciler) RemoveBrokerConfigHandler(namespacedName types.NamespacedName) {
 	r.log.V(2).Info("Removing config handler", "name", namespacedName)
 	oldHandler, ok := namespaceToConfigHandler[namespacedName]
@@ -123,7 +139,6 @@ func NewActiveMQArtemisReconciler(client rtclient.Client, scheme *runtime.Scheme
 //+kubebuilder:rbac:groups=broker.amq.io,namespace=activemq-artemis-operator,resources=activemqartemises/finalizers,verbs=update
 //+kubebuilder:rbac:groups=broker.amq.io,namespace=activemq-artemis-operator,resources=pods,verbs=get;list
 //+kubebuilder:rbac:groups="",namespace=activemq-artemis-operator,resources=pods;services;endpoints;persistentvolumeclaims;events;configmaps;secrets;routes;serviceaccounts,verbs=*
-//+kubebuilder:rbac:groups="",namespace=activemq-artemis-operator,resources=namespaces,verbs=get
 //+kubebuilder:rbac:groups=apps,namespace=activemq-artemis-operator,resources=deployments;daemonsets;replicasets;statefulsets,verbs=*
 //+kubebuilder:rbac:groups=networking.k8s.io,namespace=activemq-artemis-operator,resources=ingresses,verbs=get;list;watch;create;delete;update
 //+kubebuilder:rbac:groups=route.openshift.io,namespace=activemq-artemis-operator,resources=routes;routes/custom-host;routes/status,verbs=get;list;watch;create;delete;update
@@ -170,6 +185,16 @@ func (r *ActiveMQArtemisReconciler) Reconcile(ctx context.Context, request ctrl.
 		if ProcessBrokerStatus(customResource, r.Client, r.Scheme) {
 			requeueRequest = true
 		}
+
+		if storageExpansionInProgress(customResource) {
+			requeueRequest = true
+		}
+
+		if customResource.Spec.HA != nil {
+			// keep following failovers between the brokers of each pair
+			ProcessHAStatus(customResource, r.Client)
+			requeueRequest = true
+		}
 	}
 
 	common.ProcessStatus(customResource, r.Client, request.NamespacedName, *namer, err)
@@ -250,16 +275,86 @@ func validate(customResource *brokerv1beta1.ActiveMQArtemis, client rtclient.Cli
 		}
 	}
 
+	if validationCondition.Status == metav1.ConditionTrue && customResource.Spec.HA != nil {
+		condition := validateHA(customResource)
+		if condition != nil {
+			validationCondition = *condition
+		}
+	}
+
+	if validationCondition.Status == metav1.ConditionTrue {
+		condition := validateRouting(customResource)
+		if condition != nil {
+			validationCondition = *condition
+		}
+	}
+
+	if validationCondition.Status == metav1.ConditionTrue {
+		condition := validateStorage(customResource)
+		if condition != nil {
+			validationCondition = *condition
+		}
+	}
+
 	validationCondition.ObservedGeneration = customResource.Generation
 	meta.SetStatusCondition(&customResource.Status.Conditions, validationCondition)
 
 	return validationCondition.Status != metav1.ConditionFalse, retry
 }
 
+type activeMQArtemisSpecValidator struct {
+	client rtclient.Client
+}
+
+// NewActiveMQArtemisSpecValidator runs the validate() checks from the admission webhook
+func NewActiveMQArtemisSpecValidator(client rtclient.Client) brokerv1beta1.ActiveMQArtemisSpecValidator {
+	return &activeMQArtemisSpecValidator{client: client}
+}
+
+func (v *activeMQArtemisSpecValidator) Validate(customResource *brokerv1beta1.ActiveMQArtemis) (admission.Warnings, error) {
+	namer := MakeNamers(customResource)
+
+	var warnings admission.Warnings
+	var failures []string
+
+	// a false condition that would be retried depends on cluster state, like a missing
+	// secret, and only warrants a warning. Unknown conditions are non fatal in validate()
+	collect := func(condition *metav1.Condition, retry bool) {
+		if condition == nil {
+			return
+		}
+		if condition.Status == metav1.ConditionFalse && !retry {
+			failures = append(failures, condition.Message)
+		} else {
+			warnings = append(warnings, condition.Message)
+		}
+	}
+
+	collect(validateExtraMounts(customResource, v.client))
+	if customResource.Spec.DeploymentPlan.PodDisruptionBudget != nil {
+		collect(validatePodDisruption(customResource), false)
+	}
+	collect(validateAcceptorPorts(customResource))
+	collect(validateSSLEnabledSecrets(customResource, v.client, *namer))
+	collect(common.ValidateBrokerImageVersion(customResource), false)
+	collect(validateReservedLabels(customResource), false)
+	collect(validateExposeModes(customResource))
+	if customResource.Spec.HA != nil {
+		collect(validateHA(customResource), false)
+	}
+	collect(validateRouting(customResource), false)
+	collect(validateStorage(customResource), false)
+
+	if len(failures) > 0 {
+		return warnings, errors.New(strings.Join(failures, "; "))
+	}
+	return warnings, nil
+}
+
 func validateReservedLabels(customResource *brokerv1beta1.ActiveMQArtemis) *metav1.Condition {
 	if customResource.Spec.DeploymentPlan.Labels != nil {
 		for key := range customResource.Spec.DeploymentPlan.Labels {
-			if key == selectors.LabelAppKey || key == selectors.LabelResourceKey {
+			if key == selectors.LabelAppKey || key == selectors.LabelResourceKey || key == HALivePairLabelKey {
 				return &metav1.Condition{
 					Type:    brokerv1beta1.ValidConditionType,
 					Status:  metav1.ConditionFalse,
@@ -271,7 +366,7 @@ func validateReservedLabels(customResource *brokerv1beta1.ActiveMQArtemis) *meta
 	}
 	for index, template := range customResource.Spec.ResourceTemplates {
 		for key := range template.Labels {
-			if key == selectors.LabelAppKey || key == selectors.LabelResourceKey {
+			if key == selectors.LabelAppKey || key == selectors.LabelResourceKey || key == HALivePairLabelKey {
 				return &metav1.Condition{
 					Type:    brokerv1beta1.ValidConditionType,
 					Status:  metav1.ConditionFalse,
@@ -720,6 +815,7 @@ func EqualCRStatus(s1, s2 *brokerv1beta1.ActiveMQArtemisStatus) bool {
 		len(s2.ExternalConfigs) != len(s1.ExternalConfigs) ||
 		externalConfigsModified(s2.ExternalConfigs, s1.ExternalConfigs) ||
 		!reflect.DeepEqual(s1.PodStatus, s2.PodStatus) ||
+		!reflect.DeepEqual(s1.HA, s2.HA) ||
 		len(s1.Conditions) != len(s2.Conditions) ||
 		conditionsModified(s2.Conditions, s1.Conditions) {
 
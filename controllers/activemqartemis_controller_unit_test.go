@@ -22,7 +22,10 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/selectors"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestValidate(t *testing.T) {
@@ -51,3 +54,78 @@ func TestValidate(t *testing.T) {
 	assert.Equal(t, condition.Reason, brokerv1beta1.ValidConditionFailedReservedLabelReason)
 	assert.True(t, strings.Contains(condition.Message, "Templates[0]"))
 }
+
+func TestSpecValidatorRejectsInvalidSpec(t *testing.T) {
+
+	cr := &brokerv1beta1.ActiveMQArtemis{
+		ObjectMeta: metav1.ObjectMeta{Name: "broker", Namespace: "test"},
+		Spec: brokerv1beta1.ActiveMQArtemisSpec{
+			DeploymentPlan: brokerv1beta1.DeploymentPlanType{
+				Labels: map[string]string{selectors.LabelResourceKey: "myKey"},
+			},
+			Acceptors: []brokerv1beta1.AcceptorType{
+				{Name: "a", Port: 61616},
+				{Name: "b", Port: 61616},
+			},
+		},
+	}
+
+	validator := NewActiveMQArtemisSpecValidator(fake.NewClientBuilder().Build())
+
+	warnings, err := validator.Validate(cr)
+
+	assert.Empty(t, warnings)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "reserved label")
+	assert.Contains(t, err.Error(), "duplicate port 61616")
+}
+
+func TestSpecValidatorWarnsOnMissingResources(t *testing.T) {
+
+	cr := &brokerv1beta1.ActiveMQArtemis{
+		ObjectMeta: metav1.ObjectMeta{Name: "broker", Namespace: "test"},
+		Spec: brokerv1beta1.ActiveMQArtemisSpec{
+			DeploymentPlan: brokerv1beta1.DeploymentPlanType{
+				ExtraMounts: brokerv1beta1.ExtraMountsType{
+					Secrets: []string{"not-there"},
+				},
+			},
+			Console: brokerv1beta1.ConsoleType{
+				SSLEnabled: true,
+			},
+		},
+	}
+
+	validator := NewActiveMQArtemisSpecValidator(fake.NewClientBuilder().Build())
+
+	warnings, err := validator.Validate(cr)
+
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "missing required secret not-there")
+	assert.Contains(t, warnings[1], "broker-console-secret is not found")
+}
+
+func TestSpecValidatorRejectsJaasConfigMap(t *testing.T) {
+
+	cr := &brokerv1beta1.ActiveMQArtemis{
+		ObjectMeta: metav1.ObjectMeta{Name: "broker", Namespace: "test"},
+		Spec: brokerv1beta1.ActiveMQArtemisSpec{
+			DeploymentPlan: brokerv1beta1.DeploymentPlanType{
+				ExtraMounts: brokerv1beta1.ExtraMountsType{
+					ConfigMaps: []string{"my" + jaasConfigSuffix},
+				},
+			},
+		},
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "my" + jaasConfigSuffix, Namespace: "test"},
+	}
+	validator := NewActiveMQArtemisSpecValidator(fake.NewClientBuilder().WithObjects(configMap).Build())
+
+	_, err := validator.Validate(cr)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must be a secret")
+}
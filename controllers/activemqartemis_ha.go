@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	ss "github.com/artemiscloud/activemq-artemis-operator/pkg/resources/statefulsets"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/common"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia_client"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/namer"
+	"github.com/blang/semver/v4"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The brokers of a HA deployment are paired by ordinal, the primary of pair k
+// is ordinal 2k and its backup is ordinal 2k+1. Scaling the deployment plan
+// by two adds or removes a whole pair.
+
+const (
+	// set on the pod that is live for a pair, the pair services select it
+	HALivePairLabelKey = "ActiveMQArtemisLivePair"
+
+	haPairPrefix           = "pair-"
+	haSharedStoreName      = "shared-store"
+	haMinimumBrokerVersion = "2.31.0"
+)
+
+func haPolicy(customResource *brokerv1beta1.ActiveMQArtemis) brokerv1beta1.HAPolicy {
+	if customResource.Spec.HA.Policy == "" {
+		return brokerv1beta1.HAPolicyReplication
+	}
+	return customResource.Spec.HA.Policy
+}
+
+func haPairName(pair int32) string {
+	return haPairPrefix + strconv.Itoa(int(pair))
+}
+
+func haAllowFailback(customResource *brokerv1beta1.ActiveMQArtemis) bool {
+	if customResource.Spec.HA.AllowFailback != nil {
+		return *customResource.Spec.HA.AllowFailback
+	}
+	return true
+}
+
+func isSharedStore(customResource *brokerv1beta1.ActiveMQArtemis) bool {
+	return customResource.Spec.HA != nil && haPolicy(customResource) == brokerv1beta1.HAPolicySharedStore
+}
+
+func haSharedStoreVolumeName(customResource *brokerv1beta1.ActiveMQArtemis) string {
+	return customResource.Name + "-" + haSharedStoreName
+}
+
+// the shared store is mounted next to the data directory, /opt/<cr name>/shared-store
+func haSharedStoreMountPath(namer common.Namers) string {
+	return path.Join(path.Dir(namer.GLOBAL_DATA_PATH), haSharedStoreName)
+}
+
+// brokerHAProperties gives each ordinal its HA policy, the pairs are matched
+// by group name for replication and by journal location for shared-store
+func brokerHAProperties(customResource *brokerv1beta1.ActiveMQArtemis, namer common.Namers) []string {
+	props := []string{}
+	if customResource.Spec.HA == nil {
+		return props
+	}
+
+	allowFailback := strconv.FormatBool(haAllowFailback(customResource))
+	for ordinal := int32(0); ordinal < common.GetDeploymentSize(customResource); ordinal++ {
+		prefix := OrdinalPrefix + strconv.Itoa(int(ordinal)) + OrdinalPrefixSep
+		pairName := haPairName(ordinal / 2)
+		primary := ordinal%2 == 0
+
+		switch haPolicy(customResource) {
+		case brokerv1beta1.HAPolicySharedStore:
+			if primary {
+				props = append(props, prefix+"HAPolicyConfiguration=SHARED_STORE_PRIMARY")
+			} else {
+				props = append(props,
+					prefix+"HAPolicyConfiguration=SHARED_STORE_BACKUP",
+					prefix+"HAPolicyConfiguration.allowFailBack="+allowFailback)
+			}
+			// a pod restart is a shutdown, the pair has to fail over
+			props = append(props, prefix+"HAPolicyConfiguration.failoverOnServerShutdown=true")
+			for _, directory := range brokerDirectories(&customResource.Spec.DeploymentPlan.Storage) {
+				props = append(props, prefix+directory.property+"="+path.Join(haSharedStoreMountPath(namer), pairName, directory.name))
+			}
+		default:
+			if primary {
+				props = append(props,
+					prefix+"HAPolicyConfiguration=REPLICATION_PRIMARY_QUORUM_VOTING",
+					prefix+"HAPolicyConfiguration.groupName="+pairName,
+					// a returning primary has to look for its backup to fail back
+					prefix+"HAPolicyConfiguration.checkForActiveServer="+allowFailback)
+			} else {
+				props = append(props,
+					prefix+"HAPolicyConfiguration=REPLICATION_BACKUP_QUORUM_VOTING",
+					prefix+"HAPolicyConfiguration.groupName="+pairName,
+					prefix+"HAPolicyConfiguration.allowFailBack="+allowFailback)
+			}
+		}
+	}
+	return props
+}
+
+// configureHAServices creates a service per acceptor and pair that routes to
+// whichever broker of the pair is live, so clients don't need to follow a failover
+func (reconciler *ActiveMQArtemisReconcilerImpl) configureHAServices(customResource *brokerv1beta1.ActiveMQArtemis, namer common.Namers, client rtclient.Client) {
+	if customResource.Spec.HA == nil {
+		return
+	}
+	for pair := int32(0); pair < common.GetDeploymentSize(customResource)/2; pair++ {
+		pairName := haPairName(pair)
+		selectorLabels := namer.LabelBuilder.Labels()
+		selectorLabels[HALivePairLabelKey] = pairName
+
+		for _, acceptor := range customResource.Spec.Acceptors {
+			nameSuffix := acceptor.Name + "-" + pairName
+			serviceName := types.NamespacedName{Namespace: customResource.Namespace, Name: customResource.Name + "-" + nameSuffix + "-" + ServiceTypePostfix}
+			serviceDefinition := reconciler.ServiceDefinitionForCR(serviceName, client, nameSuffix, acceptor.Port, selectorLabels, namer.LabelBuilder.Labels())
+			reconciler.checkExistingService(customResource, serviceDefinition, client)
+			reconciler.trackDesired(serviceDefinition)
+		}
+	}
+}
+
+// ProcessHAStatus asks each broker through jolokia whether it is active, reports
+// the live pod of every pair in status and moves the live pair label to it.
+// A broker that can't be reached keeps its label till its state is known again.
+func ProcessHAStatus(cr *brokerv1beta1.ActiveMQArtemis, client rtclient.Client) {
+	reqLogger := ctrl.Log.WithValues("ActiveMQArtemis Name", cr.Name)
+
+	if cr.Spec.HA == nil {
+		cr.Status.HA = nil
+		return
+	}
+
+	resource := types.NamespacedName{
+		Name:      cr.Name,
+		Namespace: cr.Namespace,
+	}
+
+	ssInfos := ss.GetDeployedStatefulSetNames(client, cr.Namespace, []types.NamespacedName{resource})
+
+	active := map[string]bool{}
+	for _, jk := range jolokia_client.GetBrokers(resource, ssInfos, client) {
+		isActive, err := jk.Artemis.IsActive()
+		if err != nil {
+			reqLogger.V(2).Info("unknown active state reported from Jolokia", "IP", jk.IP, "Ordinal", jk.Ordinal, "error", err)
+			continue
+		}
+		active[jk.Ordinal] = isActive
+	}
+
+	previousLive := map[string]string{}
+	for _, pairStatus := range cr.Status.HA {
+		previousLive[pairStatus.Name] = pairStatus.Live
+	}
+
+	pairs := []brokerv1beta1.HAPairStatus{}
+	for pair := int32(0); pair < common.GetDeploymentSize(cr)/2; pair++ {
+		pairStatus := brokerv1beta1.HAPairStatus{Name: haPairName(pair)}
+		podNames := map[string]string{}
+		ordinals := []string{strconv.Itoa(int(2 * pair)), strconv.Itoa(int(2*pair + 1))}
+		for _, ordinal := range ordinals {
+			podNames[ordinal] = namer.CrToSS(cr.Name) + "-" + ordinal
+		}
+
+		// a split brain can leave both active, the primary is preferred
+		for _, ordinal := range ordinals {
+			if isActive, known := active[ordinal]; known && isActive {
+				pairStatus.Live = podNames[ordinal]
+				break
+			}
+		}
+		if pairStatus.Live == "" {
+			for _, ordinal := range ordinals {
+				if _, known := active[ordinal]; !known && previousLive[pairStatus.Name] == podNames[ordinal] {
+					pairStatus.Live = podNames[ordinal]
+				}
+			}
+		}
+
+		for _, ordinal := range ordinals {
+			if _, known := active[ordinal]; !known {
+				continue
+			}
+			if err := updateLivePairLabel(client, types.NamespacedName{Name: podNames[ordinal], Namespace: cr.Namespace}, pairStatus.Name, pairStatus.Live == podNames[ordinal]); err != nil {
+				reqLogger.Error(err, "failed to update live pair label", "pod", podNames[ordinal])
+			}
+		}
+		pairs = append(pairs, pairStatus)
+	}
+	cr.Status.HA = pairs
+}
+
+func updateLivePairLabel(client rtclient.Client, podKey types.NamespacedName, pairName string, live bool) error {
+	pod := &corev1.Pod{}
+	if err := client.Get(context.TODO(), podKey, pod); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	current, labelled := pod.Labels[HALivePairLabelKey]
+	if live == labelled && (!live || current == pairName) {
+		return nil
+	}
+
+	patch := rtclient.MergeFrom(pod.DeepCopy())
+	if live {
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		pod.Labels[HALivePairLabelKey] = pairName
+	} else {
+		delete(pod.Labels, HALivePairLabelKey)
+	}
+	return client.Patch(context.TODO(), pod, patch)
+}
+
+func validateHA(customResource *brokerv1beta1.ActiveMQArtemis) *metav1.Condition {
+	var message string
+	size := common.GetDeploymentSize(customResource)
+
+	if size%2 != 0 {
+		message = fmt.Sprintf(".Spec.HA pairs brokers by ordinal, .Spec.DeploymentPlan.Size %d must be even", size)
+	} else if !customResource.Spec.DeploymentPlan.PersistenceEnabled {
+		message = ".Spec.HA requires .Spec.DeploymentPlan.PersistenceEnabled"
+	} else if !isClustered(customResource) {
+		message = ".Spec.HA requires a clustered deployment, .Spec.DeploymentPlan.Clustered can't be false"
+	} else if customResource.Spec.DeploymentPlan.MessageMigration != nil && *customResource.Spec.DeploymentPlan.MessageMigration {
+		message = ".Spec.HA brokers are scaled down by pair, .Spec.DeploymentPlan.MessageMigration is not supported"
+	} else if isSharedStore(customResource) && customResource.Spec.HA.SharedStoreClaimName == "" {
+		message = ".Spec.HA.SharedStoreClaimName is required by the shared-store policy"
+	} else if isSharedStore(customResource) && len(separateBrokerDirectories(customResource)) > 0 {
+		message = ".Spec.HA shared-store keeps the broker directories on the shared store, they can't have separate volumes in .Spec.DeploymentPlan.Storage"
+	} else if version, err := common.ResolveBrokerVersionFromCR(customResource); err == nil && semver.MustParse(version).LT(semver.MustParse(haMinimumBrokerVersion)) {
+		message = fmt.Sprintf(".Spec.HA requires broker version %s or later, .Spec.Version resolves to %s", haMinimumBrokerVersion, version)
+	}
+
+	if message == "" {
+		return nil
+	}
+	return &metav1.Condition{
+		Type:    brokerv1beta1.ValidConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  brokerv1beta1.ValidConditionFailedInvalidHA,
+		Message: message,
+	}
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newHATestCr(policy brokerv1beta1.HAPolicy) *brokerv1beta1.ActiveMQArtemis {
+	cr := newTestBroker("br", 4)
+	cr.Spec.DeploymentPlan.PersistenceEnabled = true
+	cr.Spec.HA = &brokerv1beta1.HAType{Policy: policy}
+	if policy == brokerv1beta1.HAPolicySharedStore {
+		cr.Spec.HA.SharedStoreClaimName = "journals"
+	}
+	return cr
+}
+
+func TestHAReplicationPairsByOrdinal(t *testing.T) {
+	cr := newHATestCr(brokerv1beta1.HAPolicyReplication)
+	cr.Spec.HA.AllowFailback = &[]bool{false}[0]
+	cr.Spec.BrokerProperties = []string{"broker-3.HAPolicyConfiguration.allowFailBack=true"}
+	namer := MakeNamers(cr)
+	reconciler := NewActiveMQArtemisReconcilerImpl(cr, ctrl.Log.WithName("ha_test"), nil)
+
+	_, _, data, err := reconciler.addResourceForBrokerProperties(cr, *namer)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "HAPolicyConfiguration=REPLICATION_PRIMARY_QUORUM_VOTING\nHAPolicyConfiguration.groupName=pair-0\nHAPolicyConfiguration.checkForActiveServer=false\n", data["broker-0."+BrokerPropertiesName])
+	assert.Equal(t, "HAPolicyConfiguration=REPLICATION_BACKUP_QUORUM_VOTING\nHAPolicyConfiguration.groupName=pair-0\nHAPolicyConfiguration.allowFailBack=false\n", data["broker-1."+BrokerPropertiesName])
+	assert.Contains(t, data["broker-2."+BrokerPropertiesName], "HAPolicyConfiguration.groupName=pair-1\n")
+	// the user's value comes later so it wins
+	assert.Equal(t, "HAPolicyConfiguration=REPLICATION_BACKUP_QUORUM_VOTING\nHAPolicyConfiguration.groupName=pair-1\nHAPolicyConfiguration.allowFailBack=false\nHAPolicyConfiguration.allowFailBack=true\n", data["broker-3."+BrokerPropertiesName])
+	assert.NotContains(t, data[BrokerPropertiesName], "HAPolicyConfiguration")
+
+	ss, err := reconciler.NewStatefulSetForCR(cr, *namer, nil, fake.NewClientBuilder().Build())
+	assert.NoError(t, err)
+	assert.Equal(t, appsv1.ParallelPodManagement, ss.Spec.PodManagementPolicy)
+	assert.Equal(t, int32(4), *ss.Spec.Replicas)
+}
+
+func TestHASharedStorePairsShareJournal(t *testing.T) {
+	cr := newHATestCr(brokerv1beta1.HAPolicySharedStore)
+	namer := MakeNamers(cr)
+	reconciler := NewActiveMQArtemisReconcilerImpl(cr, ctrl.Log.WithName("ha_test"), nil)
+
+	_, _, data, err := reconciler.addResourceForBrokerProperties(cr, *namer)
+	assert.NoError(t, err)
+
+	for _, ordinal := range []string{"2", "3"} {
+		props := data["broker-"+ordinal+"."+BrokerPropertiesName]
+		assert.Contains(t, props, "journalDirectory=/opt/br/shared-store/pair-1/journal\n")
+		assert.Contains(t, props, "largeMessagesDirectory=/opt/br/shared-store/pair-1/large-messages\n")
+		assert.Contains(t, props, "HAPolicyConfiguration.failoverOnServerShutdown=true\n")
+	}
+	assert.Contains(t, data["broker-2."+BrokerPropertiesName], "HAPolicyConfiguration=SHARED_STORE_PRIMARY\n")
+	assert.Contains(t, data["broker-3."+BrokerPropertiesName], "HAPolicyConfiguration=SHARED_STORE_BACKUP\nHAPolicyConfiguration.allowFailBack=true\n")
+
+	podVolumes, err := reconciler.MakeVolumes(cr, *namer)
+	assert.NoError(t, err)
+	var sharedStore *corev1.Volume
+	for i := range podVolumes {
+		if podVolumes[i].Name == "br-shared-store" {
+			sharedStore = &podVolumes[i]
+		}
+	}
+	if assert.NotNil(t, sharedStore) {
+		assert.Equal(t, "journals", sharedStore.PersistentVolumeClaim.ClaimName)
+	}
+
+	mounts, err := reconciler.MakeVolumeMounts(cr, *namer)
+	assert.NoError(t, err)
+	assert.Contains(t, mounts, corev1.VolumeMount{Name: "br-shared-store", MountPath: "/opt/br/shared-store"})
+}
+
+func TestHAServicesSelectLivePod(t *testing.T) {
+	cr := newHATestCr(brokerv1beta1.HAPolicyReplication)
+	cr.Spec.Acceptors = []brokerv1beta1.AcceptorType{{Name: "amqp", Port: 5672}}
+	namer := MakeNamers(cr)
+	reconciler := NewActiveMQArtemisReconcilerImpl(cr, ctrl.Log.WithName("ha_test"), nil)
+
+	reconciler.configureHAServices(cr, *namer, fake.NewClientBuilder().Build())
+
+	services := reconciler.requestedResources[reflect.TypeOf(&corev1.Service{})]
+	assert.Len(t, services, 2)
+	service := services["br-amqp-pair-1-svc"].(*corev1.Service)
+	assert.Equal(t, "pair-1", service.Spec.Selector[HALivePairLabelKey])
+	assert.Equal(t, "br", service.Spec.Selector["ActiveMQArtemis"])
+	assert.Equal(t, int32(5672), service.Spec.Ports[0].Port)
+}
+
+func TestUpdateLivePairLabel(t *testing.T) {
+	backup := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "br-ss-1", Namespace: "test", Labels: map[string]string{"ActiveMQArtemis": "br"}}}
+	primary := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "br-ss-0", Namespace: "test", Labels: map[string]string{"ActiveMQArtemis": "br", HALivePairLabelKey: "pair-0"}}}
+	client := fake.NewClientBuilder().WithObjects(primary, backup).Build()
+
+	// failover, the backup is live now
+	assert.NoError(t, updateLivePairLabel(client, types.NamespacedName{Name: "br-ss-0", Namespace: "test"}, "pair-0", false))
+	assert.NoError(t, updateLivePairLabel(client, types.NamespacedName{Name: "br-ss-1", Namespace: "test"}, "pair-0", true))
+	// pods that are gone are skipped
+	assert.NoError(t, updateLivePairLabel(client, types.NamespacedName{Name: "br-ss-2", Namespace: "test"}, "pair-1", true))
+
+	assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{Name: "br-ss-0", Namespace: "test"}, primary))
+	assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{Name: "br-ss-1", Namespace: "test"}, backup))
+	assert.NotContains(t, primary.Labels, HALivePairLabelKey)
+	assert.Equal(t, "br", primary.Labels["ActiveMQArtemis"])
+	assert.Equal(t, "pair-0", backup.Labels[HALivePairLabelKey])
+}
+
+func TestValidateHA(t *testing.T) {
+	assert.Nil(t, validateHA(newHATestCr(brokerv1beta1.HAPolicyReplication)))
+	assert.Nil(t, validateHA(newHATestCr(brokerv1beta1.HAPolicySharedStore)))
+
+	for name, mutate := range map[string]func(cr *brokerv1beta1.ActiveMQArtemis){
+		"odd size":              func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.DeploymentPlan.Size = &[]int32{3}[0] },
+		"default size":          func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.DeploymentPlan.Size = nil },
+		"no persistence":        func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.DeploymentPlan.PersistenceEnabled = false },
+		"not clustered":         func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.DeploymentPlan.Clustered = &[]bool{false}[0] },
+		"message migration":     func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.DeploymentPlan.MessageMigration = &[]bool{true}[0] },
+		"old broker":            func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.Version = "2.28.0" },
+		"no shared store claim": func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.HA.SharedStoreClaimName = "" },
+		"separate journal volume": func(cr *brokerv1beta1.ActiveMQArtemis) {
+			cr.Spec.DeploymentPlan.Storage.Journal = &brokerv1beta1.DirectoryStorageType{}
+		},
+	} {
+		cr := newHATestCr(brokerv1beta1.HAPolicySharedStore)
+		mutate(cr)
+		condition := validateHA(cr)
+		if assert.NotNil(t, condition, name) {
+			assert.Equal(t, metav1.ConditionFalse, condition.Status, name)
+			assert.Equal(t, brokerv1beta1.ValidConditionFailedInvalidHA, condition.Reason, name)
+		}
+	}
+}
+
+func TestHAEnableRecreatesStatefulSet(t *testing.T) {
+	client := fake.NewClientBuilder().Build()
+
+	cr := newHATestCr(brokerv1beta1.HAPolicyReplication)
+	deployedCr := cr.DeepCopy()
+	deployedCr.Spec.HA = nil
+	reconciler, desired := deployForStorageTest(t, deployedCr, cr, client)
+	assert.Equal(t, appsv1.ParallelPodManagement, desired.Spec.PodManagementPolicy)
+
+	reconciler.ProcessImmutableStatefulSetChanges(cr, *MakeNamers(cr), client, desired)
+
+	assert.Nil(t, reconciler.getFromDeployed(reflect.TypeOf(appsv1.StatefulSet{}), "br-ss"))
+	assert.NoError(t, client.Create(context.TODO(), desired))
+
+	// the recreated statefulset is kept
+	reconciler.deployed = map[reflect.Type][]rtclient.Object{reflect.TypeOf(appsv1.StatefulSet{}): {desired.DeepCopy()}}
+	reconciler.ProcessImmutableStatefulSetChanges(cr, *MakeNamers(cr), client, desired)
+	assert.NotNil(t, reconciler.getFromDeployed(reflect.TypeOf(appsv1.StatefulSet{}), "br-ss"))
+}
@@ -105,6 +105,14 @@ type ActiveMQArtemisReconcilerImpl struct {
 	log                logr.Logger
 	customResource     *brokerv1beta1.ActiveMQArtemis
 	scheme             *runtime.Scheme
+	brokerConnections  []*brokerConnection
+	federations        []*federation
+	addressProperties  []string
+	securitySecrets    *securitySecrets
+	securityDelivery   *securityDelivery
+	// configured by the deployed broker properties but no longer desired
+	removedDiverts []string
+	removedBridges []string
 }
 
 func NewActiveMQArtemisReconcilerImpl(customResource *brokerv1beta1.ActiveMQArtemis, logger logr.Logger, schemeArg *runtime.Scheme) *ActiveMQArtemisReconcilerImpl {
@@ -139,6 +147,12 @@ func (reconciler *ActiveMQArtemisReconcilerImpl) Process(customResource *brokerv
 
 	reconciler.CurrentDeployedResources(customResource, client)
 
+	reconciler.brokerConnections = resolveBrokerConnectionsFor(customResource, client, reconciler.log)
+	reconciler.federations = resolveFederationsFor(customResource, client, reconciler.log)
+	reconciler.addressProperties = resolveAddressPropertiesFor(customResource, client, reconciler.log)
+	reconciler.securitySecrets = resolveSecuritySecretsFor(customResource, client, reconciler.log)
+	reconciler.securityDelivery = resolveSecurityDeliveryFor(customResource, client, reconciler.log)
+
 	// currentStateful Set is a clone of what exists if already deployed
 	// what follows should transform the resources using the crd
 	// if the transformation results in some change, process resources will respect that
@@ -149,6 +163,10 @@ func (reconciler *ActiveMQArtemisReconcilerImpl) Process(customResource *brokerv
 		return err
 	}
 
+	reconciler.ProcessStorageExpansion(customResource, namer, client, desiredStatefulSet)
+
+	reconciler.ProcessImmutableStatefulSetChanges(customResource, namer, client, desiredStatefulSet)
+
 	reconciler.ProcessDeploymentPlan(customResource, namer, client, scheme, desiredStatefulSet)
 
 	reconciler.ProcessCredentials(customResource, namer, client, scheme, desiredStatefulSet)
@@ -178,6 +196,8 @@ func (reconciler *ActiveMQArtemisReconcilerImpl) Process(customResource *brokerv
 
 	if err != nil {
 		reconciler.log.Error(err, "error processing resources")
+	} else {
+		reconciler.destroyRemovedRouting(customResource, client)
 	}
 
 	//empty the collected objects
@@ -239,6 +259,15 @@ func (reconciler *ActiveMQArtemisReconcilerImpl) addToDeployed(kind reflect.Type
 	reconciler.deployed[kind] = append(reconciler.deployed[kind], obj)
 }
 
+func (reconciler *ActiveMQArtemisReconcilerImpl) removeFromDeployed(kind reflect.Type, name string) {
+	for i, obj := range reconciler.deployed[kind] {
+		if obj.GetName() == name {
+			reconciler.deployed[kind] = append(reconciler.deployed[kind][:i], reconciler.deployed[kind][i+1:]...)
+			return
+		}
+	}
+}
+
 func (reconciler *ActiveMQArtemisReconcilerImpl) ProcessStatefulSet(customResource *brokerv1beta1.ActiveMQArtemis, namer common.Namers, client rtclient.Client) (*appsv1.StatefulSet, error) {
 
 	reqLogger := reconciler.log.WithName(customResource.Name)
@@ -524,6 +553,10 @@ func (r *ActiveMQArtemisReconcilerImpl) syncMessageMigration(customResource *bro
 			r.log.V(2).Info("Won't set up scaledown for non persistent deployment")
 			return
 		}
+		if customResource.Spec.HA != nil {
+			r.log.V(2).Info("Won't set up scaledown for HA pairs, their messages stay with the pair")
+			return
+		}
 		r.log.V(2).Info("we need scaledown for this cr", "crName", customResource.Name, "scheme", scheme)
 		if err = resources.Retrieve(namespacedName, client, scaledown); err != nil {
 			// err means not found so create
@@ -890,6 +923,8 @@ func (reconciler *ActiveMQArtemisReconcilerImpl) configureAcceptorsExposure(cust
 			}
 		}
 	}
+
+	reconciler.configureHAServices(customResource, namer, client)
 }
 
 func (reconciler *ActiveMQArtemisReconcilerImpl) ServiceDefinitionForCR(serviceName types.NamespacedName, client rtclient.Client, nameSuffix string, portNumber int32, selectorLabels map[string]string, labels map[string]string) *corev1.Service {
@@ -1673,6 +1708,16 @@ func (r *ActiveMQArtemisReconcilerImpl) MakeVolumes(customResource *brokerv1beta
 	if customResource.Spec.DeploymentPlan.PersistenceEnabled {
 		basicCRVolume := volumes.MakePersistentVolume(customResource.Name)
 		volumeDefinitions = append(volumeDefinitions, basicCRVolume...)
+
+		for _, directory := range separateBrokerDirectories(customResource) {
+			volumeDefinitions = append(volumeDefinitions, volumes.MakePersistentVolume(directory.volumeName(customResource))...)
+		}
+
+		if isSharedStore(customResource) {
+			sharedStoreVolume := volumes.MakePersistentVolume(haSharedStoreVolumeName(customResource))
+			sharedStoreVolume[0].PersistentVolumeClaim.ClaimName = customResource.Spec.HA.SharedStoreClaimName
+			volumeDefinitions = append(volumeDefinitions, sharedStoreVolume...)
+		}
 	}
 
 	volumeDefinitions = append(volumeDefinitions, customResource.Spec.DeploymentPlan.ExtraVolumes...)
@@ -1724,6 +1769,24 @@ func (r *ActiveMQArtemisReconcilerImpl) MakeVolumes(customResource *brokerv1beta
 		}
 	}
 
+	for _, connection := range r.brokerConnections {
+		if connection.trustSecret != "" {
+			addNewVolumes(secretVolumes, &volumeDefinitions, &connection.trustSecret)
+		}
+	}
+
+	for _, federation := range r.federations {
+		for i := range federation.trustSecrets {
+			addNewVolumes(secretVolumes, &volumeDefinitions, &federation.trustSecrets[i])
+		}
+	}
+
+	if r.securitySecrets != nil {
+		for i := range r.securitySecrets.secrets {
+			addNewVolumes(secretVolumes, &volumeDefinitions, &r.securitySecrets.secrets[i])
+		}
+	}
+
 	return volumeDefinitions, nil
 }
 
@@ -1741,6 +1804,14 @@ func (r *ActiveMQArtemisReconcilerImpl) MakeVolumeMounts(customResource *brokerv
 	if customResource.Spec.DeploymentPlan.PersistenceEnabled {
 		persistentCRVlMnt := volumes.MakePersistentVolumeMount(customResource.Name, namer.GLOBAL_DATA_PATH)
 		volumeMounts = append(volumeMounts, persistentCRVlMnt...)
+
+		for _, directory := range separateBrokerDirectories(customResource) {
+			volumeMounts = append(volumeMounts, volumes.MakePersistentVolumeMount(directory.volumeName(customResource), directory.mountPath(namer))...)
+		}
+
+		if isSharedStore(customResource) {
+			volumeMounts = append(volumeMounts, volumes.MakePersistentVolumeMount(haSharedStoreVolumeName(customResource), haSharedStoreMountPath(namer))...)
+		}
 	}
 
 	for _, volume := range customResource.Spec.DeploymentPlan.ExtraVolumes {
@@ -1821,6 +1892,27 @@ func (r *ActiveMQArtemisReconcilerImpl) MakeVolumeMounts(customResource *brokerv
 		}
 	}
 
+	for _, connection := range r.brokerConnections {
+		if connection.trustSecret != "" {
+			volMountName := connection.trustSecret + "-volume"
+			addNewVolumeMounts(secretVolumeMounts, &volumeMounts, &volMountName)
+		}
+	}
+
+	for _, federation := range r.federations {
+		for _, trustSecret := range federation.trustSecrets {
+			volMountName := trustSecret + "-volume"
+			addNewVolumeMounts(secretVolumeMounts, &volumeMounts, &volMountName)
+		}
+	}
+
+	if r.securitySecrets != nil {
+		for _, secret := range r.securitySecrets.secrets {
+			volMountName := secret + "-volume"
+			addNewVolumeMounts(secretVolumeMounts, &volumeMounts, &volMountName)
+		}
+	}
+
 	return volumeMounts, nil
 }
 
@@ -1898,6 +1990,16 @@ func (reconciler *ActiveMQArtemisReconcilerImpl) NewPodTemplateSpecForCR(customR
 	} else {
 		configMapsToCreate = append(configMapsToCreate, brokerPropertiesResourceName)
 	}
+	addressPropertiesResourceName := reconciler.addResourceForAddressProperties(customResource, namer)
+	if addressPropertiesResourceName != "" {
+		secretsToCreate = append(secretsToCreate, addressPropertiesResourceName)
+	}
+	securityJaasConfigResourceName, securityPropertiesResourceName := reconciler.addResourceForSecurity(customResource, namer)
+	for _, name := range []string{securityJaasConfigResourceName, securityPropertiesResourceName} {
+		if name != "" {
+			secretsToCreate = append(secretsToCreate, name)
+		}
+	}
 	extraVolumes, extraVolumeMounts, err := reconciler.createExtraConfigmapsAndSecretsVolumeMounts(configMapsToCreate, secretsToCreate, brokerPropertiesResourceName, brokerPropertiesMapData, client)
 	if err != nil {
 		return nil, err
@@ -1966,6 +2068,29 @@ func (reconciler *ActiveMQArtemisReconcilerImpl) NewPodTemplateSpecForCR(customR
 			Value: fmt.Sprintf("-Djava.security.auth.login.config=%v", jaasConfigPath),
 		}
 		environments.CreateOrAppend(podSpec.Containers, &debugArgs)
+	} else if reconciler.securityDelivery != nil && reconciler.securityDelivery.jaasConfig != nil {
+		debugArgs := corev1.EnvVar{
+			Name:  "DEBUG_ARGS",
+			Value: securityJaasConfigArgs(reconciler.securityDelivery, getSecurityJaasConfigSecretName(customResource).Name),
+		}
+		environments.CreateOrAppend(podSpec.Containers, &debugArgs)
+	}
+
+	// the trust store of the ldaps:// login modules of the security CR
+	if reconciler.securitySecrets != nil && reconciler.securitySecrets.javaArgs != "" {
+		for i := range reconciler.securitySecrets.env {
+			environments.Create(podSpec.Containers, &reconciler.securitySecrets.env[i])
+		}
+		trustOpts := corev1.EnvVar{
+			Name:  "JAVA_ARGS_APPEND",
+			Value: reconciler.securitySecrets.javaArgs,
+		}
+		environments.CreateOrAppend(podSpec.Containers, &trustOpts)
+	}
+
+	// the key of the passwords the security CR masked
+	if codecKey := securityCodecKeyEnvVar(customResource); codecKey != nil {
+		environments.Create(podSpec.Containers, codecKey)
 	}
 
 	if loggingConfigPath, found := getLoggingConfigExtraMountPath(customResource); found {
@@ -2096,6 +2221,13 @@ func (reconciler *ActiveMQArtemisReconcilerImpl) NewPodTemplateSpecForCR(customR
 		mountPoint = cfgMapPathBase
 	}
 	brokerPropsValue := brokerPropertiesConfigSystemPropValue(customResource, mountPoint, brokerPropertiesResourceName, brokerPropertiesMapData)
+	if addressPropertiesResourceName != "" {
+		// after the broker properties, the directory is watched for the address changes
+		brokerPropsValue = fmt.Sprintf("%s,%s%s/", brokerPropsValue, secretPathBase, addressPropertiesResourceName)
+	}
+	if securityPropertiesResourceName != "" {
+		brokerPropsValue = fmt.Sprintf("%s,%s%s/", brokerPropsValue, secretPathBase, securityPropertiesResourceName)
+	}
 
 	// only use init container JAVA_OPTS on existing deployments and migrate to JDK_JAVA_OPTIONS for independence
 	// from init containers and broker run scripts
@@ -2392,7 +2524,14 @@ func (reconciler *ActiveMQArtemisReconcilerImpl) addResourceForBrokerProperties(
 	// fetch and do idempotent transform based on CR
 
 	// deal with upgrade to immutable secret, only upgrade to mutable on not found
-	alder32Bytes := alder32Of(customResource.Spec.BrokerProperties)
+	// the properties generated from the CR and its dependent CRs go first, the
+	// user's brokerProperties are applied last so that they can override them
+	brokerProperties := append(brokerDirectoryProperties(customResource, namer), brokerHAProperties(customResource, namer)...)
+	brokerProperties = append(brokerProperties, brokerConnectionProperties(reconciler.brokerConnections)...)
+	brokerProperties = append(brokerProperties, federationProperties(reconciler.federations)...)
+	brokerProperties = append(brokerProperties, brokerRoutingProperties(customResource)...)
+	brokerProperties = append(brokerProperties, customResource.Spec.BrokerProperties...)
+	alder32Bytes := alder32Of(brokerProperties)
 	shaOfMap := hex.EncodeToString(alder32Bytes)
 	resourceName := types.NamespacedName{
 		Namespace: customResource.Namespace,
@@ -2417,13 +2556,14 @@ func (reconciler *ActiveMQArtemisReconcilerImpl) addResourceForBrokerProperties(
 		desired = obj.(*corev1.Secret)
 	}
 
-	data := brokerPropertiesData(customResource.Spec.BrokerProperties)
+	data := brokerPropertiesData(brokerProperties)
 
 	if desired == nil {
 		reconciler.log.V(1).Info("desired brokerprop secret nil, create new one", "name", resourceName.Name)
 		secret := secrets.MakeSecret(resourceName, data, namer.LabelBuilder.Labels())
 		desired = &secret
 	} else {
+		reconciler.removedDiverts, reconciler.removedBridges = removedRouting(desired.Data, brokerProperties)
 		desired.StringData = data
 	}
 
@@ -2680,6 +2820,13 @@ func (reconciler *ActiveMQArtemisReconcilerImpl) NewStatefulSetForCR(customResou
 	replicas := common.GetDeploymentSize(customResource)
 	currentStateFullSet = ss.MakeStatefulSet(currentStateFullSet, namer.SsNameBuilder.Name(), namer.SvcHeadlessNameBuilder.Name(), namespacedName, nil, namer.LabelBuilder.Labels(), &replicas)
 
+	// the policy can't be updated, a change recreates the statefulset in ProcessImmutableStatefulSetChanges
+	currentStateFullSet.Spec.PodManagementPolicy = appsv1.OrderedReadyPodManagement
+	if customResource.Spec.HA != nil {
+		// a backup may not report ready till it takes over, it must not hold up the next pair
+		currentStateFullSet.Spec.PodManagementPolicy = appsv1.ParallelPodManagement
+	}
+
 	podTemplateSpec, err := reconciler.NewPodTemplateSpecForCR(customResource, namer, &currentStateFullSet.Spec.Template, client)
 	if err != nil {
 		reqLogger.Error(err, "Error creating new pod template")
@@ -2722,6 +2869,24 @@ func (reconciler *ActiveMQArtemisReconcilerImpl) NewPersistentVolumeClaimArrayFo
 			reconciler.applyTemplates(pvc)
 			pvcArray = append(pvcArray, *pvc)
 		}
+
+		for _, directory := range separateBrokerDirectories(customResource) {
+			directoryName := types.NamespacedName{
+				Name:      directory.volumeName(customResource),
+				Namespace: customResource.Namespace,
+			}
+			directoryCapacity := capacity
+			if directory.storage.Size != "" {
+				directoryCapacity = directory.storage.Size
+			}
+			directoryStorageClassName := storageClassName
+			if directory.storage.StorageClassName != "" {
+				directoryStorageClassName = directory.storage.StorageClassName
+			}
+			pvc = persistentvolumeclaims.NewPersistentVolumeClaimWithCapacityAndStorageClassName(directoryName, directoryCapacity, namer.LabelBuilder.Labels(), directoryStorageClassName, []corev1.PersistentVolumeAccessMode{"ReadWriteOnce"})
+			reconciler.applyTemplates(pvc)
+			pvcArray = append(pvcArray, *pvc)
+		}
 	}
 
 	for _, epvc := range customResource.Spec.DeploymentPlan.ExtraVolumeClaimTemplates {
@@ -2861,7 +3026,7 @@ func ProcessBrokerStatus(cr *brokerv1beta1.ActiveMQArtemis, client rtclient.Clie
 	}
 	meta.SetStatusCondition(&cr.Status.Conditions, condition)
 
-	if _, _, found := getConfigExtraMount(cr, jaasConfigSuffix); found {
+	if _, found := getJaasConfigSecretName(cr); found {
 		err = AssertJaasPropertiesStatus(cr, client, scheme)
 		if err == nil {
 			condition = metav1.Condition{
@@ -2988,6 +3153,40 @@ func AssertBrokerPropertiesStatus(cr *brokerv1beta1.ActiveMQArtemis, client rtcl
 		}
 	}
 
+	if errorStatus == nil {
+		addressSecret := &corev1.Secret{}
+		if err := client.Get(context.TODO(), getAddressPropertiesSecretName(cr), addressSecret); err == nil {
+			secretProjection = newProjectionFromByteValues(addressSecret.ObjectMeta, addressSecret.Data)
+			errorStatus = checkProjectionStatus(cr, client, secretProjection, func(BrokerStatus *brokerStatus, FileName string) (propertiesStatus, bool) {
+				current, present := BrokerStatus.BrokerConfigStatus.PropertiesStatus[FileName]
+				return current, present
+			})
+			if errorStatus == nil {
+				updateExtraConfigStatus(cr, secretProjection)
+			}
+		} else if !k8serrors.IsNotFound(err) {
+			reqLogger.V(2).Info("error retrieving the address properties secret. requeing")
+			return NewUnknownJolokiaError(err)
+		}
+	}
+
+	if errorStatus == nil {
+		securitySecret := &corev1.Secret{}
+		if err := client.Get(context.TODO(), getSecurityPropertiesSecretName(cr), securitySecret); err == nil {
+			secretProjection = newProjectionFromByteValues(securitySecret.ObjectMeta, securitySecret.Data)
+			errorStatus = checkProjectionStatus(cr, client, secretProjection, func(BrokerStatus *brokerStatus, FileName string) (propertiesStatus, bool) {
+				current, present := BrokerStatus.BrokerConfigStatus.PropertiesStatus[FileName]
+				return current, present
+			})
+			if errorStatus == nil {
+				updateExtraConfigStatus(cr, secretProjection)
+			}
+		} else if !k8serrors.IsNotFound(err) {
+			reqLogger.V(2).Info("error retrieving the security properties secret. requeing")
+			return NewUnknownJolokiaError(err)
+		}
+	}
+
 	return errorStatus
 }
 
@@ -3051,23 +3250,11 @@ func checkStatus(cr *brokerv1beta1.ActiveMQArtemis, client rtclient.Client, chec
 	}
 
 	for _, jk := range jks {
-		currentJson, err := jk.Artemis.GetStatus()
-
+		brokerStatus, err := getBrokerStatus(jk, reqLogger)
 		if err != nil {
-			reqLogger.V(2).Info("unknown status reported from Jolokia.", "IP", jk.IP, "Ordinal", jk.Ordinal, "error", err)
 			return NewUnknownJolokiaError(err)
 		}
 
-		reqLogger.V(2).Info("raw json status", "IP", jk.IP, "ordinal", jk.Ordinal, "status json", currentJson)
-
-		brokerStatus, err := unmarshallStatus(currentJson)
-		if err != nil {
-			reqLogger.Error(err, "unable to unmarshall broker status", "json", currentJson)
-			return NewUnknownJolokiaError(err)
-		}
-
-		reqLogger.V(2).Info("broker status", "ordinal", jk.Ordinal, "status", brokerStatus)
-
 		artemisError := checkBrokerStatus(&brokerStatus, jk)
 		if artemisError != nil {
 			return artemisError
@@ -3077,6 +3264,27 @@ func checkStatus(cr *brokerv1beta1.ActiveMQArtemis, client rtclient.Client, chec
 	return nil
 }
 
+// getBrokerStatus reads the status the broker reports through jolokia
+func getBrokerStatus(jk *jolokia_client.JkInfo, reqLogger logr.Logger) (brokerStatus, error) {
+	currentJson, err := jk.Artemis.GetStatus()
+
+	if err != nil {
+		reqLogger.V(2).Info("unknown status reported from Jolokia.", "IP", jk.IP, "Ordinal", jk.Ordinal, "error", err)
+		return brokerStatus{}, err
+	}
+
+	reqLogger.V(2).Info("raw json status", "IP", jk.IP, "ordinal", jk.Ordinal, "status json", currentJson)
+
+	status, err := unmarshallStatus(currentJson)
+	if err != nil {
+		reqLogger.Error(err, "unable to unmarshall broker status", "json", currentJson)
+		return status, err
+	}
+
+	reqLogger.V(2).Info("broker status", "ordinal", jk.Ordinal, "status", status)
+	return status, nil
+}
+
 func checkProjectionStatus(cr *brokerv1beta1.ActiveMQArtemis, client rtclient.Client, secretProjection *projection, extractStatus func(BrokerStatus *brokerStatus, FileName string) (propertiesStatus, bool)) ArtemisError {
 	reqLogger := ctrl.Log.WithValues("ActiveMQArtemis Name", cr.Name)
 
@@ -3195,12 +3403,24 @@ func getSecretProjection(secretName types.NamespacedName, client rtclient.Client
 }
 
 func getConfigMappedJaasProperties(cr *brokerv1beta1.ActiveMQArtemis, client rtclient.Client) (*projection, error) {
-	if _, name, found := getConfigExtraMount(cr, jaasConfigSuffix); found {
+	if name, found := getJaasConfigSecretName(cr); found {
 		return getSecretProjection(types.NamespacedName{Namespace: cr.Namespace, Name: name}, client)
 	}
 	return nil, nil
 }
 
+// getJaasConfigSecretName gives the -jaas-config extra mount of the CR, or the
+// jaas config secret of a security CR with the Secrets delivery mode
+func getJaasConfigSecretName(cr *brokerv1beta1.ActiveMQArtemis) (string, bool) {
+	if _, name, found := getConfigExtraMount(cr, jaasConfigSuffix); found {
+		return name, true
+	}
+	if handler, ok := GetBrokerConfigHandler(types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}).(*ActiveMQArtemisSecurityConfigHandler); ok && deliveredBySecrets(handler.SecurityCR) {
+		return getSecurityJaasConfigSecretName(cr).Name, true
+	}
+	return "", false
+}
+
 func newProjectionFromByteValues(resourceMeta metav1.ObjectMeta, configKeyValue map[string][]byte) *projection {
 	projection := projection{Name: resourceMeta.Name, ResourceVersion: resourceMeta.ResourceVersion, Generation: resourceMeta.Generation, Files: map[string]propertyFile{}}
 	for prop_file_name, data := range configKeyValue {
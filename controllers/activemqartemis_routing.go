@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	ss "github.com/artemiscloud/activemq-artemis-operator/pkg/resources/statefulsets"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia_client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The diverts and bridges of the CR are configured with broker properties. A
+// broker reload applies new ones but does not take away the ones that are no
+// longer configured, the operator destroys those through jolokia.
+
+const (
+	divertConfigurationsKey = "divertConfigurations."
+	bridgeConfigurationsKey = "bridgeConfigurations."
+)
+
+// brokerRoutingProperties configures the diverts and bridges of the CR
+func brokerRoutingProperties(customResource *brokerv1beta1.ActiveMQArtemis) []string {
+	props := []string{}
+
+	for _, divert := range customResource.Spec.Diverts {
+		prefix := divertConfigurationsKey + divert.Name + "."
+		props = append(props,
+			prefix+"address="+divert.Address,
+			prefix+"forwardingAddress="+divert.ForwardingAddress)
+		if divert.Exclusive != nil {
+			props = append(props, prefix+"exclusive="+strconv.FormatBool(*divert.Exclusive))
+		}
+		if divert.Filter != "" {
+			props = append(props, prefix+"filterString="+divert.Filter)
+		}
+		if divert.RoutingName != "" {
+			props = append(props, prefix+"routingName="+divert.RoutingName)
+		}
+		if divert.RoutingType != "" {
+			props = append(props, prefix+"routingType="+divert.RoutingType)
+		}
+		props = append(props, transformerProperties(prefix, divert.Transformer)...)
+	}
+
+	for _, bridge := range customResource.Spec.Bridges {
+		prefix := bridgeConfigurationsKey + bridge.Name + "."
+		props = append(props,
+			prefix+"queueName="+bridge.QueueName,
+			prefix+"staticConnectors="+strings.Join(bridge.StaticConnectors, ","))
+		if bridge.ForwardingAddress != "" {
+			props = append(props, prefix+"forwardingAddress="+bridge.ForwardingAddress)
+		}
+		if bridge.Filter != "" {
+			props = append(props, prefix+"filterString="+bridge.Filter)
+		}
+		if bridge.RoutingType != "" {
+			props = append(props, prefix+"routingType="+bridge.RoutingType)
+		}
+		if bridge.HA != nil {
+			props = append(props, prefix+"ha="+strconv.FormatBool(*bridge.HA))
+		}
+		if bridge.RetryInterval != nil {
+			props = append(props, prefix+"retryInterval="+strconv.FormatInt(*bridge.RetryInterval, 10))
+		}
+		if bridge.ReconnectAttempts != nil {
+			props = append(props, prefix+"reconnectAttempts="+strconv.Itoa(int(*bridge.ReconnectAttempts)))
+		}
+		if bridge.UseDuplicateDetection != nil {
+			props = append(props, prefix+"useDuplicateDetection="+strconv.FormatBool(*bridge.UseDuplicateDetection))
+		}
+		props = append(props, transformerProperties(prefix, bridge.Transformer)...)
+	}
+
+	return props
+}
+
+func transformerProperties(prefix string, transformer *brokerv1beta1.TransformerType) []string {
+	if transformer == nil {
+		return nil
+	}
+	// the class name creates the transformer configuration, it has to come first
+	props := []string{prefix + "transformerConfiguration=" + transformer.ClassName}
+	for _, key := range sortedKeys(transformer.Properties) {
+		props = append(props, prefix+"transformerConfiguration.properties."+key+"="+transformer.Properties[key])
+	}
+	return props
+}
+
+// configuredRoutingNames finds the diverts and bridges configured by broker
+// properties, whether from the CR or the user's brokerProperties
+func configuredRoutingNames(lines []string) (map[string]bool, map[string]bool) {
+	diverts, bridges := map[string]bool{}, map[string]bool{}
+	for _, line := range lines {
+		for key, names := range map[string]map[string]bool{divertConfigurationsKey: diverts, bridgeConfigurationsKey: bridges} {
+			if !strings.HasPrefix(line, key) {
+				continue
+			}
+			if end := strings.IndexAny(line[len(key):], ".="); end > 0 {
+				names[line[len(key):len(key)+end]] = true
+			}
+		}
+	}
+	return diverts, bridges
+}
+
+// removedRouting gives the diverts and bridges that the deployed broker
+// properties configure and the desired ones don't
+func removedRouting(deployed map[string][]byte, desired []string) ([]string, []string) {
+	deployedLines := []string{}
+	for _, contents := range deployed {
+		deployedLines = append(deployedLines, strings.Split(string(contents), "\n")...)
+	}
+	deployedDiverts, deployedBridges := configuredRoutingNames(deployedLines)
+
+	desiredLines := []string{}
+	for _, prop := range desired {
+		// ordinal prefixed properties are deployed without the prefix
+		if strings.HasPrefix(prop, OrdinalPrefix) {
+			if i := strings.Index(prop, OrdinalPrefixSep); i > 0 {
+				prop = prop[i+len(OrdinalPrefixSep):]
+			}
+		}
+		desiredLines = append(desiredLines, prop)
+	}
+	desiredDiverts, desiredBridges := configuredRoutingNames(desiredLines)
+
+	removed := func(deployed map[string]bool, desired map[string]bool) []string {
+		names := []string{}
+		for name := range deployed {
+			if !desired[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+	return removed(deployedDiverts, desiredDiverts), removed(deployedBridges, desiredBridges)
+}
+
+// destroyRemovedRouting destroys the removed diverts and bridges on the running
+// brokers. A broker that can't be reached drops them when it restarts as broker
+// properties are not persisted
+func (reconciler *ActiveMQArtemisReconcilerImpl) destroyRemovedRouting(customResource *brokerv1beta1.ActiveMQArtemis, client rtclient.Client) {
+	if len(reconciler.removedDiverts) == 0 && len(reconciler.removedBridges) == 0 {
+		return
+	}
+	reqLogger := ctrl.Log.WithValues("ActiveMQArtemis Name", customResource.Name)
+
+	resource := types.NamespacedName{
+		Name:      customResource.Name,
+		Namespace: customResource.Namespace,
+	}
+	ssInfos := ss.GetDeployedStatefulSetNames(client, customResource.Namespace, []types.NamespacedName{resource})
+
+	for _, jk := range jolokia_client.GetBrokers(resource, ssInfos, client) {
+		for _, name := range reconciler.removedDiverts {
+			if _, err := jk.Artemis.DestroyDivert(name); err != nil {
+				reqLogger.V(1).Info("unable to destroy divert", "divert", name, "Ordinal", jk.Ordinal, "error", err)
+			}
+		}
+		for _, name := range reconciler.removedBridges {
+			if _, err := jk.Artemis.DestroyBridge(name); err != nil {
+				reqLogger.V(1).Info("unable to destroy bridge", "bridge", name, "Ordinal", jk.Ordinal, "error", err)
+			}
+		}
+	}
+}
+
+func validateRouting(customResource *brokerv1beta1.ActiveMQArtemis) *metav1.Condition {
+	var message string
+
+	checkName := func(kind string, name string, seen map[string]bool) string {
+		if name == "" || strings.ContainsAny(name, ".=") {
+			return fmt.Sprintf("%s name %q is a broker property key, it is required and can't contain '.' or '='", kind, name)
+		}
+		if seen[name] {
+			return fmt.Sprintf("%s name %s is not unique", kind, name)
+		}
+		seen[name] = true
+		return ""
+	}
+
+	diverts := map[string]bool{}
+	for _, divert := range customResource.Spec.Diverts {
+		if message = checkName(".Spec.Diverts", divert.Name, diverts); message != "" {
+			break
+		}
+		if divert.Address == "" || divert.ForwardingAddress == "" {
+			message = fmt.Sprintf(".Spec.Diverts %s requires an address and a forwardingAddress", divert.Name)
+			break
+		}
+		if divert.Transformer != nil && divert.Transformer.ClassName == "" {
+			message = fmt.Sprintf(".Spec.Diverts %s transformer requires a className", divert.Name)
+			break
+		}
+	}
+
+	bridges := map[string]bool{}
+	for _, bridge := range customResource.Spec.Bridges {
+		if message != "" {
+			break
+		}
+		if message = checkName(".Spec.Bridges", bridge.Name, bridges); message != "" {
+			break
+		}
+		if bridge.QueueName == "" || len(bridge.StaticConnectors) == 0 {
+			message = fmt.Sprintf(".Spec.Bridges %s requires a queueName and staticConnectors", bridge.Name)
+			break
+		}
+		if bridge.Transformer != nil && bridge.Transformer.ClassName == "" {
+			message = fmt.Sprintf(".Spec.Bridges %s transformer requires a className", bridge.Name)
+			break
+		}
+	}
+
+	if message == "" {
+		return nil
+	}
+	return &metav1.Condition{
+		Type:    brokerv1beta1.ValidConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  brokerv1beta1.ValidConditionFailedInvalidRouting,
+		Message: message,
+	}
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"testing"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func newRoutingTestCr() *brokerv1beta1.ActiveMQArtemis {
+	cr := newTestBroker("br", 2)
+	cr.Spec.Diverts = []brokerv1beta1.DivertType{{
+		Name:              "audit",
+		Address:           "orders",
+		ForwardingAddress: "orders.audit",
+		Exclusive:         &[]bool{false}[0],
+		Filter:            "priority > 4",
+		Transformer: &brokerv1beta1.TransformerType{
+			ClassName:  "org.example.Tag",
+			Properties: map[string]string{"tag": "audit", "header": "source"},
+		},
+	}}
+	cr.Spec.Bridges = []brokerv1beta1.BridgeType{{
+		Name:              "to-dc2",
+		QueueName:         "orders.outbound",
+		ForwardingAddress: "orders",
+		StaticConnectors:  []string{"dc2-0", "dc2-1"},
+		ReconnectAttempts: &[]int32{-1}[0],
+	}}
+	return cr
+}
+
+func TestRoutingBrokerProperties(t *testing.T) {
+	cr := newRoutingTestCr()
+	cr.Spec.BrokerProperties = []string{"bridgeConfigurations.to-dc2.confirmationWindowSize=1048576"}
+	namer := MakeNamers(cr)
+	reconciler := NewActiveMQArtemisReconcilerImpl(cr, ctrl.Log.WithName("routing_test"), nil)
+
+	_, _, data, err := reconciler.addResourceForBrokerProperties(cr, *namer)
+	assert.NoError(t, err)
+
+	assert.Contains(t, data[BrokerPropertiesName], "divertConfigurations.audit.address=orders\n"+
+		"divertConfigurations.audit.forwardingAddress=orders.audit\n"+
+		"divertConfigurations.audit.exclusive=false\n"+
+		"divertConfigurations.audit.filterString=priority > 4\n"+
+		"divertConfigurations.audit.transformerConfiguration=org.example.Tag\n"+
+		"divertConfigurations.audit.transformerConfiguration.properties.header=source\n"+
+		"divertConfigurations.audit.transformerConfiguration.properties.tag=audit\n")
+	assert.Contains(t, data[BrokerPropertiesName], "bridgeConfigurations.to-dc2.queueName=orders.outbound\n"+
+		"bridgeConfigurations.to-dc2.staticConnectors=dc2-0,dc2-1\n"+
+		"bridgeConfigurations.to-dc2.forwardingAddress=orders\n"+
+		"bridgeConfigurations.to-dc2.reconnectAttempts=-1\n"+
+		// the user's properties come after and can tune the bridge
+		"bridgeConfigurations.to-dc2.confirmationWindowSize=1048576\n")
+}
+
+func TestRemovedRouting(t *testing.T) {
+	deployed := map[string][]byte{
+		BrokerPropertiesName: []byte("# generated by crd\n#\n" +
+			"divertConfigurations.audit.address=orders\n" +
+			"divertConfigurations.old.address=invoices\n" +
+			"bridgeConfigurations.to-dc2.queueName=orders.outbound\n" +
+			"bridgeConfigurations.to-dc3.queueName=orders.outbound\n"),
+		"broker-1." + BrokerPropertiesName: []byte("divertConfigurations.only-one.address=orders\n"),
+	}
+
+	diverts, bridges := removedRouting(deployed, brokerRoutingProperties(newRoutingTestCr()))
+	assert.Equal(t, []string{"old", "only-one"}, diverts)
+	assert.Equal(t, []string{"to-dc3"}, bridges)
+
+	// a divert that moved to the ordinal properties is still configured
+	diverts, _ = removedRouting(deployed, append(brokerRoutingProperties(newRoutingTestCr()), "broker-1.divertConfigurations.only-one.address=orders"))
+	assert.Equal(t, []string{"old"}, diverts)
+
+	diverts, bridges = removedRouting(nil, brokerRoutingProperties(newRoutingTestCr()))
+	assert.Empty(t, diverts)
+	assert.Empty(t, bridges)
+}
+
+func TestValidateRouting(t *testing.T) {
+	assert.Nil(t, validateRouting(newRoutingTestCr()))
+
+	for name, mutate := range map[string]func(cr *brokerv1beta1.ActiveMQArtemis){
+		"dotted divert name":        func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.Diverts[0].Name = "orders.audit" },
+		"no divert name":            func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.Diverts[0].Name = "" },
+		"no forwarding address":     func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.Diverts[0].ForwardingAddress = "" },
+		"transformer without class": func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.Diverts[0].Transformer.ClassName = "" },
+		"duplicate bridge": func(cr *brokerv1beta1.ActiveMQArtemis) {
+			cr.Spec.Bridges = append(cr.Spec.Bridges, cr.Spec.Bridges[0])
+		},
+		"no queue":             func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.Bridges[0].QueueName = "" },
+		"no static connectors": func(cr *brokerv1beta1.ActiveMQArtemis) { cr.Spec.Bridges[0].StaticConnectors = nil },
+	} {
+		cr := newRoutingTestCr()
+		mutate(cr)
+		condition := validateRouting(cr)
+		if assert.NotNil(t, condition, name) {
+			assert.Equal(t, metav1.ConditionFalse, condition.Status, name)
+			assert.Equal(t, brokerv1beta1.ValidConditionFailedInvalidRouting, condition.Reason, name)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/common"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// brokerDirectory is a broker data directory that can be given a volume of its own
+type brokerDirectory struct {
+	// suffix of the volume name and mount path
+	name string
+	// broker property that points the broker at the directory
+	property string
+	storage  *brokerv1beta1.DirectoryStorageType
+}
+
+// brokerDirectories returns the broker data directories that hold messages
+func brokerDirectories(storage *brokerv1beta1.StorageType) []brokerDirectory {
+	return []brokerDirectory{
+		{name: "journal", property: "journalDirectory", storage: storage.Journal},
+		{name: "bindings", property: "bindingsDirectory", storage: storage.Bindings},
+		{name: "paging", property: "pagingDirectory", storage: storage.Paging},
+		{name: "large-messages", property: "largeMessagesDirectory", storage: storage.LargeMessages},
+	}
+}
+
+// separateBrokerDirectories returns the directories of a persistent broker
+// that are configured with a separate volume
+func separateBrokerDirectories(customResource *brokerv1beta1.ActiveMQArtemis) []brokerDirectory {
+	directories := []brokerDirectory{}
+	if !customResource.Spec.DeploymentPlan.PersistenceEnabled {
+		return directories
+	}
+	for _, directory := range brokerDirectories(&customResource.Spec.DeploymentPlan.Storage) {
+		if directory.storage != nil {
+			directories = append(directories, directory)
+		}
+	}
+	return directories
+}
+
+func (directory *brokerDirectory) volumeName(customResource *brokerv1beta1.ActiveMQArtemis) string {
+	return customResource.Name + "-" + directory.name
+}
+
+// the directory is mounted next to the data directory, /opt/<cr name>/<directory name>
+func (directory *brokerDirectory) mountPath(namer common.Namers) string {
+	return path.Join(path.Dir(namer.GLOBAL_DATA_PATH), directory.name)
+}
+
+// brokerDirectoryProperties points the broker at the separate directory volumes
+func brokerDirectoryProperties(customResource *brokerv1beta1.ActiveMQArtemis, namer common.Namers) []string {
+	props := []string{}
+	for _, directory := range separateBrokerDirectories(customResource) {
+		props = append(props, directory.property+"="+directory.mountPath(namer))
+	}
+	return props
+}
+
+// validateStorage parses the storage sizes, the volume claims are built with
+// resource.MustParse
+func validateStorage(customResource *brokerv1beta1.ActiveMQArtemis) *metav1.Condition {
+	invalid := func(field string, size string, err error) *metav1.Condition {
+		return &metav1.Condition{
+			Type:    brokerv1beta1.ValidConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  brokerv1beta1.ValidConditionFailedInvalidStorage,
+			Message: fmt.Sprintf("%s %q is not a valid quantity, %v", field, size, err),
+		}
+	}
+
+	storage := &customResource.Spec.DeploymentPlan.Storage
+	if storage.Size != "" {
+		if _, err := resource.ParseQuantity(storage.Size); err != nil {
+			return invalid(".Spec.DeploymentPlan.Storage.Size", storage.Size, err)
+		}
+	}
+	for _, directory := range brokerDirectories(storage) {
+		if directory.storage == nil || directory.storage.Size == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(directory.storage.Size); err != nil {
+			return invalid(".Spec.DeploymentPlan.Storage "+directory.name+" size", directory.storage.Size, err)
+		}
+	}
+	return nil
+}
+
+const (
+	volumeExpanded              = "Expanded"
+	volumeExpansionRequested    = "Requested"
+	volumeExpansionPending      = "Pending"
+	volumeExpansionNotSupported = "NotSupported"
+)
+
+// ProcessStorageExpansion grows the volume claims of the running brokers when
+// the desired statefulset asks for more storage than the deployed one.
+// VolumeClaimTemplates can't be updated, so once every claim has been patched
+// the deployed statefulset is deleted, leaving its pods running, and is
+// recreated from the desired template by ProcessResources.
+// Progress is reported per ordinal in the StorageExpanded condition. A storage
+// class that refuses the expansion is not asked again until the spec changes.
+func (reconciler *ActiveMQArtemisReconcilerImpl) ProcessStorageExpansion(customResource *brokerv1beta1.ActiveMQArtemis, namer common.Namers, client rtclient.Client, desired *appsv1.StatefulSet) {
+
+	reqLogger := reconciler.log.WithValues("ActiveMQArtemis Name", customResource.Name)
+
+	obj := reconciler.getFromDeployed(reflect.TypeOf(appsv1.StatefulSet{}), namer.SsNameBuilder.Name())
+	if obj == nil || !customResource.Spec.DeploymentPlan.PersistenceEnabled {
+		return
+	}
+	deployed := obj.(*appsv1.StatefulSet)
+
+	growing := growingVolumeClaimTemplates(deployed, desired)
+	current := meta.FindStatusCondition(customResource.Status.Conditions, brokerv1beta1.StorageExpandedConditionType)
+	if len(growing) == 0 && (current == nil || current.Status == metav1.ConditionTrue) {
+		return
+	}
+
+	if current != nil && current.Reason == brokerv1beta1.StorageExpandedConditionNotSupportedReason && current.ObservedGeneration == customResource.Generation {
+		// already refused for this spec, patching the claims again would only fail again
+		keepDeployedVolumeSizes(desired, growing)
+		return
+	}
+
+	var replicas int32 = 1
+	if deployed.Spec.Replicas != nil {
+		replicas = *deployed.Spec.Replicas
+	}
+
+	var notSupported []string
+	var patchFailed bool
+	progress := make([]string, 0, replicas)
+	for i := int32(0); i < replicas; i++ {
+		ordinalStatus := volumeExpanded
+		for _, template := range desired.Spec.VolumeClaimTemplates {
+			want, found := template.Spec.Resources.Requests[corev1.ResourceStorage]
+			if !found {
+				continue
+			}
+			pvcKey := types.NamespacedName{Namespace: customResource.Namespace, Name: template.Name + "-" + deployed.Name + "-" + strconv.Itoa(int(i))}
+			status, err := expandPersistentVolumeClaim(client, pvcKey, want)
+			if err != nil {
+				if k8serrors.IsForbidden(err) || k8serrors.IsInvalid(err) {
+					reqLogger.V(1).Info("volume claim can't be expanded", "pvc", pvcKey, "error", err)
+					notSupported = append(notSupported, fmt.Sprintf("%s: %v", pvcKey.Name, err))
+				} else {
+					reqLogger.Error(err, "failed to expand volume claim", "pvc", pvcKey)
+					patchFailed = true
+				}
+			}
+			if ordinalStatus == volumeExpanded {
+				ordinalStatus = status
+			}
+		}
+		progress = append(progress, fmt.Sprintf("%d=%s", i, ordinalStatus))
+	}
+
+	condition := metav1.Condition{
+		Type:               brokerv1beta1.StorageExpandedConditionType,
+		Status:             metav1.ConditionFalse,
+		Reason:             brokerv1beta1.StorageExpandedConditionInProgressReason,
+		Message:            strings.Join(progress, ", "),
+		ObservedGeneration: customResource.Generation,
+	}
+
+	if len(notSupported) > 0 {
+		// keep the deployed sizes, recreating the statefulset would not resize anything
+		keepDeployedVolumeSizes(desired, growing)
+		condition.Reason = brokerv1beta1.StorageExpandedConditionNotSupportedReason
+		condition.Message = "the storage class does not allow volume expansion, " + strings.Join(notSupported, "; ")
+	} else if patchFailed {
+		// the volume claim templates are immutable, keep the deployed sizes
+		// until every claim is patched on a later pass
+		keepDeployedVolumeSizes(desired, growing)
+	} else {
+		if len(growing) > 0 {
+			reqLogger.V(1).Info("recreating statefulset to match expanded volume claims", "name", deployed.Name)
+			if err := reconciler.recreateStatefulSet(client, deployed, desired); err != nil {
+				reqLogger.Error(err, "failed to delete statefulset for volume expansion", "name", deployed.Name)
+			}
+		}
+		if allExpanded(progress) {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = brokerv1beta1.StorageExpandedConditionCompleteReason
+		}
+	}
+
+	meta.SetStatusCondition(&customResource.Status.Conditions, condition)
+}
+
+// ProcessImmutableStatefulSetChanges deletes the deployed statefulset, leaving
+// its pods running, when the desired statefulset changes fields that can't be
+// updated, like the volume claim templates of the separate broker directories
+// or the pod management policy of high availability.
+// ProcessResources recreates it from the desired statefulset and the pods are
+// rolled onto the new template.
+func (reconciler *ActiveMQArtemisReconcilerImpl) ProcessImmutableStatefulSetChanges(customResource *brokerv1beta1.ActiveMQArtemis, namer common.Namers, client rtclient.Client, desired *appsv1.StatefulSet) {
+
+	obj := reconciler.getFromDeployed(reflect.TypeOf(appsv1.StatefulSet{}), namer.SsNameBuilder.Name())
+	if obj == nil {
+		return
+	}
+	deployed := obj.(*appsv1.StatefulSet)
+
+	changes := immutableStatefulSetChanges(deployed, desired)
+	if len(changes) == 0 {
+		return
+	}
+
+	reconciler.log.V(1).Info("recreating statefulset to apply immutable changes", "name", deployed.Name, "changes", changes)
+	if err := reconciler.recreateStatefulSet(client, deployed, desired); err != nil {
+		reconciler.log.Error(err, "failed to delete statefulset for immutable changes", "name", deployed.Name)
+	}
+}
+
+// recreateStatefulSet deletes the deployed statefulset without its pods and
+// leaves the desired one to be created by ProcessResources
+func (reconciler *ActiveMQArtemisReconcilerImpl) recreateStatefulSet(client rtclient.Client, deployed *appsv1.StatefulSet, desired *appsv1.StatefulSet) error {
+	if err := client.Delete(context.TODO(), deployed, rtclient.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	reconciler.removeFromDeployed(reflect.TypeOf(appsv1.StatefulSet{}), deployed.Name)
+	// desired was built on the deployed statefulset, a create must not carry its identity
+	desired.ResourceVersion = ""
+	desired.UID = ""
+	return nil
+}
+
+// immutableStatefulSetChanges describes the changes from the deployed to the
+// desired statefulset that the api server rejects on update
+func immutableStatefulSetChanges(deployed *appsv1.StatefulSet, desired *appsv1.StatefulSet) []string {
+	changes := []string{}
+	if !reflect.DeepEqual(volumeClaimTemplateNames(deployed), volumeClaimTemplateNames(desired)) {
+		changes = append(changes, fmt.Sprintf("volumeClaimTemplates %v to %v", volumeClaimTemplateNames(deployed), volumeClaimTemplateNames(desired)))
+	}
+	if podManagementPolicy(deployed) != podManagementPolicy(desired) {
+		changes = append(changes, fmt.Sprintf("podManagementPolicy %s to %s", podManagementPolicy(deployed), podManagementPolicy(desired)))
+	}
+	return changes
+}
+
+// podManagementPolicy gives the policy the api server applies when it is not set
+func podManagementPolicy(statefulSet *appsv1.StatefulSet) appsv1.PodManagementPolicyType {
+	if statefulSet.Spec.PodManagementPolicy == "" {
+		return appsv1.OrderedReadyPodManagement
+	}
+	return statefulSet.Spec.PodManagementPolicy
+}
+
+func volumeClaimTemplateNames(statefulSet *appsv1.StatefulSet) []string {
+	names := []string{}
+	for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+		names = append(names, template.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// growingVolumeClaimTemplates returns the deployed storage request of each
+// template that the desired statefulset wants to grow
+func growingVolumeClaimTemplates(deployed *appsv1.StatefulSet, desired *appsv1.StatefulSet) map[string]resource.Quantity {
+	growing := map[string]resource.Quantity{}
+	for _, template := range desired.Spec.VolumeClaimTemplates {
+		want, found := template.Spec.Resources.Requests[corev1.ResourceStorage]
+		if !found {
+			continue
+		}
+		for _, existing := range deployed.Spec.VolumeClaimTemplates {
+			if existing.Name != template.Name {
+				continue
+			}
+			if have, found := existing.Spec.Resources.Requests[corev1.ResourceStorage]; found && want.Cmp(have) > 0 {
+				growing[template.Name] = have
+			}
+		}
+	}
+	return growing
+}
+
+// keepDeployedVolumeSizes puts the deployed storage requests of the growing
+// templates back into the desired statefulset
+func keepDeployedVolumeSizes(desired *appsv1.StatefulSet, growing map[string]resource.Quantity) {
+	for name, have := range growing {
+		for i := range desired.Spec.VolumeClaimTemplates {
+			if desired.Spec.VolumeClaimTemplates[i].Name == name {
+				desired.Spec.VolumeClaimTemplates[i].Spec.Resources.Requests[corev1.ResourceStorage] = have
+			}
+		}
+	}
+}
+
+// expandPersistentVolumeClaim requests the wanted size on the claim if it asks
+// for less and reports how far the resize has got. Whether the storage class
+// allows expansion is enforced by the api server when the claim is updated.
+func expandPersistentVolumeClaim(client rtclient.Client, pvcKey types.NamespacedName, want resource.Quantity) (string, error) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := client.Get(context.TODO(), pvcKey, pvc); err != nil {
+		if k8serrors.IsNotFound(err) {
+			// the claim will be created from the template with the wanted size
+			return volumeExpanded, nil
+		}
+		return volumeExpansionPending, err
+	}
+
+	if requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; requested.Cmp(want) < 0 {
+		patch := rtclient.MergeFrom(pvc.DeepCopy())
+		if pvc.Spec.Resources.Requests == nil {
+			pvc.Spec.Resources.Requests = corev1.ResourceList{}
+		}
+		pvc.Spec.Resources.Requests[corev1.ResourceStorage] = want
+		if err := client.Patch(context.TODO(), pvc, patch); err != nil {
+			if k8serrors.IsForbidden(err) || k8serrors.IsInvalid(err) {
+				return volumeExpansionNotSupported, err
+			}
+			return volumeExpansionPending, err
+		}
+		return volumeExpansionRequested, nil
+	}
+
+	if capacity, found := pvc.Status.Capacity[corev1.ResourceStorage]; found && capacity.Cmp(want) >= 0 {
+		return volumeExpanded, nil
+	}
+
+	for _, condition := range pvc.Status.Conditions {
+		if condition.Status == corev1.ConditionTrue {
+			// Resizing or FileSystemResizePending
+			return string(condition.Type), nil
+		}
+	}
+	return volumeExpansionPending, nil
+}
+
+func allExpanded(progress []string) bool {
+	for _, ordinalStatus := range progress {
+		if !strings.HasSuffix(ordinalStatus, "="+volumeExpanded) {
+			return false
+		}
+	}
+	return true
+}
+
+func storageExpansionInProgress(customResource *brokerv1beta1.ActiveMQArtemis) bool {
+	condition := meta.FindStatusCondition(customResource.Status.Conditions, brokerv1beta1.StorageExpandedConditionType)
+	return condition != nil && condition.Reason == brokerv1beta1.StorageExpandedConditionInProgressReason
+}
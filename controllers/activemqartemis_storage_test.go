@@ -0,0 +1,339 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// a persistent broker with two ordinals
+func newStorageTestCr(size string) *brokerv1beta1.ActiveMQArtemis {
+	cr := newTestBroker("br", 2)
+	cr.Spec.DeploymentPlan.PersistenceEnabled = true
+	cr.Spec.DeploymentPlan.Storage.Size = size
+	return cr
+}
+
+func newStorageTestPvc(name string, size string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+		},
+	}
+}
+
+// deploys the statefulset for the given size and returns a reconciler for the cr
+func deployForStorageTest(t *testing.T, deployedCr *brokerv1beta1.ActiveMQArtemis, cr *brokerv1beta1.ActiveMQArtemis, client rtclient.Client) (*ActiveMQArtemisReconcilerImpl, *appsv1.StatefulSet) {
+	namer := MakeNamers(deployedCr)
+	deployer := NewActiveMQArtemisReconcilerImpl(deployedCr, ctrl.Log.WithName("storage_test"), client.Scheme())
+	deployedSs, err := deployer.NewStatefulSetForCR(deployedCr, *namer, nil, client)
+	assert.NoError(t, err)
+	deployedSs.Namespace = "test"
+	assert.NoError(t, client.Create(context.TODO(), deployedSs))
+
+	reconciler := NewActiveMQArtemisReconcilerImpl(cr, ctrl.Log.WithName("storage_test"), client.Scheme())
+	reconciler.deployed = map[reflect.Type][]rtclient.Object{reflect.TypeOf(appsv1.StatefulSet{}): {deployedSs}}
+	desired, err := reconciler.NewStatefulSetForCR(cr, *namer, deployedSs.DeepCopy(), client)
+	assert.NoError(t, err)
+	return reconciler, desired
+}
+
+func TestStorageExpansionPatchesClaimsAndRecreatesStatefulSet(t *testing.T) {
+	client := fake.NewClientBuilder().WithObjects(
+		newStorageTestPvc("br-br-ss-0", "2Gi"),
+		newStorageTestPvc("br-br-ss-1", "2Gi"),
+	).Build()
+
+	cr := newStorageTestCr("4Gi")
+	reconciler, desired := deployForStorageTest(t, newStorageTestCr("2Gi"), cr, client)
+
+	reconciler.ProcessStorageExpansion(cr, *MakeNamers(cr), client, desired)
+
+	for _, name := range []string{"br-br-ss-0", "br-br-ss-1"} {
+		pvc := &corev1.PersistentVolumeClaim{}
+		assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: name}, pvc))
+		requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		assert.Equal(t, "4Gi", requested.String())
+	}
+
+	err := client.Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: "br-ss"}, &appsv1.StatefulSet{})
+	assert.True(t, k8serrors.IsNotFound(err))
+	assert.Nil(t, reconciler.getFromDeployed(reflect.TypeOf(appsv1.StatefulSet{}), "br-ss"))
+
+	condition := meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.StorageExpandedConditionType)
+	assert.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, brokerv1beta1.StorageExpandedConditionInProgressReason, condition.Reason)
+	assert.Equal(t, "0=Requested, 1=Requested", condition.Message)
+	assert.True(t, storageExpansionInProgress(cr))
+}
+
+func TestStorageExpansionReportsProgressPerOrdinal(t *testing.T) {
+	resizing := newStorageTestPvc("br-br-ss-1", "4Gi")
+	resizing.Status.Capacity[corev1.ResourceStorage] = resource.MustParse("2Gi")
+	resizing.Status.Conditions = []corev1.PersistentVolumeClaimCondition{
+		{Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue},
+	}
+	client := fake.NewClientBuilder().WithObjects(newStorageTestPvc("br-br-ss-0", "4Gi"), resizing).Build()
+
+	cr := newStorageTestCr("4Gi")
+	meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
+		Type:   brokerv1beta1.StorageExpandedConditionType,
+		Status: metav1.ConditionFalse,
+		Reason: brokerv1beta1.StorageExpandedConditionInProgressReason,
+	})
+	reconciler, desired := deployForStorageTest(t, newStorageTestCr("4Gi"), cr, client)
+
+	reconciler.ProcessStorageExpansion(cr, *MakeNamers(cr), client, desired)
+
+	condition := meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.StorageExpandedConditionType)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "0=Expanded, 1=FileSystemResizePending", condition.Message)
+	assert.NotNil(t, reconciler.getFromDeployed(reflect.TypeOf(appsv1.StatefulSet{}), "br-ss"))
+
+	resizing.Status.Capacity[corev1.ResourceStorage] = resource.MustParse("4Gi")
+	resizing.Status.Conditions = nil
+	assert.NoError(t, client.Status().Update(context.TODO(), resizing))
+
+	reconciler.ProcessStorageExpansion(cr, *MakeNamers(cr), client, desired)
+
+	condition = meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.StorageExpandedConditionType)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, brokerv1beta1.StorageExpandedConditionCompleteReason, condition.Reason)
+	assert.False(t, storageExpansionInProgress(cr))
+}
+
+func TestStorageExpansionNotSupportedKeepsStatefulSet(t *testing.T) {
+	patches := 0
+	client := fake.NewClientBuilder().WithObjects(
+		newStorageTestPvc("br-br-ss-0", "2Gi"),
+		newStorageTestPvc("br-br-ss-1", "2Gi"),
+	).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(ctx context.Context, client rtclient.WithWatch, obj rtclient.Object, patch rtclient.Patch, opts ...rtclient.PatchOption) error {
+			patches++
+			return k8serrors.NewForbidden(corev1.Resource("persistentvolumeclaims"), obj.GetName(), nil)
+		},
+	}).Build()
+
+	cr := newStorageTestCr("4Gi")
+	cr.Generation = 2
+	reconciler, desired := deployForStorageTest(t, newStorageTestCr("2Gi"), cr, client)
+	next, changed := desired.DeepCopy(), desired.DeepCopy()
+
+	reconciler.ProcessStorageExpansion(cr, *MakeNamers(cr), client, desired)
+	assert.Equal(t, 2, patches)
+
+	assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: "br-ss"}, &appsv1.StatefulSet{}))
+	requested := desired.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal(t, "2Gi", requested.String())
+
+	condition := meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.StorageExpandedConditionType)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, brokerv1beta1.StorageExpandedConditionNotSupportedReason, condition.Reason)
+	assert.Equal(t, int64(2), condition.ObservedGeneration)
+	assert.False(t, storageExpansionInProgress(cr))
+
+	// the claims are not patched again for the same spec
+	reconciler.ProcessStorageExpansion(cr, *MakeNamers(cr), client, next)
+	assert.Equal(t, 2, patches)
+	requested = next.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal(t, "2Gi", requested.String())
+
+	// a spec change tries again
+	cr.Generation = 3
+	reconciler.ProcessStorageExpansion(cr, *MakeNamers(cr), client, changed)
+	assert.Equal(t, 4, patches)
+}
+
+func TestStorageExpansionNoopWithoutGrowth(t *testing.T) {
+	client := fake.NewClientBuilder().Build()
+
+	cr := newStorageTestCr("2Gi")
+	reconciler, desired := deployForStorageTest(t, newStorageTestCr("2Gi"), cr, client)
+
+	reconciler.ProcessStorageExpansion(cr, *MakeNamers(cr), client, desired)
+
+	assert.Nil(t, meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.StorageExpandedConditionType))
+	assert.NotNil(t, reconciler.getFromDeployed(reflect.TypeOf(appsv1.StatefulSet{}), "br-ss"))
+}
+
+func TestSeparateDirectoryVolumes(t *testing.T) {
+	cr := newStorageTestCr("4Gi")
+	cr.Spec.DeploymentPlan.Storage.StorageClassName = "standard"
+	cr.Spec.DeploymentPlan.Storage.Journal = &brokerv1beta1.DirectoryStorageType{Size: "1Gi", StorageClassName: "ssd"}
+	cr.Spec.DeploymentPlan.Storage.LargeMessages = &brokerv1beta1.DirectoryStorageType{}
+	cr.Spec.BrokerProperties = []string{"largeMessagesDirectory=/opt/br/data/large"}
+	namer := MakeNamers(cr)
+	reconciler := NewActiveMQArtemisReconcilerImpl(cr, ctrl.Log.WithName("storage_test"), nil)
+
+	claims := *reconciler.NewPersistentVolumeClaimArrayForCR(cr, *namer, 1)
+	assert.Len(t, claims, 3)
+	sizes := map[string]string{}
+	classes := map[string]string{}
+	for _, claim := range claims {
+		requested := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+		sizes[claim.Name] = requested.String()
+		classes[claim.Name] = *claim.Spec.StorageClassName
+	}
+	assert.Equal(t, map[string]string{"br": "4Gi", "br-journal": "1Gi", "br-large-messages": "4Gi"}, sizes)
+	assert.Equal(t, map[string]string{"br": "standard", "br-journal": "ssd", "br-large-messages": "standard"}, classes)
+
+	mounts, err := reconciler.MakeVolumeMounts(cr, *namer)
+	assert.NoError(t, err)
+	mountPaths := map[string]string{}
+	for _, mount := range mounts {
+		mountPaths[mount.Name] = mount.MountPath
+	}
+	assert.Equal(t, "/opt/br/data", mountPaths["br"])
+	assert.Equal(t, "/opt/br/journal", mountPaths["br-journal"])
+	assert.Equal(t, "/opt/br/large-messages", mountPaths["br-large-messages"])
+
+	podVolumes, err := reconciler.MakeVolumes(cr, *namer)
+	assert.NoError(t, err)
+	assert.Len(t, podVolumes, 3)
+
+	_, _, data, err := reconciler.addResourceForBrokerProperties(cr, *namer)
+	assert.NoError(t, err)
+	props := data[BrokerPropertiesName]
+	assert.Contains(t, props, "journalDirectory=/opt/br/journal\n")
+	assert.Contains(t, props, "largeMessagesDirectory=/opt/br/large-messages\n")
+	// the user's value comes later so it wins
+	assert.Less(t, strings.Index(props, "largeMessagesDirectory=/opt/br/large-messages"), strings.Index(props, "largeMessagesDirectory=/opt/br/data/large"))
+	assert.NotContains(t, props, "pagingDirectory")
+}
+
+func TestSeparateDirectoryVolumesNeedPersistence(t *testing.T) {
+	cr := newStorageTestCr("4Gi")
+	cr.Spec.DeploymentPlan.PersistenceEnabled = false
+	cr.Spec.DeploymentPlan.Storage.Paging = &brokerv1beta1.DirectoryStorageType{}
+
+	assert.Empty(t, separateBrokerDirectories(cr))
+	assert.Empty(t, brokerDirectoryProperties(cr, *MakeNamers(cr)))
+}
+
+func TestSeparateDirectoryVolumeToggleRecreatesStatefulSet(t *testing.T) {
+	client := fake.NewClientBuilder().Build()
+
+	cr := newStorageTestCr("2Gi")
+	cr.Spec.DeploymentPlan.Storage.Journal = &brokerv1beta1.DirectoryStorageType{Size: "1Gi"}
+	reconciler, desired := deployForStorageTest(t, newStorageTestCr("2Gi"), cr, client)
+	assert.Equal(t, []string{"br", "br-journal"}, volumeClaimTemplateNames(desired))
+
+	reconciler.ProcessImmutableStatefulSetChanges(cr, *MakeNamers(cr), client, desired)
+
+	err := client.Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: "br-ss"}, &appsv1.StatefulSet{})
+	assert.True(t, k8serrors.IsNotFound(err))
+	assert.Nil(t, reconciler.getFromDeployed(reflect.TypeOf(appsv1.StatefulSet{}), "br-ss"))
+	// ProcessResources can create the desired statefulset
+	assert.NoError(t, client.Create(context.TODO(), desired))
+
+	// and back off the separate volume
+	client = fake.NewClientBuilder().Build()
+	cr = newStorageTestCr("2Gi")
+	reconciler, desired = deployForStorageTest(t, newStorageTestCr("2Gi"), cr, client)
+	deployed := reconciler.getFromDeployed(reflect.TypeOf(appsv1.StatefulSet{}), "br-ss").(*appsv1.StatefulSet)
+	deployed.Spec.VolumeClaimTemplates = append(deployed.Spec.VolumeClaimTemplates, corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "br-paging"}})
+
+	reconciler.ProcessImmutableStatefulSetChanges(cr, *MakeNamers(cr), client, desired)
+
+	assert.Nil(t, reconciler.getFromDeployed(reflect.TypeOf(appsv1.StatefulSet{}), "br-ss"))
+}
+
+func TestImmutableStatefulSetChangesKeepsUnchangedStatefulSet(t *testing.T) {
+	client := fake.NewClientBuilder().Build()
+
+	cr := newStorageTestCr("2Gi")
+	cr.Spec.DeploymentPlan.Storage.Paging = &brokerv1beta1.DirectoryStorageType{}
+	deployedCr := cr.DeepCopy()
+	reconciler, desired := deployForStorageTest(t, deployedCr, cr, client)
+
+	reconciler.ProcessImmutableStatefulSetChanges(cr, *MakeNamers(cr), client, desired)
+
+	assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: "br-ss"}, &appsv1.StatefulSet{}))
+	assert.NotNil(t, reconciler.getFromDeployed(reflect.TypeOf(appsv1.StatefulSet{}), "br-ss"))
+}
+
+func TestStorageExpansionPatchFailureKeepsDeployedSizesAndRetries(t *testing.T) {
+	failing := true
+	client := fake.NewClientBuilder().WithObjects(
+		newStorageTestPvc("br-br-ss-0", "2Gi"),
+		newStorageTestPvc("br-br-ss-1", "2Gi"),
+	).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(ctx context.Context, client rtclient.WithWatch, obj rtclient.Object, patch rtclient.Patch, opts ...rtclient.PatchOption) error {
+			if failing && obj.GetName() == "br-br-ss-1" {
+				return k8serrors.NewServiceUnavailable("try again")
+			}
+			return client.Patch(ctx, obj, patch, opts...)
+		},
+	}).Build()
+
+	cr := newStorageTestCr("4Gi")
+	reconciler, desired := deployForStorageTest(t, newStorageTestCr("2Gi"), cr, client)
+	next := desired.DeepCopy()
+
+	reconciler.ProcessStorageExpansion(cr, *MakeNamers(cr), client, desired)
+
+	assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: "br-ss"}, &appsv1.StatefulSet{}))
+	requested := desired.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal(t, "2Gi", requested.String())
+
+	condition := meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.StorageExpandedConditionType)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, brokerv1beta1.StorageExpandedConditionInProgressReason, condition.Reason)
+	assert.True(t, storageExpansionInProgress(cr))
+
+	// the next pass patches the remaining claim and recreates the statefulset
+	failing = false
+	reconciler.ProcessStorageExpansion(cr, *MakeNamers(cr), client, next)
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: "br-br-ss-1"}, pvc))
+	requested = pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal(t, "4Gi", requested.String())
+	err := client.Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: "br-ss"}, &appsv1.StatefulSet{})
+	assert.True(t, k8serrors.IsNotFound(err))
+}
+
+func TestValidateStorageRejectsInvalidSizes(t *testing.T) {
+	assert.Nil(t, validateStorage(newStorageTestCr("2Gi")))
+
+	cr := newStorageTestCr("big")
+	condition := validateStorage(cr)
+	assert.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, brokerv1beta1.ValidConditionFailedInvalidStorage, condition.Reason)
+	assert.Contains(t, condition.Message, ".Spec.DeploymentPlan.Storage.Size")
+
+	cr = newStorageTestCr("2Gi")
+	cr.Spec.DeploymentPlan.Storage.Journal = &brokerv1beta1.DirectoryStorageType{Size: "1Gi"}
+	cr.Spec.DeploymentPlan.Storage.Paging = &brokerv1beta1.DirectoryStorageType{Size: "5 Gi"}
+	condition = validateStorage(cr)
+	assert.NotNil(t, condition)
+	assert.Equal(t, brokerv1beta1.ValidConditionFailedInvalidStorage, condition.Reason)
+	assert.Contains(t, condition.Message, "paging size \"5 Gi\"")
+
+	valid, _ := validate(cr, fake.NewClientBuilder().Build(), *MakeNamers(cr))
+	assert.False(t, valid)
+	assert.Equal(t, brokerv1beta1.ValidConditionFailedInvalidStorage, meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.ValidConditionType).Reason)
+}
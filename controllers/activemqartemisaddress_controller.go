@@ -18,8 +18,13 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/resources"
 	ss "github.com/artemiscloud/activemq-artemis-operator/pkg/resources/statefulsets"
 	mgmt "github.com/artemiscloud/activemq-artemis-operator/pkg/utils/artemis"
 	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/channels"
@@ -32,16 +37,24 @@ import (
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 type AddressDeployment struct {
 	AddressResource brokerv1beta1.ActiveMQArtemisAddress
+	// one for each address and queue of the CR
+	Definitions []*brokerv1beta1.ActiveMQArtemisAddress
 	//a 0-len array means all statefulsets
 	SsTargetNameBuilders []SSInfoData
 }
@@ -51,15 +64,24 @@ var namespacedNameToAddressName = make(map[types.NamespacedName]AddressDeploymen
 // ActiveMQArtemisAddressReconciler reconciles a ActiveMQArtemisAddress object
 type ActiveMQArtemisAddressReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
-	log    logr.Logger
+	Scheme           *runtime.Scheme
+	BrokerReconciler *ActiveMQArtemisReconciler
+	log              logr.Logger
+	recorder         record.EventRecorder
+	// target CRs and properties checksum last delivered for each address with
+	// the BrokerProperties delivery mode
+	delivered map[types.NamespacedName][]types.NamespacedName
+	checksums map[types.NamespacedName]string
 }
 
-func NewActiveMQArtemisAddressReconciler(client client.Client, scheme *runtime.Scheme, logger logr.Logger) *ActiveMQArtemisAddressReconciler {
+func NewActiveMQArtemisAddressReconciler(client client.Client, scheme *runtime.Scheme, brokerReconciler *ActiveMQArtemisReconciler, logger logr.Logger) *ActiveMQArtemisAddressReconciler {
 	return &ActiveMQArtemisAddressReconciler{
-		Client: client,
-		Scheme: scheme,
-		log:    logger,
+		Client:           client,
+		Scheme:           scheme,
+		BrokerReconciler: brokerReconciler,
+		log:              logger,
+		delivered:        make(map[types.NamespacedName][]types.NamespacedName),
+		checksums:        make(map[types.NamespacedName]string),
 	}
 }
 
@@ -86,9 +108,12 @@ func (r *ActiveMQArtemisAddressReconciler) Reconcile(ctx context.Context, reques
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Delete action
+			r.undeliver(request.NamespacedName, reqLogger)
 			if lookupSucceeded {
+				// the brokers keep an address that is no longer in their properties, the
+				// queue is deleted through jolokia whatever the delivery mode
 				if addressInstance.AddressResource.Spec.RemoveFromBrokerOnDelete {
-					err = r.deleteQueue(&addressInstance, request, r.Client)
+					err = r.deleteDefinitions(&addressInstance, request, r.Client)
 					if err != nil {
 						reqLogger.Error(err, "Failed to delete the queue")
 					}
@@ -110,12 +135,45 @@ func (r *ActiveMQArtemisAddressReconciler) Reconcile(ctx context.Context, reques
 		return ctrl.Result{}, err
 	}
 
+	if instance.DeletionTimestamp != nil {
+		return r.finalizeAddress(instance, request, reqLogger)
+	}
+	if syncAddressFinalizer(instance) {
+		if err = r.Update(context.TODO(), instance); err != nil {
+			reqLogger.Error(err, "unable to update the finalizer")
+			return ctrl.Result{}, err
+		}
+	}
+
+	condition := validateAddress(instance)
+	var definitions []*brokerv1beta1.ActiveMQArtemisAddress
+	if condition == nil {
+		definitions, condition = addressDefinitions(instance, r.Client)
+	}
+	if condition != nil {
+		condition.ObservedGeneration = instance.Generation
+		meta.SetStatusCondition(&instance.Status.Conditions, *condition)
+		meta.RemoveStatusCondition(&instance.Status.Conditions, brokerv1beta1.AddressAppliedConditionType)
+		r.undeliver(request.NamespacedName, reqLogger)
+		// nothing to apply until the CR is changed
+		return ctrl.Result{}, r.updateStatus(instance)
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               brokerv1beta1.ValidConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             brokerv1beta1.ValidConditionSuccessReason,
+		ObservedGeneration: instance.Generation,
+	})
+
 	addressDeployment := AddressDeployment{
 		AddressResource:      *instance,
+		Definitions:          definitions,
 		SsTargetNameBuilders: r.createNameBuilders(instance),
 	}
 
-	if !lookupSucceeded {
+	// an address delivered by broker properties is always reconciled after a
+	// restart to know its target brokers again
+	if !lookupSucceeded && !deliveredByBrokerProperties(instance) {
 		//check stored cr
 		if existingCr := lsrcrs.RetrieveLastSuccessfulReconciledCR(request.NamespacedName, "address", r.Client, getAddressLabels(instance)); existingCr != nil {
 			//compare resource version
@@ -123,12 +181,37 @@ func (r *ActiveMQArtemisAddressReconciler) Reconcile(ctx context.Context, reques
 				reqLogger.V(1).Info("The incoming address CR is identical to stored CR, don't do reconcile")
 				//the namespacedNameToAddressName is empty after a restart
 				namespacedNameToAddressName[request.NamespacedName] = addressDeployment
-				return ctrl.Result{RequeueAfter: common.GetReconcileResyncPeriod()}, nil
+				return ctrl.Result{RequeueAfter: common.GetAddressDriftRepairPeriod()}, nil
 			}
 		}
 	}
 
-	err = r.createQueue(&addressDeployment, request, r.Client)
+	moreBatches := false
+	if deliveredByBrokerProperties(instance) {
+		err = r.deliverByBrokerProperties(&addressDeployment, request, r.Client)
+	} else {
+		// an address no longer delivered by broker properties drops out of them
+		r.undeliver(request.NamespacedName, reqLogger)
+		if usesAddressEntries(instance) {
+			var previous *AddressDeployment
+			if lookupSucceeded {
+				previous = &addressInstance
+			}
+			moreBatches, err = r.applyAddressEntries(&addressDeployment, request, r.Client, addressEntriesChanged(&addressDeployment, previous))
+		} else if lookupSucceeded && addressInstance.AddressResource.Generation == instance.Generation {
+			// the CR is unchanged since it was applied
+			if common.GetAddressDriftRepairPeriod() == 0 {
+				return ctrl.Result{}, nil
+			}
+			err = r.repairQueue(&addressDeployment, request, r.Client)
+		} else {
+			err = r.createQueue(&addressDeployment, request, r.Client)
+		}
+	}
+	instance.Status = addressDeployment.AddressResource.Status
+	if statusErr := r.updateStatus(instance); statusErr != nil {
+		reqLogger.V(1).Info("unable to update status", "error", statusErr)
+	}
 	if nil == err {
 		namespacedNameToAddressName[request.NamespacedName] = addressDeployment
 		crstr, merr := common.ToJson(instance)
@@ -143,7 +226,45 @@ func (r *ActiveMQArtemisAddressReconciler) Reconcile(ctx context.Context, reques
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{RequeueAfter: common.GetReconcileResyncPeriod()}, nil
+	if moreBatches {
+		return ctrl.Result{RequeueAfter: addressBatchInterval}, nil
+	}
+	if deliveredByBrokerProperties(instance) {
+		// the status follows the brokers loading the properties
+		return ctrl.Result{RequeueAfter: common.GetReconcileResyncPeriod()}, nil
+	}
+	return ctrl.Result{RequeueAfter: common.GetAddressDriftRepairPeriod()}, nil
+}
+
+func (r *ActiveMQArtemisAddressReconciler) updateStatus(desired *brokerv1beta1.ActiveMQArtemisAddress) error {
+	common.SetReadyCondition(&desired.Status.Conditions)
+	return resources.UpdateStatus(r.Client, desired)
+}
+
+func validateAddress(instance *brokerv1beta1.ActiveMQArtemisAddress) *metav1.Condition {
+	var message string
+	if instance.Spec.AddressName == "" && !usesAddressEntries(instance) {
+		message = ".Spec.AddressName is required"
+	} else if instance.Spec.BatchSize != nil && *instance.Spec.BatchSize < 1 {
+		message = ".Spec.BatchSize must be at least 1"
+	} else if instance.Spec.BrokerSelector != nil && len(instance.Spec.ApplyToCrNames) > 0 {
+		message = ".Spec.BrokerSelector can't be used with .Spec.ApplyToCrNames"
+	} else if instance.Spec.RoutingType != nil && !strings.EqualFold(*instance.Spec.RoutingType, "anycast") && !strings.EqualFold(*instance.Spec.RoutingType, "multicast") {
+		message = fmt.Sprintf(".Spec.RoutingType %s is not one of anycast or multicast", *instance.Spec.RoutingType)
+	} else if addressDeletePolicy(instance) == brokerv1beta1.AddressDeletePolicies.MoveMessagesTo && instance.Spec.DeletePolicy.MoveMessagesTo == "" {
+		message = ".Spec.DeletePolicy.MoveMessagesTo is required with the MoveMessagesTo policy"
+	} else if addressDeletePolicy(instance) == brokerv1beta1.AddressDeletePolicies.MoveMessagesTo && instance.Spec.DeletePolicy.MoveMessagesTo == instance.Spec.AddressName {
+		message = ".Spec.DeletePolicy.MoveMessagesTo can't be the address itself"
+	}
+	if message == "" {
+		return nil
+	}
+	return &metav1.Condition{
+		Type:    brokerv1beta1.ValidConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  brokerv1beta1.ValidConditionInvalidAddressReason,
+		Message: message,
+	}
 }
 
 func getAddressLabels(cr *brokerv1beta1.ActiveMQArtemisAddress) map[string]string {
@@ -188,8 +309,10 @@ func (r *ActiveMQArtemisAddressReconciler) createNameBuilders(instance *brokerv1
 // SetupWithManager sets up the controller with the Manager.
 func (r *ActiveMQArtemisAddressReconciler) SetupWithManager(mgr ctrl.Manager, ctx context.Context) error {
 	go r.setupAddressObserver(mgr, ctx)
+	r.recorder = mgr.GetEventRecorderFor("activemqartemisaddress-controller")
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&brokerv1beta1.ActiveMQArtemisAddress{}).
+		// the status changes on every apply, only spec changes need a reconcile
+		For(&brokerv1beta1.ActiveMQArtemisAddress{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Owns(&corev1.Pod{}).
 		Complete(r)
 }
@@ -199,21 +322,29 @@ func (r *ActiveMQArtemisAddressReconciler) createQueue(instance *AddressDeployme
 
 	r.log.V(1).Info("Creating ActiveMQArtemisAddress")
 
-	var err error = nil
-	artemisArray := r.getPodBrokers(instance, request, client)
+	artemisArray, brokerCount, err := r.getPodBrokers(instance, request, client)
+	if err != nil {
+		return err
+	}
+	status := &instance.AddressResource.Status
+	status.Brokers = nil
+	status.BrokerCount = brokerCount
 	if nil != artemisArray {
 		for _, a := range artemisArray {
 			if nil == a {
 				r.log.V(1).Info("Creating ActiveMQArtemisAddress artemisArray had a nil!")
 				continue
 			}
-			err = createAddressResource(a, &instance.AddressResource, r.log)
+			var response *jolokia.ResponseData
+			response, err = createAddressResource(a, &instance.AddressResource, r.log)
+			setAddressBrokerStatus(status, newAddressBrokerStatus(a, &instance.AddressResource, response, err))
 			if err != nil {
 				r.log.V(1).Info("Failed to create address resource", "failed broker", a)
 				continue
 			}
 		}
 	}
+	setAddressAppliedCondition(&instance.AddressResource)
 
 	if err == nil {
 		r.log.V(1).Info("Successfully created resources on all brokers", "size", len(artemisArray))
@@ -222,18 +353,21 @@ func (r *ActiveMQArtemisAddressReconciler) createQueue(instance *AddressDeployme
 	return err
 }
 
-func createAddressResource(a *jc.JkInfo, addressRes *brokerv1beta1.ActiveMQArtemisAddress, log logr.Logger) error {
+// createAddressResource applies the address or queue to a broker, the
+// response of a failed request is returned with the error
+func createAddressResource(a *jc.JkInfo, addressRes *brokerv1beta1.ActiveMQArtemisAddress, log logr.Logger) (*jolokia.ResponseData, error) {
+	routingType := addressRoutingType(addressRes)
 	//Now checking if create queue or address
 	if addressRes.Spec.QueueName == nil || *addressRes.Spec.QueueName == "" {
 		//create address
-		response, err := a.Artemis.CreateAddress(addressRes.Spec.AddressName, *addressRes.Spec.RoutingType)
+		response, err := a.Artemis.CreateAddress(addressRes.Spec.AddressName, routingType)
 		if nil != err {
 			if mgmt.GetCreationError(response) == mgmt.ADDRESS_ALREADY_EXISTS {
 				log.V(1).Info("Address already exists, no retry", "address", addressRes.Spec.AddressName)
-				return nil
+				return nil, nil
 			} else {
 				log.Error(err, "Error creating ActiveMQArtemisAddress", "address", addressRes.Spec.AddressName)
-				return err
+				return response, err
 			}
 		} else {
 			log.V(1).Info("Created ActiveMQArtemisAddress for address " + addressRes.Spec.AddressName)
@@ -241,19 +375,14 @@ func createAddressResource(a *jc.JkInfo, addressRes *brokerv1beta1.ActiveMQArtem
 	} else {
 		log.V(1).Info("Queue name is not empty so create queue", "name", *addressRes.Spec.QueueName, "broker", a.IP)
 		//first make sure address exists
-		response, err := a.Artemis.CreateAddress(addressRes.Spec.AddressName, *addressRes.Spec.RoutingType)
+		response, err := a.Artemis.CreateAddress(addressRes.Spec.AddressName, routingType)
 		if nil != err && mgmt.GetCreationError(response) != mgmt.ADDRESS_ALREADY_EXISTS {
 			log.Error(err, "Error creating ActiveMQArtemisAddress", "address", addressRes.Spec.AddressName)
-			return err
+			return response, err
 		}
 
 		defaultConfigurationManaged := true
 		if addressRes.Spec.QueueConfiguration == nil {
-			routingType := "MULTICAST"
-			if addressRes.Spec.RoutingType != nil {
-				routingType = *addressRes.Spec.RoutingType
-			}
-
 			addressRes.Spec.QueueConfiguration = &brokerv1beta1.QueueConfigurationType{
 				RoutingType:          &routingType,
 				ConfigurationManaged: &defaultConfigurationManaged,
@@ -266,7 +395,7 @@ func createAddressResource(a *jc.JkInfo, addressRes *brokerv1beta1.ActiveMQArtem
 		if err != nil {
 			log.Error(err, "Failed to get queue config json string")
 			//here we return nil as no point to requeue reconcile again
-			return nil
+			return nil, nil
 		}
 		respData, err := a.Artemis.CreateQueueFromConfig(queueCfg, ignoreIfExists)
 		if nil != err {
@@ -276,15 +405,133 @@ func createAddressResource(a *jc.JkInfo, addressRes *brokerv1beta1.ActiveMQArtem
 				if err != nil {
 					log.Error(err, "Failed to update queue", "details", respData)
 				}
-				return err
+				return respData, err
 			}
 			log.Error(err, "Creating ActiveMQArtemisAddress error for "+*addressRes.Spec.QueueName)
-			return err
+			return respData, err
 		} else {
 			log.V(1).Info("Created ActiveMQArtemisAddress for " + *addressRes.Spec.QueueName)
 		}
 	}
-	return nil
+	return nil, nil
+}
+
+func addressRoutingType(addressRes *brokerv1beta1.ActiveMQArtemisAddress) string {
+	if addressRes.Spec.RoutingType == nil {
+		return defaultRoutingType
+	}
+	return *addressRes.Spec.RoutingType
+}
+
+// newAddressBrokerStatus gives the result of applying the address to a broker
+// along with the message and consumer counts the broker reports
+func newAddressBrokerStatus(a *jc.JkInfo, addressRes *brokerv1beta1.ActiveMQArtemisAddress, response *jolokia.ResponseData, err error) brokerv1beta1.AddressBrokerStatus {
+	status := brokerv1beta1.AddressBrokerStatus{
+		CrName:          a.CrName,
+		Ordinal:         a.Ordinal,
+		Result:          brokerv1beta1.AddressBrokerApplied,
+		LastAttemptTime: metav1.Now(),
+	}
+	if err != nil {
+		status.Result = brokerv1beta1.AddressBrokerFailed
+		status.ErrorCode = mgmt.GetCreationError(response)
+		status.Message = err.Error()
+		if response != nil && response.Error != "" {
+			status.Message = response.Error
+		}
+		return status
+	}
+
+	// the counts are best effort, they are left out when the broker doesn't report them
+	if addressRes.Spec.QueueName != nil && *addressRes.Spec.QueueName != "" {
+		routingType := addressRoutingType(addressRes)
+		if count, err := a.Artemis.GetQueueMessageCount(addressRes.Spec.AddressName, *addressRes.Spec.QueueName, routingType); err == nil {
+			status.MessageCount = &count
+		}
+		if count, err := a.Artemis.GetQueueConsumerCount(addressRes.Spec.AddressName, *addressRes.Spec.QueueName, routingType); err == nil {
+			status.ConsumerCount = &count
+		}
+	} else if count, err := a.Artemis.GetAddressMessageCount(addressRes.Spec.AddressName); err == nil {
+		status.MessageCount = &count
+	}
+	return status
+}
+
+// setAddressBrokerStatus replaces the status of a broker, keeping the brokers ordered
+func setAddressBrokerStatus(status *brokerv1beta1.ActiveMQArtemisAddressStatus, brokerStatus brokerv1beta1.AddressBrokerStatus) {
+	for i, existing := range status.Brokers {
+		if existing.CrName == brokerStatus.CrName && existing.Ordinal == brokerStatus.Ordinal {
+			status.Brokers[i] = brokerStatus
+			return
+		}
+	}
+	status.Brokers = append(status.Brokers, brokerStatus)
+	sort.SliceStable(status.Brokers, func(i, j int) bool {
+		if status.Brokers[i].CrName != status.Brokers[j].CrName {
+			return status.Brokers[i].CrName < status.Brokers[j].CrName
+		}
+		ordinalI, _ := strconv.Atoi(status.Brokers[i].Ordinal)
+		ordinalJ, _ := strconv.Atoi(status.Brokers[j].Ordinal)
+		return ordinalI < ordinalJ
+	})
+	if int32(len(status.Brokers)) > status.BrokerCount {
+		status.BrokerCount = int32(len(status.Brokers))
+	}
+}
+
+// setAddressAppliedCondition counts the brokers the address is applied to and
+// sets the Applied condition from them
+func setAddressAppliedCondition(instance *brokerv1beta1.ActiveMQArtemisAddress) {
+	status := &instance.Status
+	failed := []string{}
+	pending := 0
+	status.AppliedCount = 0
+	for _, broker := range status.Brokers {
+		switch broker.Result {
+		case brokerv1beta1.AddressBrokerApplied:
+			status.AppliedCount++
+		case brokerv1beta1.AddressBrokerPending:
+			pending++
+		default:
+			failed = append(failed, fmt.Sprintf("%s-%s %s", broker.CrName, broker.Ordinal, broker.ErrorCode))
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               brokerv1beta1.AddressAppliedConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             brokerv1beta1.AddressAppliedConditionSuccessReason,
+		Message:            fmt.Sprintf("applied to %d of %d brokers", status.AppliedCount, status.BrokerCount),
+		ObservedGeneration: instance.Generation,
+	}
+	if status.BrokerCount == 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = brokerv1beta1.AddressAppliedConditionNoBrokersReason
+		condition.Message = "no target broker is running"
+	} else if len(failed) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = brokerv1beta1.AddressAppliedConditionFailedReason
+		condition.Message += ", failed on " + strings.Join(failed, ", ")
+	} else if pending > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = brokerv1beta1.AddressAppliedConditionPendingReason
+		condition.Message += fmt.Sprintf(", pending on %d", pending)
+	} else if status.AppliedCount < status.BrokerCount {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = brokerv1beta1.AddressAppliedConditionFailedReason
+	}
+	if len(status.Entries) > 0 && status.BrokerCount > 0 {
+		summary, entriesFailed, entriesPending := addressEntriesSummary(status.Entries)
+		condition.Message += ", " + summary
+		if entriesFailed {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = brokerv1beta1.AddressAppliedConditionFailedReason
+		} else if entriesPending && condition.Status == metav1.ConditionTrue {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = brokerv1beta1.AddressAppliedConditionPendingReason
+		}
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
 }
 
 type AddressRetry struct {
@@ -322,22 +569,21 @@ func (ar *AddressRetry) safeDelete() {
 	}
 }
 
-// This method deals with deleting queues and addresses.
-func (r *ActiveMQArtemisAddressReconciler) deleteQueue(instance *AddressDeployment, request ctrl.Request, client client.Client) error {
+// This method deals with deleting the queue or address of a definition.
+func (r *ActiveMQArtemisAddressReconciler) deleteDefinition(definition *brokerv1beta1.ActiveMQArtemisAddress, artemisArray []*jc.JkInfo, request ctrl.Request) error {
 
 	reqLogger := r.log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 
-	addressName := instance.AddressResource.Spec.AddressName
+	addressName := definition.Spec.AddressName
 
 	queueName := ""
-	if instance.AddressResource.Spec.QueueName != nil {
-		queueName = *instance.AddressResource.Spec.QueueName
+	if definition.Spec.QueueName != nil {
+		queueName = *definition.Spec.QueueName
 	}
 
 	reqLogger.V(1).Info("Deleting ActiveMQArtemisAddress for queue " + addressName + "/" + queueName)
 
 	var err error = nil
-	artemisArray := r.getPodBrokers(instance, request, client)
 	if nil != artemisArray {
 		addressRetry := NewAddressRetry(addressName, make([]*mgmt.Artemis, 0), r.log.WithName("retry"))
 		for _, a := range artemisArray {
@@ -375,14 +621,32 @@ func (r *ActiveMQArtemisAddressReconciler) deleteQueue(instance *AddressDeployme
 	return err
 }
 
-func (r *ActiveMQArtemisAddressReconciler) getPodBrokers(instance *AddressDeployment, request ctrl.Request, client client.Client) []*jc.JkInfo {
+// getPodBrokers gives the running target brokers and the number of target brokers
+func (r *ActiveMQArtemisAddressReconciler) getPodBrokers(instance *AddressDeployment, request ctrl.Request, client client.Client) ([]*jc.JkInfo, int32, error) {
 	reqLogger := r.log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
 	reqLogger.V(2).Info("Getting Pod Brokers for address " + instance.AddressResource.Namespace + "/" + instance.AddressResource.Name)
-	targetCrNamespacedNames := createTargetCrNamespacedNames(request.Namespace, instance.AddressResource.Spec.ApplyToCrNames, reqLogger)
-	reqLogger.V(2).Info("target Cr names", "result", targetCrNamespacedNames)
-	ssInfos := ss.GetDeployedStatefulSetNames(client, request.Namespace, targetCrNamespacedNames)
+	var ssInfos []ss.StatefulSetInfo
+	if instance.AddressResource.Spec.BrokerSelector != nil {
+		selectedCrs, err := resolveSelectedCrs(&instance.AddressResource, client)
+		if err != nil {
+			reqLogger.V(1).Info("unable to select the target crs", "error", err)
+			return nil, 0, err
+		}
+		reqLogger.V(2).Info("selected Cr names", "result", selectedCrs)
+		for namespace, crs := range groupByNamespace(selectedCrs) {
+			ssInfos = append(ssInfos, ss.GetDeployedStatefulSetNames(client, namespace, crs)...)
+		}
+	} else {
+		targetCrNamespacedNames := createTargetCrNamespacedNames(request.Namespace, instance.AddressResource.Spec.ApplyToCrNames, reqLogger)
+		reqLogger.V(2).Info("target Cr names", "result", targetCrNamespacedNames)
+		ssInfos = ss.GetDeployedStatefulSetNames(client, request.Namespace, targetCrNamespacedNames)
+	}
 
-	return jc.GetBrokers(request.NamespacedName, ssInfos, client)
+	var brokerCount int32 = 0
+	for _, info := range ssInfos {
+		brokerCount += info.Replicas
+	}
+	return jc.GetBrokers(request.NamespacedName, ssInfos, client), brokerCount, nil
 }
 
 func createTargetCrNamespacedNames(namespace string, targetCrNames []string, log logr.Logger) []types.NamespacedName {
@@ -400,10 +664,120 @@ func createTargetCrNamespacedNames(namespace string, targetCrNames []string, log
 	return result
 }
 
+// resolveSelectedCrs finds the broker CRs selected by the brokerSelector of an
+// address, in the namespaces the address may target
+func resolveSelectedCrs(instance *brokerv1beta1.ActiveMQArtemisAddress, c client.Client) ([]types.NamespacedName, error) {
+	selector := instance.Spec.BrokerSelector
+
+	namespaces := []string{instance.Namespace}
+	if selector.NamespaceSelector != nil {
+		namespaceSelector, err := metav1.LabelSelectorAsSelector(selector.NamespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+		namespaceList := &corev1.NamespaceList{}
+		if err := namespaceReader(c).List(context.TODO(), namespaceList, &client.ListOptions{LabelSelector: namespaceSelector}); err != nil {
+			return nil, err
+		}
+		namespaces = nil
+		for _, namespace := range namespaceList.Items {
+			if common.IsAddressTargetNamespaceAllowed(instance.Namespace, namespace.Name) {
+				namespaces = append(namespaces, namespace.Name)
+			}
+		}
+	}
+
+	crSelector := labels.Everything()
+	if selector.LabelSelector != nil {
+		var err error
+		if crSelector, err = metav1.LabelSelectorAsSelector(selector.LabelSelector); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []types.NamespacedName = nil
+	for _, namespace := range namespaces {
+		crList := &brokerv1beta1.ActiveMQArtemisList{}
+		if err := c.List(context.TODO(), crList, &client.ListOptions{Namespace: namespace, LabelSelector: crSelector}); err != nil {
+			return nil, err
+		}
+		for _, cr := range crList.Items {
+			result = append(result, types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].String() < result[j].String()
+	})
+	return result, nil
+}
+
+// namespaceReader reads the cluster scoped namespaces from the api server, the
+// cache of the manager only watches namespaced resources
+func namespaceReader(c client.Client) client.Reader {
+	if mgr := common.GetManager(); mgr != nil {
+		return mgr.GetAPIReader()
+	}
+	return c
+}
+
+func groupByNamespace(crs []types.NamespacedName) map[string][]types.NamespacedName {
+	grouped := map[string][]types.NamespacedName{}
+	for _, cr := range crs {
+		grouped[cr.Namespace] = append(grouped[cr.Namespace], cr)
+	}
+	return grouped
+}
+
+// addressAppliesTo tells if an address applies to the brokers of a CR
+func addressAppliesTo(instance *brokerv1beta1.ActiveMQArtemisAddress, crName types.NamespacedName, c client.Client, log logr.Logger) bool {
+	if instance.Spec.BrokerSelector != nil {
+		selectedCrs, err := resolveSelectedCrs(instance, c)
+		if err != nil {
+			log.V(1).Info("unable to select the target crs", "address", instance.Name, "error", err)
+			return false
+		}
+		for _, selected := range selectedCrs {
+			if selected == crName {
+				return true
+			}
+		}
+		return false
+	}
+	if instance.Namespace != crName.Namespace {
+		return false
+	}
+	targetCrNamespacedNames := createTargetCrNamespacedNames(instance.Namespace, instance.Spec.ApplyToCrNames, log)
+	if targetCrNamespacedNames == nil {
+		return true
+	}
+	for _, target := range targetCrNamespacedNames {
+		if target == crName {
+			return true
+		}
+	}
+	return false
+}
+
 func GetStatefulSetNameForPod(client client.Client, pod *types.NamespacedName, log logr.Logger) (string, int, map[string]string) {
 	log.V(1).Info("Trying to find SS name for pod", "pod name", pod.Name, "pod ns", pod.Namespace)
 	for crName, addressDeployment := range namespacedNameToAddressName {
 		log.V(2).Info("checking address cr in stock", "cr", crName)
+		if addressDeployment.AddressResource.Spec.BrokerSelector != nil {
+			selectedCrs, err := resolveSelectedCrs(&addressDeployment.AddressResource, client)
+			if err != nil {
+				log.V(1).Info("unable to select the target crs", "cr", crName, "error", err)
+				continue
+			}
+			for _, selected := range selectedCrs {
+				builder := createStatefulSetNameBuilder(selected.Name)
+				ssNameSpace := types.NamespacedName{Name: builder.NameBuilder.Name(), Namespace: selected.Namespace}
+				if _, ok, podSerial := namer.PodBelongsToStatefulset(pod, &ssNameSpace); ok {
+					log.V(2).Info("pod belongs to a selected cr", "ssName", ssNameSpace.Name, "podSerial", podSerial)
+					return ssNameSpace.Name, podSerial, builder.Labels
+				}
+			}
+			continue
+		}
 		if crName.Namespace != pod.Namespace {
 			log.V(2).Info("this cr doesn't match pod's namespace", "cr's ns", crName.Namespace)
 			continue
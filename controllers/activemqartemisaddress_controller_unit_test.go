@@ -0,0 +1,501 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	mgmt "github.com/artemiscloud/activemq-artemis-operator/pkg/utils/artemis"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia"
+	jc "github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia_client"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func newAddressTestCr() *brokerv1beta1.ActiveMQArtemisAddress {
+	queueName := "orders"
+	routingType := "anycast"
+	return &brokerv1beta1.ActiveMQArtemisAddress{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "test", Generation: 2},
+		Spec: brokerv1beta1.ActiveMQArtemisAddressSpec{
+			AddressName: "orders",
+			QueueName:   &queueName,
+			RoutingType: &routingType,
+		},
+	}
+}
+
+func TestValidateAddress(t *testing.T) {
+	cr := newAddressTestCr()
+	assert.Nil(t, validateAddress(cr))
+
+	cr.Spec.RoutingType = nil
+	assert.Nil(t, validateAddress(cr))
+
+	routingType := "broadcast"
+	cr.Spec.RoutingType = &routingType
+	condition := validateAddress(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidAddressReason, condition.Reason)
+	}
+
+	cr = newAddressTestCr()
+	cr.Spec.AddressName = ""
+	condition = validateAddress(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, ".Spec.AddressName is required", condition.Message)
+	}
+}
+
+func TestNewAddressBrokerStatusFailed(t *testing.T) {
+	cr := newAddressTestCr()
+	jk := &jc.JkInfo{CrName: "ex-aao", Ordinal: "1"}
+	response := &jolokia.ResponseData{
+		Status: 500,
+		Error:  "javax.management.MBeanException : AMQ229019: Queue orders already exists on address orders",
+	}
+
+	status := newAddressBrokerStatus(jk, cr, response, errors.New("500"))
+
+	assert.Equal(t, "ex-aao", status.CrName)
+	assert.Equal(t, "1", status.Ordinal)
+	assert.Equal(t, brokerv1beta1.AddressBrokerFailed, status.Result)
+	assert.Equal(t, mgmt.QUEUE_ALREADY_EXISTS, status.ErrorCode)
+	assert.Equal(t, response.Error, status.Message)
+	assert.False(t, status.LastAttemptTime.IsZero())
+	assert.Nil(t, status.MessageCount)
+
+	status = newAddressBrokerStatus(jk, cr, nil, errors.New("connection refused"))
+	assert.Equal(t, mgmt.UNKNOWN_ERROR, status.ErrorCode)
+	assert.Equal(t, "connection refused", status.Message)
+}
+
+func TestAddressAppliedCondition(t *testing.T) {
+	cr := newAddressTestCr()
+	cr.Status.BrokerCount = 3
+
+	setAddressBrokerStatus(&cr.Status, brokerv1beta1.AddressBrokerStatus{CrName: "ex-aao", Ordinal: "10", Result: brokerv1beta1.AddressBrokerApplied})
+	setAddressBrokerStatus(&cr.Status, brokerv1beta1.AddressBrokerStatus{CrName: "ex-aao", Ordinal: "2", Result: brokerv1beta1.AddressBrokerFailed, ErrorCode: mgmt.UNKNOWN_ERROR})
+	setAddressBrokerStatus(&cr.Status, brokerv1beta1.AddressBrokerStatus{CrName: "a", Ordinal: "0", Result: brokerv1beta1.AddressBrokerApplied})
+	setAddressAppliedCondition(cr)
+
+	assert.Equal(t, []string{"a", "ex-aao", "ex-aao"}, []string{cr.Status.Brokers[0].CrName, cr.Status.Brokers[1].CrName, cr.Status.Brokers[2].CrName})
+	assert.Equal(t, "2", cr.Status.Brokers[1].Ordinal)
+	assert.Equal(t, int32(2), cr.Status.AppliedCount)
+	condition := meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.AddressAppliedConditionType)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, brokerv1beta1.AddressAppliedConditionFailedReason, condition.Reason)
+		assert.Equal(t, "applied to 2 of 3 brokers, failed on ex-aao-2 AMQ_UNKNOWN", condition.Message)
+		assert.Equal(t, int64(2), condition.ObservedGeneration)
+	}
+
+	// a retry on the failed broker replaces its result
+	setAddressBrokerStatus(&cr.Status, brokerv1beta1.AddressBrokerStatus{CrName: "ex-aao", Ordinal: "2", Result: brokerv1beta1.AddressBrokerApplied})
+	setAddressAppliedCondition(cr)
+
+	assert.Len(t, cr.Status.Brokers, 3)
+	assert.Equal(t, int32(3), cr.Status.AppliedCount)
+	assert.True(t, meta.IsStatusConditionTrue(cr.Status.Conditions, brokerv1beta1.AddressAppliedConditionType))
+
+	cr.Status = brokerv1beta1.ActiveMQArtemisAddressStatus{}
+	setAddressAppliedCondition(cr)
+	condition = meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.AddressAppliedConditionType)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.AddressAppliedConditionNoBrokersReason, condition.Reason)
+	}
+}
+
+func TestDesiredQueueAttributes(t *testing.T) {
+	cr := newAddressTestCr()
+	assert.Empty(t, desiredQueueAttributes(cr))
+
+	maxConsumers := int32(-1)
+	ringSize := int64(12000000)
+	filter := "color = 'red'"
+	durable := false
+	enabled := true
+	cr.Spec.QueueConfiguration = &brokerv1beta1.QueueConfigurationType{
+		MaxConsumers: &maxConsumers,
+		RingSize:     &ringSize,
+		FilterString: &filter,
+		// can't be changed on an existing queue so it is not compared
+		Durable: &durable,
+		Enabled: &enabled,
+	}
+
+	assert.Equal(t, []queueAttribute{
+		{"Filter", "color = 'red'"},
+		{"MaxConsumers", "-1"},
+		{"Enabled", "true"},
+		{"RingSize", "12000000"},
+	}, desiredQueueAttributes(cr))
+}
+
+func TestAttributeMatches(t *testing.T) {
+	assert.True(t, attributeMatches("true", "true"))
+	assert.True(t, attributeMatches("-1", "-1"))
+	// jolokia numbers come back as floats
+	assert.True(t, attributeMatches("1.2e+07", "12000000"))
+	assert.False(t, attributeMatches("false", "true"))
+	assert.False(t, attributeMatches("", "color = 'red'"))
+	assert.False(t, attributeMatches("10", "1"))
+}
+
+func TestResolveSelectedCrs(t *testing.T) {
+	payments := newTestBroker("payments", 1)
+	payments.Labels = map[string]string{"tier": "payments"}
+	orders := newTestBroker("orders", 1)
+	orders.Labels = map[string]string{"tier": "orders"}
+	// the address namespace doesn't allow targeting the payments namespace
+	otherNamespace := newTestBroker("payments", 1)
+	otherNamespace.Namespace = "payments"
+	otherNamespace.Labels = map[string]string{"tier": "payments"}
+	client := newTestClient(payments, orders, otherNamespace,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: map[string]string{"team": "pay"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{"team": "pay"}}})
+
+	cr := newAddressTestCr()
+	cr.Spec.BrokerSelector = &brokerv1beta1.BrokerSelectorType{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "payments"}},
+	}
+	selected, err := resolveSelectedCrs(cr, client)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.NamespacedName{{Namespace: "test", Name: "payments"}}, selected)
+
+	cr.Spec.BrokerSelector.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "pay"}}
+	selected, err = resolveSelectedCrs(cr, client)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.NamespacedName{{Namespace: "test", Name: "payments"}}, selected)
+
+	cr.Spec.BrokerSelector.LabelSelector = nil
+	selected, err = resolveSelectedCrs(cr, client)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.NamespacedName{{Namespace: "test", Name: "orders"}, {Namespace: "test", Name: "payments"}}, selected)
+
+	log := ctrl.Log.WithName("address_test")
+	assert.True(t, addressAppliesTo(cr, types.NamespacedName{Namespace: "test", Name: "orders"}, client, log))
+	assert.False(t, addressAppliesTo(cr, types.NamespacedName{Namespace: "payments", Name: "payments"}, client, log))
+
+	cr.Spec.BrokerSelector = nil
+	cr.Spec.ApplyToCrNames = []string{"orders"}
+	assert.True(t, addressAppliesTo(cr, types.NamespacedName{Namespace: "test", Name: "orders"}, client, log))
+	assert.False(t, addressAppliesTo(cr, types.NamespacedName{Namespace: "test", Name: "payments"}, client, log))
+
+	cr.Spec.BrokerSelector = &brokerv1beta1.BrokerSelectorType{}
+	condition := validateAddress(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidAddressReason, condition.Reason)
+	}
+}
+
+func newAddressPropertiesTestCr(name string, addressName string, queueName string, routingType string) *brokerv1beta1.ActiveMQArtemisAddress {
+	cr := newAddressTestCr()
+	cr.Name = name
+	cr.Spec.AddressName = addressName
+	cr.Spec.QueueName = &queueName
+	cr.Spec.RoutingType = &routingType
+	cr.Spec.DeliveryMode = &brokerv1beta1.AddressDeliveryModes.BrokerProperties
+	return cr
+}
+
+func TestAddressBrokerProperties(t *testing.T) {
+	orders := newAddressPropertiesTestCr("orders", "orders", "orders", "anycast")
+	maxConsumers := int32(1)
+	durable := false
+	orders.Spec.QueueConfiguration = &brokerv1beta1.QueueConfigurationType{MaxConsumers: &maxConsumers, Durable: &durable}
+	audit := newAddressPropertiesTestCr("orders-audit", "orders", "orders.audit", "multicast")
+	events := newAddressPropertiesTestCr("events", "app.events", "", "multicast")
+
+	assert.Equal(t, []string{
+		`addressConfigurations."app.events".routingTypes=MULTICAST`,
+		"addressConfigurations.orders.routingTypes=ANYCAST,MULTICAST",
+		"addressConfigurations.orders.queueConfigs.orders.address=orders",
+		"addressConfigurations.orders.queueConfigs.orders.routingType=ANYCAST",
+		"addressConfigurations.orders.queueConfigs.orders.durable=false",
+		"addressConfigurations.orders.queueConfigs.orders.maxConsumers=1",
+		`addressConfigurations.orders.queueConfigs."orders.audit".address=orders`,
+		`addressConfigurations.orders.queueConfigs."orders.audit".routingType=MULTICAST`,
+	}, addressBrokerProperties([]*brokerv1beta1.ActiveMQArtemisAddress{orders, audit, events}))
+}
+
+func TestAddressPropertiesSecret(t *testing.T) {
+	cr := newTestBroker("br", 2)
+	orders := newAddressPropertiesTestCr("orders", "orders", "orders", "anycast")
+	// created through jolokia
+	invoices := newAddressPropertiesTestCr("invoices", "invoices", "invoices", "anycast")
+	invoices.Spec.DeliveryMode = nil
+	// for another broker CR
+	payments := newAddressPropertiesTestCr("payments", "payments", "payments", "anycast")
+	payments.Spec.ApplyToCrNames = []string{"other"}
+	client := newTestClient(cr, orders, invoices, payments)
+
+	reconciler := NewActiveMQArtemisReconcilerImpl(cr, ctrl.Log.WithName("address_test"), nil)
+	reconciler.addressProperties = resolveAddressPropertiesFor(cr, client, reconciler.log)
+	assert.Equal(t, "br-address-props", reconciler.addResourceForAddressProperties(cr, *MakeNamers(cr)))
+
+	secret := reconciler.requestedResources[reflect.TypeOf(&corev1.Secret{})]["br-address-props"].(*corev1.Secret)
+	assert.Equal(t, "# generated by crd\n#\n"+
+		"addressConfigurations.orders.routingTypes=ANYCAST\n"+
+		"addressConfigurations.orders.queueConfigs.orders.address=orders\n"+
+		"addressConfigurations.orders.queueConfigs.orders.routingType=ANYCAST\n", secret.StringData[AddressPropertiesName])
+
+	secret.Data = map[string][]byte{AddressPropertiesName: []byte(secret.StringData[AddressPropertiesName])}
+	assert.True(t, addressInSecret(orders, secret))
+	assert.False(t, addressInSecret(invoices, secret))
+
+	// no address, no secret to mount
+	reconciler = NewActiveMQArtemisReconcilerImpl(cr, ctrl.Log.WithName("address_test"), nil)
+	assert.Equal(t, "", reconciler.addResourceForAddressProperties(cr, *MakeNamers(cr)))
+}
+
+func TestAddressPropertiesBrokerStatus(t *testing.T) {
+	cr := newTestBroker("br", 2)
+	orders := newAddressPropertiesTestCr("orders", "orders", "orders", "anycast")
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "br-address-props", Namespace: "test"},
+		Data:       map[string][]byte{AddressPropertiesName: []byte("addressConfigurations.orders.routingTypes=ANYCAST\n")},
+	}
+	client := newTestClient(secret)
+
+	// the queue is not in the secret yet
+	statuses := addressPropertiesBrokerStatus([]*brokerv1beta1.ActiveMQArtemisAddress{orders}, cr, client)
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, brokerv1beta1.AddressBrokerPending, statuses[0].Result)
+
+	secret.Data[AddressPropertiesName] = []byte("addressConfigurations.orders.routingTypes=ANYCAST\n" +
+		"addressConfigurations.orders.queueConfigs.orders.address=orders\n" +
+		"addressConfigurations.orders.queueConfigs.orders.routingType=ANYCAST\n")
+	assert.NoError(t, client.Update(context.TODO(), secret))
+	assert.NoError(t, client.Get(context.TODO(), types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, secret))
+	meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
+		Type:   brokerv1beta1.ConfigAppliedConditionType,
+		Status: metav1.ConditionTrue,
+		Reason: brokerv1beta1.ConfigAppliedConditionSynchedReason,
+	})
+
+	// the brokers have loaded an older version of the secret
+	cr.Status.ExternalConfigs = []brokerv1beta1.ExternalConfigStatus{{Name: secret.Name, ResourceVersion: "1"}}
+	statuses = addressPropertiesBrokerStatus([]*brokerv1beta1.ActiveMQArtemisAddress{orders}, cr, client)
+	assert.Equal(t, brokerv1beta1.AddressBrokerPending, statuses[1].Result)
+
+	cr.Status.ExternalConfigs[0].ResourceVersion = secret.ResourceVersion
+	statuses = addressPropertiesBrokerStatus([]*brokerv1beta1.ActiveMQArtemisAddress{orders}, cr, client)
+	assert.Equal(t, []string{"0", "1"}, []string{statuses[0].Ordinal, statuses[1].Ordinal})
+	assert.Equal(t, brokerv1beta1.AddressBrokerApplied, statuses[1].Result)
+
+	meta.SetStatusCondition(&cr.Status.Conditions, metav1.Condition{
+		Type:    brokerv1beta1.ConfigAppliedConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  brokerv1beta1.ConfigAppliedConditionSynchedWithErrorReason,
+		Message: "unable to apply addressConfigurations.orders.queueConfigs.orders.ringSize",
+	})
+	statuses = addressPropertiesBrokerStatus([]*brokerv1beta1.ActiveMQArtemisAddress{orders}, cr, client)
+	assert.Equal(t, brokerv1beta1.AddressBrokerFailed, statuses[0].Result)
+	assert.Equal(t, brokerv1beta1.ConfigAppliedConditionSynchedWithErrorReason, statuses[0].ErrorCode)
+
+	orders.Status.BrokerCount = 2
+	for _, status := range []string{brokerv1beta1.AddressBrokerApplied, brokerv1beta1.AddressBrokerPending} {
+		setAddressBrokerStatus(&orders.Status, brokerv1beta1.AddressBrokerStatus{CrName: "br", Ordinal: strconv.Itoa(len(orders.Status.Brokers)), Result: status})
+	}
+	setAddressAppliedCondition(orders)
+	condition := meta.FindStatusCondition(orders.Status.Conditions, brokerv1beta1.AddressAppliedConditionType)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.AddressAppliedConditionPendingReason, condition.Reason)
+		assert.Equal(t, "applied to 1 of 2 brokers, pending on 1", condition.Message)
+	}
+}
+
+func TestAddressDefinitions(t *testing.T) {
+	multicast := "multicast"
+	cr := newAddressTestCr()
+	cr.Spec.Addresses = []brokerv1beta1.AddressEntryType{
+		{AddressName: "events", RoutingType: &multicast},
+		{AddressName: "jobs", Queues: []brokerv1beta1.QueueEntryType{{QueueName: "high"}, {QueueName: "low"}}},
+	}
+	cr.Spec.AddressesConfigMap = "more-addresses"
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "more-addresses", Namespace: "test"},
+		Data: map[string]string{AddressesConfigMapKey: `
+- addressName: audit
+  queues:
+  - queueName: audit
+    queueConfiguration:
+      durable: false
+`},
+	}
+
+	definitions, condition := addressDefinitions(cr, newTestClient(configMap))
+	assert.Nil(t, condition)
+	keys := []string{}
+	for _, definition := range definitions {
+		keys = append(keys, addressEntryKey(definition))
+		assert.Nil(t, definition.Spec.Addresses)
+	}
+	assert.Equal(t, []string{"orders/orders", "events", "jobs/high", "jobs/low", "audit/audit"}, keys)
+	assert.Equal(t, multicast, *definitions[1].Spec.RoutingType)
+	assert.False(t, *definitions[4].Spec.QueueConfiguration.Durable)
+
+	_, condition = addressDefinitions(cr, newTestClient())
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionMissingResourcesReason, condition.Reason)
+	}
+
+	configMap.Data[AddressesConfigMapKey] = "- address: audit"
+	_, condition = addressDefinitions(cr, newTestClient(configMap))
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidAddressReason, condition.Reason)
+	}
+
+	cr.Spec.AddressesConfigMap = ""
+	cr.Spec.Addresses = append(cr.Spec.Addresses, brokerv1beta1.AddressEntryType{AddressName: "jobs", Queues: []brokerv1beta1.QueueEntryType{{QueueName: "low"}}})
+	_, condition = addressDefinitions(cr, newTestClient())
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "jobs/low is defined more than once", condition.Message)
+	}
+}
+
+func TestAddressEntriesCondition(t *testing.T) {
+	cr := newAddressTestCr()
+	cr.Status.BrokerCount = 1
+	setAddressBrokerStatus(&cr.Status, brokerv1beta1.AddressBrokerStatus{CrName: "ex-aao", Ordinal: "0", Result: brokerv1beta1.AddressBrokerApplied})
+	cr.Status.Entries = []brokerv1beta1.AddressEntryStatus{
+		{AddressName: "orders", QueueName: "orders", Result: brokerv1beta1.AddressBrokerApplied, AppliedCount: 1},
+		{AddressName: "events", Result: brokerv1beta1.AddressBrokerPending},
+	}
+	setAddressAppliedCondition(cr)
+
+	condition := meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.AddressAppliedConditionType)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.AddressAppliedConditionPendingReason, condition.Reason)
+		assert.Equal(t, "applied to 1 of 1 brokers, 1 of 2 entries applied", condition.Message)
+	}
+
+	cr.Status.Entries[1] = brokerv1beta1.AddressEntryStatus{AddressName: "events", Result: brokerv1beta1.AddressBrokerFailed, ErrorCode: mgmt.UNKNOWN_ERROR}
+	setAddressAppliedCondition(cr)
+
+	condition = meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.AddressAppliedConditionType)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, brokerv1beta1.AddressAppliedConditionFailedReason, condition.Reason)
+		assert.Equal(t, "applied to 1 of 1 brokers, 1 of 2 entries applied, failed events AMQ_UNKNOWN", condition.Message)
+	}
+}
+
+func TestSyncAddressFinalizer(t *testing.T) {
+	cr := newAddressTestCr()
+	assert.False(t, syncAddressFinalizer(cr))
+
+	cr.Spec.RemoveFromBrokerOnDelete = true
+	assert.True(t, syncAddressFinalizer(cr))
+	assert.Equal(t, []string{addressFinalizer}, cr.Finalizers)
+	assert.False(t, syncAddressFinalizer(cr))
+
+	cr.Spec.RemoveFromBrokerOnDelete = false
+	assert.True(t, syncAddressFinalizer(cr))
+	assert.Empty(t, cr.Finalizers)
+}
+
+func TestValidateAddressDeletePolicy(t *testing.T) {
+	cr := newAddressTestCr()
+	assert.Equal(t, brokerv1beta1.AddressDeletePolicies.Force, addressDeletePolicy(cr))
+	assert.Equal(t, defaultAddressDeleteTimeout, addressDeleteTimeout(cr))
+
+	cr.Spec.DeletePolicy = &brokerv1beta1.AddressDeletePolicyType{Policy: brokerv1beta1.AddressDeletePolicies.MoveMessagesTo}
+	condition := validateAddress(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, ".Spec.DeletePolicy.MoveMessagesTo is required with the MoveMessagesTo policy", condition.Message)
+	}
+
+	cr.Spec.DeletePolicy.MoveMessagesTo = "orders"
+	assert.NotNil(t, validateAddress(cr))
+
+	cr.Spec.DeletePolicy.MoveMessagesTo = "orders.archive"
+	assert.Nil(t, validateAddress(cr))
+
+	// nor can it be any other address of the CR
+	cr.Spec.Addresses = []brokerv1beta1.AddressEntryType{{AddressName: "orders.archive"}}
+	_, condition = addressDefinitions(cr, newTestClient())
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "orders.archive: .Spec.DeletePolicy.MoveMessagesTo can't be an address of the CR", condition.Message)
+	}
+}
+
+func TestAddressDeleteTimedOut(t *testing.T) {
+	cr := newAddressTestCr()
+	started := &brokerv1beta1.AddressDeletionStatus{StartTime: metav1.NewTime(time.Now().Add(-time.Hour))}
+	assert.False(t, addressDeleteTimedOut(cr, started))
+
+	cr.Spec.DeletePolicy = &brokerv1beta1.AddressDeletePolicyType{Policy: brokerv1beta1.AddressDeletePolicies.OnlyIfEmpty}
+	assert.False(t, addressDeleteTimedOut(cr, started))
+
+	for _, policy := range []brokerv1beta1.AddressDeletePolicy{brokerv1beta1.AddressDeletePolicies.WaitForConsumersToDrain, brokerv1beta1.AddressDeletePolicies.MoveMessagesTo} {
+		cr.Spec.DeletePolicy = &brokerv1beta1.AddressDeletePolicyType{Policy: policy, MoveMessagesTo: "orders.archive"}
+		assert.True(t, addressDeleteTimedOut(cr, started))
+		assert.False(t, addressDeleteTimedOut(cr, &brokerv1beta1.AddressDeletionStatus{StartTime: metav1.Now()}))
+	}
+}
+
+func TestFinalizeAddressWithoutBrokers(t *testing.T) {
+	cr := newAddressTestCr()
+	cr.Spec.RemoveFromBrokerOnDelete = true
+	cr.Spec.DeletePolicy = &brokerv1beta1.AddressDeletePolicyType{Policy: brokerv1beta1.AddressDeletePolicies.OnlyIfEmpty}
+	cr.Finalizers = []string{addressFinalizer}
+	now := metav1.Now()
+	cr.DeletionTimestamp = &now
+	client := newTestClient(cr)
+	r := NewActiveMQArtemisAddressReconciler(client, nil, nil, ctrl.Log)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}}
+
+	// no broker holds the queue, the CR is released
+	result, err := r.finalizeAddress(cr, request, ctrl.Log)
+
+	assert.Nil(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.Empty(t, cr.Finalizers)
+	assert.Nil(t, cr.Status.Deletion)
+	assert.True(t, k8serrors.IsNotFound(client.Get(context.TODO(), request.NamespacedName, &brokerv1beta1.ActiveMQArtemisAddress{})))
+}
+
+func TestFinalizeAddressKeepsFinalizerWithoutTargetBrokers(t *testing.T) {
+	cr := newAddressTestCr()
+	cr.Spec.RemoveFromBrokerOnDelete = true
+	cr.Spec.BrokerSelector = &brokerv1beta1.BrokerSelectorType{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "pay"}},
+	}
+	cr.Finalizers = []string{addressFinalizer}
+	now := metav1.Now()
+	cr.DeletionTimestamp = &now
+	// the operator is not allowed to list the namespaces
+	client := interceptor.NewClient(newTestClient(cr), interceptor.Funcs{
+		List: func(ctx context.Context, client rtclient.WithWatch, list rtclient.ObjectList, opts ...rtclient.ListOption) error {
+			if _, namespaces := list.(*corev1.NamespaceList); namespaces {
+				return k8serrors.NewForbidden(corev1.Resource("namespaces"), "", nil)
+			}
+			return client.List(ctx, list, opts...)
+		},
+	})
+	r := NewActiveMQArtemisAddressReconciler(client, nil, nil, ctrl.Log)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}}
+
+	_, err := r.finalizeAddress(cr, request, ctrl.Log)
+
+	assert.True(t, k8serrors.IsForbidden(err))
+	assert.Equal(t, []string{addressFinalizer}, cr.Finalizers)
+	if assert.NotNil(t, cr.Status.Deletion) {
+		assert.Equal(t, brokerv1beta1.AddressDeletionFailed, cr.Status.Deletion.State)
+	}
+	assert.NoError(t, client.Get(context.TODO(), request.NamespacedName, &brokerv1beta1.ActiveMQArtemisAddress{}))
+}
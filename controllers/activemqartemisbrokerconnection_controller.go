@@ -0,0 +1,473 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/resources"
+	ss "github.com/artemiscloud/activemq-artemis-operator/pkg/resources/statefulsets"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/certutil"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/common"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia_client"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// the queue a mirror stores messages in till the target has them
+	mirrorQueuePrefix = "$ACTIVEMQ_ARTEMIS_MIRROR_"
+
+	brokerConnectionUserKey     = "user"
+	brokerConnectionPasswordKey = "password"
+)
+
+// ActiveMQArtemisBrokerConnectionReconciler reconciles a ActiveMQArtemisBrokerConnection object
+type ActiveMQArtemisBrokerConnectionReconciler struct {
+	rtclient.Client
+	Scheme           *runtime.Scheme
+	BrokerReconciler *ActiveMQArtemisReconciler
+	log              logr.Logger
+	// source CR and properties checksum last applied for each connection, the
+	// source brokers are reconciled when either changes or the connection is deleted
+	sources   map[types.NamespacedName]string
+	checksums map[types.NamespacedName]string
+}
+
+func NewActiveMQArtemisBrokerConnectionReconciler(client rtclient.Client, scheme *runtime.Scheme, brokerReconciler *ActiveMQArtemisReconciler, logger logr.Logger) *ActiveMQArtemisBrokerConnectionReconciler {
+	return &ActiveMQArtemisBrokerConnectionReconciler{
+		Client:           client,
+		Scheme:           scheme,
+		BrokerReconciler: brokerReconciler,
+		log:              logger,
+		sources:          make(map[types.NamespacedName]string),
+		checksums:        make(map[types.NamespacedName]string),
+	}
+}
+
+//+kubebuilder:rbac:groups=broker.amq.io,namespace=activemq-artemis-operator,resources=activemqartemisbrokerconnections,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=broker.amq.io,namespace=activemq-artemis-operator,resources=activemqartemisbrokerconnections/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=broker.amq.io,namespace=activemq-artemis-operator,resources=activemqartemisbrokerconnections/finalizers,verbs=update
+
+// Reconcile checks the source and target of the connection, has the source
+// brokers reconciled when the properties they need for it change and reports
+// the state of the connection of each source broker
+func (r *ActiveMQArtemisBrokerConnectionReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+
+	reqLogger := r.log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name, "Reconciling", "ActiveMQArtemisBrokerConnection")
+
+	instance := &brokerv1beta1.ActiveMQArtemisBrokerConnection{}
+
+	if err := r.Client.Get(context.TODO(), request.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			// the source brokers drop the connection properties on reconcile
+			if source, found := r.sources[request.NamespacedName]; found {
+				delete(r.sources, request.NamespacedName)
+				delete(r.checksums, request.NamespacedName)
+				return ctrl.Result{}, r.BrokerReconciler.ReconcileBroker(types.NamespacedName{Name: source, Namespace: request.Namespace})
+			}
+			return ctrl.Result{}, nil
+		}
+		reqLogger.Error(err, "Reconcile errored thats not IsNotFound, requeuing request", "Request Namespace", request.Namespace, "Request Name", request.Name)
+		return ctrl.Result{}, err
+	}
+
+	sourceKey := types.NamespacedName{Name: instance.Spec.SourceCrName, Namespace: instance.Namespace}
+	if previous, found := r.sources[request.NamespacedName]; found && previous != sourceKey.Name {
+		delete(r.checksums, request.NamespacedName)
+		if err := r.BrokerReconciler.ReconcileBroker(types.NamespacedName{Name: previous, Namespace: request.Namespace}); err != nil {
+			reqLogger.Error(err, "failed to reconcile previous source", "source", previous)
+		}
+	}
+	r.sources[request.NamespacedName] = sourceKey.Name
+
+	var connection *brokerConnection
+	validCondition := metav1.Condition{
+		Type:   brokerv1beta1.ValidConditionType,
+		Status: metav1.ConditionTrue,
+		Reason: brokerv1beta1.ValidConditionSuccessReason,
+	}
+
+	source := &brokerv1beta1.ActiveMQArtemis{}
+	if err := r.Client.Get(context.TODO(), sourceKey, source); err != nil {
+		validCondition.Status = metav1.ConditionFalse
+		validCondition.Reason = brokerv1beta1.ValidConditionSourceNotFoundReason
+		validCondition.Message = fmt.Sprintf("unable to find the source ActiveMQArtemis %s, %v", sourceKey.Name, err)
+	} else {
+		var condition *metav1.Condition
+		if connection, condition = resolveBrokerConnection(instance, source, r.Client); condition != nil {
+			validCondition = *condition
+		}
+	}
+	validCondition.ObservedGeneration = instance.Generation
+	meta.SetStatusCondition(&instance.Status.Conditions, validCondition)
+
+	if connection != nil {
+		if checksum := hex.EncodeToString(alder32Of(connection.props)); r.checksums[request.NamespacedName] != checksum {
+			reqLogger.V(1).Info("broker connection changed, reconciling source", "source", sourceKey)
+			if err := r.BrokerReconciler.ReconcileBroker(sourceKey); err != nil {
+				reqLogger.Error(err, "failed to reconcile source", "source", sourceKey)
+			} else {
+				r.checksums[request.NamespacedName] = checksum
+			}
+		}
+		processBrokerConnectionStatus(instance, source, connection, r.Client)
+	} else {
+		instance.Status.Brokers = nil
+		meta.RemoveStatusCondition(&instance.Status.Conditions, brokerv1beta1.ConnectedConditionType)
+	}
+
+	if err := r.updateStatus(instance); err != nil {
+		reqLogger.Error(err, "unable to update status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: common.GetReconcileResyncPeriod()}, nil
+}
+
+func (r *ActiveMQArtemisBrokerConnectionReconciler) updateStatus(desired *brokerv1beta1.ActiveMQArtemisBrokerConnection) error {
+
+	common.SetReadyCondition(&desired.Status.Conditions)
+
+	current := &brokerv1beta1.ActiveMQArtemisBrokerConnection{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, current); err != nil {
+		return err
+	}
+
+	if len(desired.Status.Conditions) != len(current.Status.Conditions) ||
+		conditionsModified(desired.Status.Conditions, current.Status.Conditions) ||
+		!reflect.DeepEqual(desired.Status.Brokers, current.Status.Brokers) {
+		return resources.UpdateStatus(r.Client, desired)
+	}
+	return nil
+}
+
+// brokerConnection is what the source brokers are given for an ActiveMQArtemisBrokerConnection
+type brokerConnection struct {
+	name string
+	// host:port each source ordinal connects to, an ordinal without one has no target
+	targets map[int32]string
+	// ordinal prefixed broker properties
+	props       []string
+	trustSecret string
+}
+
+// resolveBrokerConnection works out the target of each source broker and the
+// properties that configure the connection. A condition is returned when the
+// connection can't be configured
+func resolveBrokerConnection(connection *brokerv1beta1.ActiveMQArtemisBrokerConnection, source *brokerv1beta1.ActiveMQArtemis, client rtclient.Client) (*brokerConnection, *metav1.Condition) {
+
+	invalid := func(reason string, format string, args ...interface{}) (*brokerConnection, *metav1.Condition) {
+		return nil, &metav1.Condition{
+			Type:    brokerv1beta1.ValidConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+
+	// the name is a key segment of the broker properties
+	if strings.Contains(connection.Name, ".") {
+		return invalid(brokerv1beta1.ValidConditionInvalidTargetReason, "the name %s is used as a broker property key and can't contain '.'", connection.Name)
+	}
+
+	target := connection.Spec.Target
+	if (target.CrName == "") == (target.Endpoint == "") {
+		return invalid(brokerv1beta1.ValidConditionInvalidTargetReason, "exactly one of .Spec.Target.CrName and .Spec.Target.Endpoint is required")
+	}
+
+	resolved := &brokerConnection{
+		name:        connection.Name,
+		targets:     map[int32]string{},
+		trustSecret: target.TrustSecret,
+	}
+	sslEnabled := target.SSLEnabled
+	var user, password string
+
+	if target.CrName != "" {
+		targetCr := &brokerv1beta1.ActiveMQArtemis{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: target.CrName, Namespace: connection.Namespace}, targetCr); err != nil {
+			return invalid(brokerv1beta1.ValidConditionMissingResourcesReason, "unable to find the target ActiveMQArtemis %s, %v", target.CrName, err)
+		}
+		acceptor, port := findAcceptor(targetCr, target.AcceptorName)
+		if acceptor == nil {
+			return invalid(brokerv1beta1.ValidConditionInvalidTargetReason, "the target ActiveMQArtemis %s has no acceptor named %q", target.CrName, target.AcceptorName)
+		}
+		if protocols := strings.ToUpper(acceptor.Protocols); protocols != "" && protocols != "ALL" && !strings.Contains(protocols, "AMQP") {
+			return invalid(brokerv1beta1.ValidConditionInvalidTargetReason, "the acceptor %s of the target ActiveMQArtemis %s does not accept AMQP", acceptor.Name, target.CrName)
+		}
+		sslEnabled = acceptor.SSLEnabled
+
+		targetNamers := MakeNamers(targetCr)
+		for ordinal := int32(0); ordinal < common.GetDeploymentSize(targetCr); ordinal++ {
+			resolved.targets[ordinal] = net.JoinHostPort(common.OrdinalFQDN(targetNamers, targetCr.Namespace, ordinal), strconv.Itoa(int(port)))
+		}
+
+		if target.CredentialsSecret == "" {
+			var err error
+			secretName := targetNamers.SecretsCredentialsNameBuilder.Name()
+			if user, password, err = readCredentialsSecret(client, connection.Namespace, secretName, "AMQ_USER", "AMQ_PASSWORD"); err != nil {
+				return invalid(brokerv1beta1.ValidConditionMissingResourcesReason, "unable to find the credentials secret %s of the target ActiveMQArtemis %s, %v", secretName, target.CrName, err)
+			}
+		}
+	} else {
+		if _, _, err := net.SplitHostPort(target.Endpoint); err != nil {
+			return invalid(brokerv1beta1.ValidConditionInvalidTargetReason, ".Spec.Target.Endpoint must be host:port, %v", err)
+		}
+		for ordinal := int32(0); ordinal < common.GetDeploymentSize(source); ordinal++ {
+			resolved.targets[ordinal] = target.Endpoint
+		}
+	}
+
+	if target.CredentialsSecret != "" {
+		var err error
+		if user, password, err = readCredentialsSecret(client, connection.Namespace, target.CredentialsSecret, brokerConnectionUserKey, brokerConnectionPasswordKey); err != nil {
+			return invalid(brokerv1beta1.ValidConditionMissingResourcesReason, "unable to find the credentials secret %s, %v", target.CredentialsSecret, err)
+		}
+	}
+
+	uriParameters := ""
+	if sslEnabled {
+		if target.TrustSecret == "" {
+			return invalid(brokerv1beta1.ValidConditionInvalidTargetReason, ".Spec.Target.TrustSecret is required to connect with TLS")
+		}
+		trustSecret := &corev1.Secret{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: target.TrustSecret, Namespace: connection.Namespace}, trustSecret); err != nil {
+			return invalid(brokerv1beta1.ValidConditionMissingResourcesReason, "unable to find the trust secret %s, %v", target.TrustSecret, err)
+		}
+		trustArgs, err := certutil.GetTrustArgumentsFromSecret(trustSecret, target.TrustStoreType)
+		if err != nil {
+			return invalid(brokerv1beta1.ValidConditionInvalidCertSecretReason, "the trust secret %s can't be used, %v", target.TrustSecret, err)
+		}
+		uriParameters = "?" + trustArgs.ToFlags()
+	} else {
+		resolved.trustSecret = ""
+	}
+
+	key := "AMQPConnections." + connection.Name + "."
+	mirror := connection.Spec.Mirror
+	for ordinal := int32(0); ordinal < common.GetDeploymentSize(source); ordinal++ {
+		hostPort, found := resolved.targets[ordinal]
+		if !found {
+			continue
+		}
+		prefix := OrdinalPrefix + strconv.Itoa(int(ordinal)) + OrdinalPrefixSep + key
+		resolved.props = append(resolved.props, prefix+"uri=tcp://"+hostPort+uriParameters)
+		if user != "" {
+			resolved.props = append(resolved.props, prefix+"user="+user, prefix+"password="+password)
+		}
+		if connection.Spec.RetryInterval != nil {
+			resolved.props = append(resolved.props, prefix+"retryInterval="+strconv.Itoa(int(*connection.Spec.RetryInterval)))
+		}
+		if connection.Spec.ReconnectAttempts != nil {
+			resolved.props = append(resolved.props, prefix+"reconnectAttempts="+strconv.Itoa(int(*connection.Spec.ReconnectAttempts)))
+		}
+		resolved.props = append(resolved.props, prefix+"connectionElements.mirror.type=MIRROR")
+		for property, value := range map[string]*bool{
+			"messageAcknowledgements": mirror.MessageAcknowledgements,
+			"queueCreation":           mirror.QueueCreation,
+			"queueRemoval":            mirror.QueueRemoval,
+			"sync":                    mirror.Sync,
+		} {
+			if value != nil {
+				resolved.props = append(resolved.props, prefix+"connectionElements.mirror."+property+"="+strconv.FormatBool(*value))
+			}
+		}
+		if mirror.AddressFilter != "" {
+			resolved.props = append(resolved.props, prefix+"connectionElements.mirror.addressFilter="+mirror.AddressFilter)
+		}
+	}
+	// the map iteration above has no order, keep the checksum of the properties stable
+	sort.Strings(resolved.props)
+
+	return resolved, nil
+}
+
+// readCredentialsSecret returns the user and password held by the secret under the given keys
+func readCredentialsSecret(client rtclient.Client, namespace string, name string, userKey string, passwordKey string) (string, string, error) {
+	secret := &corev1.Secret{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, secret); err != nil {
+		return "", "", err
+	}
+	return string(secret.Data[userKey]), string(secret.Data[passwordKey]), nil
+}
+
+// findAcceptor returns the named acceptor with the port it listens on, an
+// acceptor without a port is given one the way generateAcceptorsString does
+func findAcceptor(customResource *brokerv1beta1.ActiveMQArtemis, name string) (*brokerv1beta1.AcceptorType, int32) {
+	var defaultPort int32 = 61626
+	for i, acceptor := range customResource.Spec.Acceptors {
+		port := acceptor.Port
+		if port == 0 {
+			port = defaultPort
+			defaultPort += 10
+		}
+		if acceptor.Name == name {
+			return &customResource.Spec.Acceptors[i], port
+		}
+	}
+	return nil, 0
+}
+
+// resolveBrokerConnectionsFor gives the broker connections that have the CR as
+// their source, ordered by name. Connections that can't be resolved are left
+// out, the broker connection reconciler reports why
+func resolveBrokerConnectionsFor(customResource *brokerv1beta1.ActiveMQArtemis, client rtclient.Client, log logr.Logger) []*brokerConnection {
+	resolved := []*brokerConnection{}
+
+	list := &brokerv1beta1.ActiveMQArtemisBrokerConnectionList{}
+	if err := client.List(context.TODO(), list, &rtclient.ListOptions{Namespace: customResource.Namespace}); err != nil {
+		log.Error(err, "unable to list broker connections")
+		return resolved
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Name < list.Items[j].Name
+	})
+
+	for index := range list.Items {
+		if list.Items[index].Spec.SourceCrName != customResource.Name {
+			continue
+		}
+		connection, condition := resolveBrokerConnection(&list.Items[index], customResource, client)
+		if condition != nil {
+			log.V(1).Info("skipping broker connection", "name", list.Items[index].Name, "reason", condition.Message)
+			continue
+		}
+		resolved = append(resolved, connection)
+	}
+	return resolved
+}
+
+// brokerConnectionProperties collects the properties of the resolved connections
+func brokerConnectionProperties(connections []*brokerConnection) []string {
+	props := []string{}
+	for _, connection := range connections {
+		props = append(props, connection.props...)
+	}
+	return props
+}
+
+type brokerConnectionInfo struct {
+	Name    string `json:"name"`
+	Started bool   `json:"started"`
+}
+
+// processBrokerConnectionStatus asks each source broker through jolokia for
+// the state of the connection and the messages still to be mirrored
+func processBrokerConnectionStatus(instance *brokerv1beta1.ActiveMQArtemisBrokerConnection, source *brokerv1beta1.ActiveMQArtemis, connection *brokerConnection, client rtclient.Client) {
+	reqLogger := ctrl.Log.WithValues("ActiveMQArtemisBrokerConnection Name", instance.Name)
+
+	resource := types.NamespacedName{Name: source.Name, Namespace: source.Namespace}
+	ssInfos := ss.GetDeployedStatefulSetNames(client, source.Namespace, []types.NamespacedName{resource})
+
+	observed := map[string]brokerv1beta1.BrokerConnectionStatus{}
+	for _, jk := range jolokia_client.GetBrokers(resource, ssInfos, client) {
+		status := brokerv1beta1.BrokerConnectionStatus{Ordinal: jk.Ordinal, State: brokerv1beta1.BrokerConnectionUnknown}
+		data, err := jk.Artemis.ListBrokerConnections()
+		if err != nil || data == nil {
+			reqLogger.V(2).Info("unable to list broker connections", "IP", jk.IP, "Ordinal", jk.Ordinal, "error", err)
+			observed[jk.Ordinal] = status
+			continue
+		}
+		infos := []brokerConnectionInfo{}
+		if err := json.Unmarshal([]byte(data.Value), &infos); err != nil {
+			reqLogger.V(2).Info("unable to parse broker connections", "Ordinal", jk.Ordinal, "value", data.Value, "error", err)
+			observed[jk.Ordinal] = status
+			continue
+		}
+		status.State = brokerv1beta1.BrokerConnectionNotConfigured
+		for _, info := range infos {
+			if info.Name != connection.name {
+				continue
+			}
+			status.State = brokerv1beta1.BrokerConnectionStopped
+			if info.Started {
+				status.State = brokerv1beta1.BrokerConnectionStarted
+			}
+			mirrorQueue := mirrorQueuePrefix + connection.name
+			if count, err := jk.Artemis.GetQueueMessageCount(mirrorQueue, mirrorQueue, "anycast"); err == nil {
+				status.PendingMessages = &count
+			}
+		}
+		observed[jk.Ordinal] = status
+	}
+
+	brokers := []brokerv1beta1.BrokerConnectionStatus{}
+	notStarted := []string{}
+	unknown := false
+	for ordinal := int32(0); ordinal < common.GetDeploymentSize(source); ordinal++ {
+		ordinalString := strconv.Itoa(int(ordinal))
+		status, found := observed[ordinalString]
+		if !found {
+			status = brokerv1beta1.BrokerConnectionStatus{Ordinal: ordinalString, State: brokerv1beta1.BrokerConnectionUnknown}
+		}
+		if target, hasTarget := connection.targets[ordinal]; hasTarget {
+			status.Target = target
+		} else {
+			status.State = brokerv1beta1.BrokerConnectionNoTarget
+			status.PendingMessages = nil
+		}
+		switch status.State {
+		case brokerv1beta1.BrokerConnectionStarted:
+		case brokerv1beta1.BrokerConnectionUnknown:
+			unknown = true
+		default:
+			notStarted = append(notStarted, ordinalString+"="+status.State)
+		}
+		brokers = append(brokers, status)
+	}
+	instance.Status.Brokers = brokers
+
+	condition := metav1.Condition{
+		Type:   brokerv1beta1.ConnectedConditionType,
+		Status: metav1.ConditionTrue,
+		Reason: brokerv1beta1.ConnectedConditionStartedReason,
+	}
+	if len(notStarted) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = brokerv1beta1.ConnectedConditionNotStartedReason
+		condition.Message = strings.Join(notStarted, ", ")
+	} else if unknown {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = brokerv1beta1.ConnectedConditionUnknownReason
+	}
+	condition.ObservedGeneration = instance.Generation
+	meta.SetStatusCondition(&instance.Status.Conditions, condition)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ActiveMQArtemisBrokerConnectionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&brokerv1beta1.ActiveMQArtemisBrokerConnection{}).
+		Complete(r)
+}
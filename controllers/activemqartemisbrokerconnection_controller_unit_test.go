@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"testing"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/certutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func newBrokerConnection(target brokerv1beta1.BrokerConnectionTargetType) *brokerv1beta1.ActiveMQArtemisBrokerConnection {
+	return &brokerv1beta1.ActiveMQArtemisBrokerConnection{
+		ObjectMeta: metav1.ObjectMeta{Name: "dr", Namespace: "test"},
+		Spec: brokerv1beta1.ActiveMQArtemisBrokerConnectionSpec{
+			SourceCrName: "br",
+			Target:       target,
+		},
+	}
+}
+
+func TestBrokerConnectionToTargetCr(t *testing.T) {
+	source := newTestBroker("br", 3)
+	target := newTestBroker("dr", 2, brokerv1beta1.AcceptorType{Name: "core"}, brokerv1beta1.AcceptorType{Name: "amqp", Protocols: "amqp"})
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dr-credentials-secret", Namespace: "test"},
+		Data:       map[string][]byte{"AMQ_USER": []byte("admin"), "AMQ_PASSWORD": []byte("secret")},
+	}
+	connection := newBrokerConnection(brokerv1beta1.BrokerConnectionTargetType{CrName: "dr", AcceptorName: "amqp"})
+	connection.Spec.Mirror.QueueRemoval = &[]bool{false}[0]
+	connection.Spec.Mirror.AddressFilter = "orders,!orders.tmp"
+
+	resolved, condition := resolveBrokerConnection(connection, source, newTestClient(target, credentials))
+	assert.Nil(t, condition)
+
+	// the acceptor without a port is given the second default port
+	assert.Equal(t, "dr-ss-1.dr-hdls-svc.test.svc.cluster.local:61636", resolved.targets[1])
+	assert.NotContains(t, resolved.targets, int32(2))
+	assert.Contains(t, resolved.props, "broker-0.AMQPConnections.dr.uri=tcp://dr-ss-0.dr-hdls-svc.test.svc.cluster.local:61636")
+	assert.Contains(t, resolved.props, "broker-1.AMQPConnections.dr.user=admin")
+	assert.Contains(t, resolved.props, "broker-1.AMQPConnections.dr.password=secret")
+	assert.Contains(t, resolved.props, "broker-1.AMQPConnections.dr.connectionElements.mirror.type=MIRROR")
+	assert.Contains(t, resolved.props, "broker-1.AMQPConnections.dr.connectionElements.mirror.queueRemoval=false")
+	assert.Contains(t, resolved.props, "broker-1.AMQPConnections.dr.connectionElements.mirror.addressFilter=orders,!orders.tmp")
+	// ordinal 2 has no broker to mirror to
+	for _, prop := range resolved.props {
+		assert.NotContains(t, prop, "broker-2.")
+	}
+	assert.Empty(t, resolved.trustSecret)
+}
+
+func TestBrokerConnectionToEndpointWithTLS(t *testing.T) {
+	source := newTestBroker("br", 2)
+	bundle := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dr-ca", Namespace: "test", Annotations: map[string]string{certutil.Bundle_annotation_key: "hash"}},
+		Data:       map[string][]byte{"ca.pem": []byte("pem")},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dr-user", Namespace: "test"},
+		Data:       map[string][]byte{"user": []byte("mirror"), "password": []byte("pass")},
+	}
+	connection := newBrokerConnection(brokerv1beta1.BrokerConnectionTargetType{
+		Endpoint:          "dr.example.com:5671",
+		SSLEnabled:        true,
+		TrustSecret:       "dr-ca",
+		CredentialsSecret: "dr-user",
+	})
+	connection.Spec.RetryInterval = &[]int32{1000}[0]
+
+	resolved, condition := resolveBrokerConnection(connection, source, newTestClient(bundle, credentials))
+	assert.Nil(t, condition)
+
+	for _, ordinal := range []string{"0", "1"} {
+		assert.Contains(t, resolved.props, "broker-"+ordinal+".AMQPConnections.dr.uri=tcp://dr.example.com:5671?sslEnabled=true;trustStorePath=/etc/dr-ca-volume/ca.pem;trustStoreType=PEM")
+		assert.Contains(t, resolved.props, "broker-"+ordinal+".AMQPConnections.dr.user=mirror")
+		assert.Contains(t, resolved.props, "broker-"+ordinal+".AMQPConnections.dr.retryInterval=1000")
+	}
+
+	// the source brokers get the properties and the trust secret mounted
+	namer := MakeNamers(source)
+	reconciler := NewActiveMQArtemisReconcilerImpl(source, ctrl.Log.WithName("broker_connection_test"), nil)
+	reconciler.brokerConnections = []*brokerConnection{resolved}
+
+	_, _, data, err := reconciler.addResourceForBrokerProperties(source, *namer)
+	assert.NoError(t, err)
+	assert.Contains(t, data["broker-1."+BrokerPropertiesName], "AMQPConnections.dr.password=pass\n")
+
+	podVolumes, err := reconciler.MakeVolumes(source, *namer)
+	assert.NoError(t, err)
+	found := false
+	for _, volume := range podVolumes {
+		if volume.Name == "dr-ca-volume" {
+			found = true
+			assert.Equal(t, "dr-ca", volume.Secret.SecretName)
+		}
+	}
+	assert.True(t, found)
+
+	mounts, err := reconciler.MakeVolumeMounts(source, *namer)
+	assert.NoError(t, err)
+	assert.Contains(t, mounts, corev1.VolumeMount{Name: "dr-ca-volume", MountPath: "/etc/dr-ca-volume", ReadOnly: true})
+}
+
+func TestResolveBrokerConnectionsForSource(t *testing.T) {
+	source := newTestBroker("br", 1)
+	mine := newBrokerConnection(brokerv1beta1.BrokerConnectionTargetType{Endpoint: "dr:5672"})
+	other := newBrokerConnection(brokerv1beta1.BrokerConnectionTargetType{Endpoint: "dr:5672"})
+	other.Name = "other"
+	other.Spec.SourceCrName = "another"
+	invalid := newBrokerConnection(brokerv1beta1.BrokerConnectionTargetType{})
+	invalid.Name = "invalid"
+
+	resolved := resolveBrokerConnectionsFor(source, newTestClient(mine, other, invalid), ctrl.Log)
+	if assert.Len(t, resolved, 1) {
+		assert.Equal(t, "dr", resolved[0].name)
+	}
+}
+
+func TestBrokerConnectionInvalid(t *testing.T) {
+	source := newTestBroker("br", 1)
+	target := newTestBroker("dr", 1,
+		brokerv1beta1.AcceptorType{Name: "core", Port: 61616, Protocols: "core"},
+		brokerv1beta1.AcceptorType{Name: "tls", Port: 5671, SSLEnabled: true})
+	client := newTestClient(target)
+
+	for name, test := range map[string]struct {
+		target brokerv1beta1.BrokerConnectionTargetType
+		reason string
+	}{
+		"no target":             {brokerv1beta1.BrokerConnectionTargetType{}, brokerv1beta1.ValidConditionInvalidTargetReason},
+		"cr and endpoint":       {brokerv1beta1.BrokerConnectionTargetType{CrName: "dr", Endpoint: "dr:5672"}, brokerv1beta1.ValidConditionInvalidTargetReason},
+		"bad endpoint":          {brokerv1beta1.BrokerConnectionTargetType{Endpoint: "dr"}, brokerv1beta1.ValidConditionInvalidTargetReason},
+		"missing cr":            {brokerv1beta1.BrokerConnectionTargetType{CrName: "missing", AcceptorName: "amqp"}, brokerv1beta1.ValidConditionMissingResourcesReason},
+		"missing acceptor":      {brokerv1beta1.BrokerConnectionTargetType{CrName: "dr", AcceptorName: "amqp"}, brokerv1beta1.ValidConditionInvalidTargetReason},
+		"acceptor without amqp": {brokerv1beta1.BrokerConnectionTargetType{CrName: "dr", AcceptorName: "core", CredentialsSecret: "creds"}, brokerv1beta1.ValidConditionInvalidTargetReason},
+		"tls without trust":     {brokerv1beta1.BrokerConnectionTargetType{Endpoint: "dr:5671", SSLEnabled: true}, brokerv1beta1.ValidConditionInvalidTargetReason},
+		"missing trust secret":  {brokerv1beta1.BrokerConnectionTargetType{Endpoint: "dr:5671", SSLEnabled: true, TrustSecret: "ca"}, brokerv1beta1.ValidConditionMissingResourcesReason},
+		"missing credentials":   {brokerv1beta1.BrokerConnectionTargetType{Endpoint: "dr:5672", CredentialsSecret: "creds"}, brokerv1beta1.ValidConditionMissingResourcesReason},
+	} {
+		resolved, condition := resolveBrokerConnection(newBrokerConnection(test.target), source, client)
+		assert.Nil(t, resolved, name)
+		if assert.NotNil(t, condition, name) {
+			assert.Equal(t, metav1.ConditionFalse, condition.Status, name)
+			assert.Equal(t, test.reason, condition.Reason, name)
+		}
+	}
+
+	dotted := newBrokerConnection(brokerv1beta1.BrokerConnectionTargetType{Endpoint: "dr:5672"})
+	dotted.Name = "to.dr"
+	_, condition := resolveBrokerConnection(dotted, source, client)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidTargetReason, condition.Reason)
+	}
+}
@@ -0,0 +1,684 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/resources"
+	ss "github.com/artemiscloud/activemq-artemis-operator/pkg/resources/statefulsets"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/certutil"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/common"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia_client"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/namer"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const federationConnectorFactory = "org.apache.activemq.artemis.core.remoting.impl.netty.NettyConnectorFactory"
+
+// ActiveMQArtemisFederationReconciler reconciles a ActiveMQArtemisFederation object
+type ActiveMQArtemisFederationReconciler struct {
+	rtclient.Client
+	Scheme           *runtime.Scheme
+	BrokerReconciler *ActiveMQArtemisReconciler
+	log              logr.Logger
+	// federated CRs and properties checksum last applied for each federation, the
+	// federated brokers are reconciled when either changes or the federation is deleted
+	federated map[types.NamespacedName][]string
+	checksums map[types.NamespacedName]string
+}
+
+func NewActiveMQArtemisFederationReconciler(client rtclient.Client, scheme *runtime.Scheme, brokerReconciler *ActiveMQArtemisReconciler, logger logr.Logger) *ActiveMQArtemisFederationReconciler {
+	return &ActiveMQArtemisFederationReconciler{
+		Client:           client,
+		Scheme:           scheme,
+		BrokerReconciler: brokerReconciler,
+		log:              logger,
+		federated:        make(map[types.NamespacedName][]string),
+		checksums:        make(map[types.NamespacedName]string),
+	}
+}
+
+//+kubebuilder:rbac:groups=broker.amq.io,namespace=activemq-artemis-operator,resources=activemqartemisfederations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=broker.amq.io,namespace=activemq-artemis-operator,resources=activemqartemisfederations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=broker.amq.io,namespace=activemq-artemis-operator,resources=activemqartemisfederations/finalizers,verbs=update
+
+// Reconcile works out the federation for each broker CR it applies to, has
+// those brokers reconciled when the properties they need for it change and
+// reports whether each link of the federation is up
+func (r *ActiveMQArtemisFederationReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+
+	reqLogger := r.log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name, "Reconciling", "ActiveMQArtemisFederation")
+
+	instance := &brokerv1beta1.ActiveMQArtemisFederation{}
+
+	if err := r.Client.Get(context.TODO(), request.NamespacedName, instance); err != nil {
+		if errors.IsNotFound(err) {
+			// the federated brokers drop the federation properties on reconcile
+			if federated, found := r.federated[request.NamespacedName]; found {
+				delete(r.federated, request.NamespacedName)
+				delete(r.checksums, request.NamespacedName)
+				return ctrl.Result{}, r.reconcileBrokers(request.Namespace, federated, reqLogger)
+			}
+			return ctrl.Result{}, nil
+		}
+		reqLogger.Error(err, "Reconcile errored thats not IsNotFound, requeuing request", "Request Namespace", request.Namespace, "Request Name", request.Name)
+		return ctrl.Result{}, err
+	}
+
+	brokers := &brokerv1beta1.ActiveMQArtemisList{}
+	if err := r.Client.List(context.TODO(), brokers, &rtclient.ListOptions{Namespace: request.Namespace}); err != nil {
+		reqLogger.Error(err, "unable to list broker crs")
+		return ctrl.Result{}, err
+	}
+	sort.Slice(brokers.Items, func(i, j int) bool {
+		return brokers.Items[i].Name < brokers.Items[j].Name
+	})
+
+	validCondition := metav1.Condition{
+		Type:   brokerv1beta1.ValidConditionType,
+		Status: metav1.ConditionTrue,
+		Reason: brokerv1beta1.ValidConditionSuccessReason,
+	}
+
+	federatedCrs := []*brokerv1beta1.ActiveMQArtemis{}
+	federatedNames := []string{}
+	props := []string{}
+	for index := range brokers.Items {
+		customResource := &brokers.Items[index]
+		if !federationAppliesTo(instance, customResource.Name) {
+			continue
+		}
+		federatedCrs = append(federatedCrs, customResource)
+		federatedNames = append(federatedNames, customResource.Name)
+		if validCondition.Status != metav1.ConditionTrue {
+			continue
+		}
+		resolved, condition := resolveFederation(instance, customResource, r.Client)
+		if condition != nil {
+			validCondition = *condition
+			props = nil
+			continue
+		}
+		props = append(props, customResource.Name)
+		props = append(props, resolved.props...)
+	}
+	validCondition.ObservedGeneration = instance.Generation
+	meta.SetStatusCondition(&instance.Status.Conditions, validCondition)
+
+	// the brokers no longer federated drop the properties, the others pick up any change
+	toReconcile := []string{}
+	previous := r.federated[request.NamespacedName]
+	checksum := hex.EncodeToString(alder32Of(props))
+	for _, name := range previous {
+		if !containsString(federatedNames, name) {
+			toReconcile = append(toReconcile, name)
+		}
+	}
+	if r.checksums[request.NamespacedName] != checksum {
+		toReconcile = append(toReconcile, federatedNames...)
+	}
+	if len(toReconcile) > 0 {
+		reqLogger.V(1).Info("federation changed, reconciling brokers", "brokers", toReconcile)
+		if err := r.reconcileBrokers(request.Namespace, toReconcile, reqLogger); err == nil {
+			r.checksums[request.NamespacedName] = checksum
+		}
+	}
+	r.federated[request.NamespacedName] = federatedNames
+
+	instance.Status.FederatedCrs = federatedNames
+	if validCondition.Status == metav1.ConditionTrue {
+		processFederationStatus(instance, federatedCrs, r.Client)
+	} else {
+		removeLinkUpConditions(instance, nil)
+	}
+
+	if err := r.updateStatus(instance); err != nil {
+		reqLogger.Error(err, "unable to update status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: common.GetReconcileResyncPeriod()}, nil
+}
+
+func (r *ActiveMQArtemisFederationReconciler) reconcileBrokers(namespace string, names []string, reqLogger logr.Logger) error {
+	var result error
+	for _, name := range names {
+		if err := r.BrokerReconciler.ReconcileBroker(types.NamespacedName{Name: name, Namespace: namespace}); err != nil {
+			reqLogger.Error(err, "failed to reconcile federated broker", "broker", name)
+			result = err
+		}
+	}
+	return result
+}
+
+func (r *ActiveMQArtemisFederationReconciler) updateStatus(desired *brokerv1beta1.ActiveMQArtemisFederation) error {
+
+	common.SetReadyCondition(&desired.Status.Conditions)
+
+	current := &brokerv1beta1.ActiveMQArtemisFederation{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, current); err != nil {
+		return err
+	}
+
+	if len(desired.Status.Conditions) != len(current.Status.Conditions) ||
+		conditionsModified(desired.Status.Conditions, current.Status.Conditions) ||
+		!reflect.DeepEqual(desired.Status.FederatedCrs, current.Status.FederatedCrs) {
+		return resources.UpdateStatus(r.Client, desired)
+	}
+	return nil
+}
+
+// federationAppliesTo follows ApplyToCrNames of the security and address CRs
+func federationAppliesTo(instance *brokerv1beta1.ActiveMQArtemisFederation, crName string) bool {
+	if len(instance.Spec.ApplyToCrNames) == 0 {
+		return true
+	}
+	for _, name := range instance.Spec.ApplyToCrNames {
+		if name == "*" || name == "" || name == crName {
+			return true
+		}
+	}
+	return false
+}
+
+// federation is what the brokers of a CR are given for an ActiveMQArtemisFederation
+type federation struct {
+	name         string
+	props        []string
+	trustSecrets []string
+}
+
+// federationLink is an upstream or downstream of the federation as resolved for the brokers of one CR
+type federationLink struct {
+	// static connectors, host:port
+	hostPorts         []string
+	sslEnabled        bool
+	trustArgs         *certutil.SslArguments
+	user              string
+	password          string
+	reconnectAttempts *int32
+	policyRefs        []string
+}
+
+// resolveFederation works out the broker properties that federate the brokers
+// of the CR. A condition is returned when the federation can't be configured
+func resolveFederation(instance *brokerv1beta1.ActiveMQArtemisFederation, customResource *brokerv1beta1.ActiveMQArtemis, client rtclient.Client) (*federation, *metav1.Condition) {
+
+	invalid := func(reason string, format string, args ...interface{}) (*federation, *metav1.Condition) {
+		return nil, &metav1.Condition{
+			Type:    brokerv1beta1.ValidConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+
+	// names are key segments of the broker properties
+	if strings.Contains(instance.Name, ".") {
+		return invalid(brokerv1beta1.ValidConditionInvalidNameReason, "the name %s is used as a broker property key and can't contain '.'", instance.Name)
+	}
+
+	policies := []string{}
+	for _, policy := range instance.Spec.AddressPolicies {
+		policies = append(policies, policy.Name)
+	}
+	for _, policy := range instance.Spec.QueuePolicies {
+		policies = append(policies, policy.Name)
+	}
+	for index, policy := range policies {
+		if policy == "" || strings.Contains(policy, ".") || containsString(policies[:index], policy) {
+			return invalid(brokerv1beta1.ValidConditionInvalidPolicyReason, "policy names must be unique, set and without '.', %q is not", policy)
+		}
+	}
+
+	resolved := &federation{name: instance.Name}
+	key := "federationConfigurations." + instance.Name + "."
+	linkNames := []string{}
+
+	links := []brokerv1beta1.FederationUpstreamType{}
+	links = append(links, instance.Spec.Upstreams...)
+	for _, downstream := range instance.Spec.Downstreams {
+		links = append(links, downstream.FederationUpstreamType)
+	}
+	for index, spec := range links {
+		if spec.Name == "" || strings.Contains(spec.Name, ".") || containsString(linkNames, spec.Name) {
+			return invalid(brokerv1beta1.ValidConditionInvalidLinkReason, "upstream and downstream names must be unique, set and without '.', %q is not", spec.Name)
+		}
+		linkNames = append(linkNames, spec.Name)
+
+		link, condition := resolveFederationLink(instance, spec, client)
+		if condition != nil {
+			return nil, condition
+		}
+		for _, policy := range link.policyRefs {
+			if !containsString(policies, policy) {
+				return invalid(brokerv1beta1.ValidConditionInvalidPolicyReason, "%s refers to the policy %s that is not defined", spec.Name, policy)
+			}
+		}
+		if len(link.policyRefs) == 0 {
+			link.policyRefs = policies
+		}
+
+		linkKey := key + "upstreamConfigurations." + spec.Name + "."
+		if index >= len(instance.Spec.Upstreams) {
+			linkKey = key + "downstreamConfigurations." + spec.Name + "."
+
+			downstream := instance.Spec.Downstreams[index-len(instance.Spec.Upstreams)]
+			acceptor, port := findAcceptor(customResource, downstream.UpstreamAcceptorName)
+			if acceptor == nil {
+				return invalid(brokerv1beta1.ValidConditionInvalidLinkReason, "the downstream %s connects back to the acceptor %q that ActiveMQArtemis %s does not have", spec.Name, downstream.UpstreamAcceptorName, customResource.Name)
+			}
+			if !acceptsCore(acceptor) {
+				return invalid(brokerv1beta1.ValidConditionInvalidLinkReason, "the acceptor %s of ActiveMQArtemis %s does not accept CORE", acceptor.Name, customResource.Name)
+			}
+			// the downstream brokers connect back to the broker that told them to
+			connector := instance.Name + "-" + spec.Name + "-upstream"
+			resolved.props = append(resolved.props, linkKey+"upstreamConfigurationRef="+connector)
+			resolved.props = append(resolved.props, federationConnectorProperties(connector, "", strconv.Itoa(int(port)), acceptor.SSLEnabled, nil)...)
+			namers := MakeNamers(customResource)
+			for ordinal := int32(0); ordinal < common.GetDeploymentSize(customResource); ordinal++ {
+				host := common.OrdinalFQDN(namers, customResource.Namespace, ordinal)
+				resolved.props = append(resolved.props, OrdinalPrefix+strconv.Itoa(int(ordinal))+OrdinalPrefixSep+"connectorConfigurations."+connector+".params.host="+host)
+			}
+		}
+
+		connectors := []string{}
+		for position, hostPort := range link.hostPorts {
+			host, port, _ := net.SplitHostPort(hostPort)
+			connector := instance.Name + "-" + spec.Name + "-" + strconv.Itoa(position)
+			connectors = append(connectors, connector)
+			resolved.props = append(resolved.props, federationConnectorProperties(connector, host, port, link.sslEnabled, link.trustArgs)...)
+		}
+		resolved.props = append(resolved.props, linkKey+"connectionConfiguration.staticConnectors="+strings.Join(connectors, ","))
+		if link.user != "" {
+			resolved.props = append(resolved.props, linkKey+"connectionConfiguration.username="+link.user, linkKey+"connectionConfiguration.password="+link.password)
+		}
+		if link.reconnectAttempts != nil {
+			resolved.props = append(resolved.props, linkKey+"connectionConfiguration.reconnectAttempts="+strconv.Itoa(int(*link.reconnectAttempts)))
+		}
+		if len(link.policyRefs) > 0 {
+			resolved.props = append(resolved.props, linkKey+"policyRefs="+strings.Join(link.policyRefs, ","))
+		}
+		if link.sslEnabled && !containsString(resolved.trustSecrets, spec.TrustSecret) {
+			resolved.trustSecrets = append(resolved.trustSecrets, spec.TrustSecret)
+		}
+	}
+
+	for _, policy := range instance.Spec.AddressPolicies {
+		policyKey := key + "addressPolicies." + policy.Name + "."
+		for index, match := range policy.Includes {
+			resolved.props = append(resolved.props, policyKey+"includes.include"+strconv.Itoa(index)+".addressMatch="+match)
+		}
+		for index, match := range policy.Excludes {
+			resolved.props = append(resolved.props, policyKey+"excludes.exclude"+strconv.Itoa(index)+".addressMatch="+match)
+		}
+		if policy.MaxHops != nil {
+			resolved.props = append(resolved.props, policyKey+"maxHops="+strconv.Itoa(int(*policy.MaxHops)))
+		}
+		if policy.AutoDelete != nil {
+			resolved.props = append(resolved.props, policyKey+"autoDelete="+strconv.FormatBool(*policy.AutoDelete))
+		}
+		if policy.AutoDeleteDelay != nil {
+			resolved.props = append(resolved.props, policyKey+"autoDeleteDelay="+strconv.FormatInt(*policy.AutoDeleteDelay, 10))
+		}
+		if policy.AutoDeleteMessageCount != nil {
+			resolved.props = append(resolved.props, policyKey+"autoDeleteMessageCount="+strconv.FormatInt(*policy.AutoDeleteMessageCount, 10))
+		}
+		if policy.EnableDivertBindings != nil {
+			resolved.props = append(resolved.props, policyKey+"enableDivertBindings="+strconv.FormatBool(*policy.EnableDivertBindings))
+		}
+	}
+
+	for _, policy := range instance.Spec.QueuePolicies {
+		policyKey := key + "queuePolicies." + policy.Name + "."
+		for kind, matches := range map[string][]brokerv1beta1.FederationQueueMatchType{"include": policy.Includes, "exclude": policy.Excludes} {
+			for index, match := range matches {
+				matchKey := policyKey + kind + "s." + kind + strconv.Itoa(index) + "."
+				if match.AddressMatch != "" {
+					resolved.props = append(resolved.props, matchKey+"addressMatch="+match.AddressMatch)
+				}
+				if match.QueueMatch != "" {
+					resolved.props = append(resolved.props, matchKey+"queueMatch="+match.QueueMatch)
+				}
+			}
+		}
+		if policy.IncludeFederated != nil {
+			resolved.props = append(resolved.props, policyKey+"includeFederated="+strconv.FormatBool(*policy.IncludeFederated))
+		}
+		if policy.PriorityAdjustment != nil {
+			resolved.props = append(resolved.props, policyKey+"priorityAdjustment="+strconv.Itoa(int(*policy.PriorityAdjustment)))
+		}
+	}
+	// the map iteration above has no order, keep the checksum of the properties stable
+	sort.Strings(resolved.props)
+
+	return resolved, nil
+}
+
+// resolveFederationLink works out where an upstream or downstream is and how to connect to it
+func resolveFederationLink(instance *brokerv1beta1.ActiveMQArtemisFederation, spec brokerv1beta1.FederationUpstreamType, client rtclient.Client) (*federationLink, *metav1.Condition) {
+
+	invalid := func(reason string, format string, args ...interface{}) (*federationLink, *metav1.Condition) {
+		return nil, &metav1.Condition{
+			Type:    brokerv1beta1.ValidConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: spec.Name + ": " + fmt.Sprintf(format, args...),
+		}
+	}
+
+	targets := 0
+	for _, set := range []bool{spec.CrName != "", spec.ServiceName != "", len(spec.Endpoints) > 0} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return invalid(brokerv1beta1.ValidConditionInvalidLinkReason, "exactly one of crName, serviceName and endpoints is required")
+	}
+
+	link := &federationLink{
+		sslEnabled:        spec.SSLEnabled,
+		reconnectAttempts: spec.ReconnectAttempts,
+		policyRefs:        spec.PolicyRefs,
+	}
+
+	switch {
+	case spec.CrName != "":
+		linkCr := &brokerv1beta1.ActiveMQArtemis{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: spec.CrName, Namespace: instance.Namespace}, linkCr); err != nil {
+			return invalid(brokerv1beta1.ValidConditionMissingResourcesReason, "unable to find ActiveMQArtemis %s, %v", spec.CrName, err)
+		}
+		acceptor, port := findAcceptor(linkCr, spec.AcceptorName)
+		if acceptor == nil {
+			return invalid(brokerv1beta1.ValidConditionInvalidLinkReason, "ActiveMQArtemis %s has no acceptor named %q", spec.CrName, spec.AcceptorName)
+		}
+		if !acceptsCore(acceptor) {
+			return invalid(brokerv1beta1.ValidConditionInvalidLinkReason, "the acceptor %s of ActiveMQArtemis %s does not accept CORE", acceptor.Name, spec.CrName)
+		}
+		link.sslEnabled = acceptor.SSLEnabled
+
+		linkNamers := MakeNamers(linkCr)
+		for ordinal := int32(0); ordinal < common.GetDeploymentSize(linkCr); ordinal++ {
+			link.hostPorts = append(link.hostPorts, net.JoinHostPort(common.OrdinalFQDN(linkNamers, linkCr.Namespace, ordinal), strconv.Itoa(int(port))))
+		}
+
+		if spec.CredentialsSecret == "" {
+			var err error
+			secretName := linkNamers.SecretsCredentialsNameBuilder.Name()
+			if link.user, link.password, err = readCredentialsSecret(client, instance.Namespace, secretName, "AMQ_USER", "AMQ_PASSWORD"); err != nil {
+				return invalid(brokerv1beta1.ValidConditionMissingResourcesReason, "unable to find the credentials secret %s of ActiveMQArtemis %s, %v", secretName, spec.CrName, err)
+			}
+		}
+
+	case spec.ServiceName != "":
+		service := &corev1.Service{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: spec.ServiceName, Namespace: instance.Namespace}, service); err != nil {
+			return invalid(brokerv1beta1.ValidConditionMissingResourcesReason, "unable to find the Service %s, %v", spec.ServiceName, err)
+		}
+		port := int32(0)
+		for _, servicePort := range service.Spec.Ports {
+			if spec.ServicePort == 0 || servicePort.Port == spec.ServicePort {
+				port = servicePort.Port
+				break
+			}
+		}
+		if port == 0 {
+			return invalid(brokerv1beta1.ValidConditionInvalidLinkReason, "the Service %s has no port %d", spec.ServiceName, spec.ServicePort)
+		}
+		link.hostPorts = []string{net.JoinHostPort(common.ServiceFQDN(service.Name, service.Namespace), strconv.Itoa(int(port)))}
+
+	default:
+		for _, endpoint := range spec.Endpoints {
+			if _, _, err := net.SplitHostPort(endpoint); err != nil {
+				return invalid(brokerv1beta1.ValidConditionInvalidLinkReason, "endpoints must be host:port, %v", err)
+			}
+		}
+		link.hostPorts = spec.Endpoints
+	}
+
+	if spec.CredentialsSecret != "" {
+		var err error
+		if link.user, link.password, err = readCredentialsSecret(client, instance.Namespace, spec.CredentialsSecret, brokerConnectionUserKey, brokerConnectionPasswordKey); err != nil {
+			return invalid(brokerv1beta1.ValidConditionMissingResourcesReason, "unable to find the credentials secret %s, %v", spec.CredentialsSecret, err)
+		}
+	}
+
+	if link.sslEnabled {
+		if spec.TrustSecret == "" {
+			return invalid(brokerv1beta1.ValidConditionInvalidLinkReason, "trustSecret is required to connect with TLS")
+		}
+		trustSecret := &corev1.Secret{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Name: spec.TrustSecret, Namespace: instance.Namespace}, trustSecret); err != nil {
+			return invalid(brokerv1beta1.ValidConditionMissingResourcesReason, "unable to find the trust secret %s, %v", spec.TrustSecret, err)
+		}
+		trustArgs, err := certutil.GetTrustArgumentsFromSecret(trustSecret, spec.TrustStoreType)
+		if err != nil {
+			return invalid(brokerv1beta1.ValidConditionInvalidCertSecretReason, "the trust secret %s can't be used, %v", spec.TrustSecret, err)
+		}
+		link.trustArgs = trustArgs
+	}
+
+	return link, nil
+}
+
+func acceptsCore(acceptor *brokerv1beta1.AcceptorType) bool {
+	protocols := strings.ToUpper(acceptor.Protocols)
+	return protocols == "" || protocols == "ALL" || strings.Contains(protocols, "CORE")
+}
+
+// federationConnectorProperties define a connector, the host is left out when it differs by ordinal
+func federationConnectorProperties(connector string, host string, port string, sslEnabled bool, trustArgs *certutil.SslArguments) []string {
+	key := "connectorConfigurations." + connector + "."
+	props := []string{
+		key + "factoryClassName=" + federationConnectorFactory,
+		key + "params.port=" + port,
+	}
+	if host != "" {
+		props = append(props, key+"params.host="+host)
+	}
+	if sslEnabled {
+		props = append(props, key+"params.sslEnabled=true")
+	}
+	if trustArgs != nil {
+		props = append(props, key+"params.trustStorePath="+trustArgs.TrustStorePath)
+		if trustArgs.TrustStoreType != "" {
+			props = append(props, key+"params.trustStoreType="+trustArgs.TrustStoreType)
+		}
+		if trustArgs.TrustStorePassword != nil {
+			props = append(props, key+"params.trustStorePassword="+*trustArgs.TrustStorePassword)
+		}
+	}
+	return props
+}
+
+// resolveFederationsFor gives the federations that apply to the CR, ordered by
+// name. Federations that can't be resolved are left out, the federation
+// reconciler reports why
+func resolveFederationsFor(customResource *brokerv1beta1.ActiveMQArtemis, client rtclient.Client, log logr.Logger) []*federation {
+	resolved := []*federation{}
+
+	list := &brokerv1beta1.ActiveMQArtemisFederationList{}
+	if err := client.List(context.TODO(), list, &rtclient.ListOptions{Namespace: customResource.Namespace}); err != nil {
+		log.Error(err, "unable to list federations")
+		return resolved
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Name < list.Items[j].Name
+	})
+
+	for index := range list.Items {
+		if !federationAppliesTo(&list.Items[index], customResource.Name) {
+			continue
+		}
+		federation, condition := resolveFederation(&list.Items[index], customResource, client)
+		if condition != nil {
+			log.V(1).Info("skipping federation", "name", list.Items[index].Name, "reason", condition.Message)
+			continue
+		}
+		resolved = append(resolved, federation)
+	}
+	return resolved
+}
+
+// federationProperties collects the properties of the resolved federations
+func federationProperties(federations []*federation) []string {
+	props := []string{}
+	for _, federation := range federations {
+		props = append(props, federation.props...)
+	}
+	return props
+}
+
+type connectionInfo struct {
+	ClientAddress string `json:"clientAddress"`
+}
+
+// processFederationStatus sets a LinkUp condition for each upstream and
+// downstream. The federated brokers open a connection to the brokers of a
+// link, so a link to a CR is up when one of its brokers has a connection from
+// each federated broker. Links to a Service or endpoints can't be observed
+func processFederationStatus(instance *brokerv1beta1.ActiveMQArtemisFederation, federatedCrs []*brokerv1beta1.ActiveMQArtemis, client rtclient.Client) {
+	reqLogger := ctrl.Log.WithValues("ActiveMQArtemisFederation Name", instance.Name)
+
+	// pod IP to pod name of the running federated brokers
+	federatedPods := map[string]string{}
+	for _, customResource := range federatedCrs {
+		for ordinal := int32(0); ordinal < common.GetDeploymentSize(customResource); ordinal++ {
+			pod := &corev1.Pod{}
+			podName := namer.CrToSS(customResource.Name) + "-" + strconv.Itoa(int(ordinal))
+			if err := client.Get(context.TODO(), types.NamespacedName{Name: podName, Namespace: customResource.Namespace}, pod); err == nil && pod.Status.PodIP != "" {
+				federatedPods[pod.Status.PodIP] = podName
+			}
+		}
+	}
+
+	links := []brokerv1beta1.FederationUpstreamType{}
+	links = append(links, instance.Spec.Upstreams...)
+	for _, downstream := range instance.Spec.Downstreams {
+		links = append(links, downstream.FederationUpstreamType)
+	}
+
+	current := map[string]bool{}
+	for _, link := range links {
+		condition := metav1.Condition{
+			Type:               brokerv1beta1.LinkUpConditionTypePrefix + link.Name,
+			Status:             metav1.ConditionUnknown,
+			ObservedGeneration: instance.Generation,
+		}
+		current[condition.Type] = true
+
+		if link.CrName == "" {
+			condition.Reason = brokerv1beta1.LinkUpConditionNotObservableReason
+			condition.Message = "only links to an ActiveMQArtemis CR in this namespace are observed"
+		} else if clients, observed := linkClientAddresses(link.CrName, instance.Namespace, client, reqLogger); !observed || len(federatedPods) == 0 {
+			condition.Reason = brokerv1beta1.LinkUpConditionUnknownReason
+			condition.Message = "the federated brokers and the brokers of " + link.CrName + " need to be running"
+		} else {
+			notConnected := []string{}
+			for ip, podName := range federatedPods {
+				if !clients[ip] {
+					notConnected = append(notConnected, podName)
+				}
+			}
+			sort.Strings(notConnected)
+			if len(notConnected) == 0 {
+				condition.Status = metav1.ConditionTrue
+				condition.Reason = brokerv1beta1.LinkUpConditionConnectedReason
+			} else {
+				condition.Status = metav1.ConditionFalse
+				condition.Reason = brokerv1beta1.LinkUpConditionNotConnectedReason
+				condition.Message = "not connected to " + link.CrName + ": " + strings.Join(notConnected, ", ")
+			}
+		}
+		meta.SetStatusCondition(&instance.Status.Conditions, condition)
+	}
+	removeLinkUpConditions(instance, current)
+}
+
+// removeLinkUpConditions drops the LinkUp conditions of links that are gone
+func removeLinkUpConditions(instance *brokerv1beta1.ActiveMQArtemisFederation, keep map[string]bool) {
+	for _, condition := range append([]metav1.Condition{}, instance.Status.Conditions...) {
+		if strings.HasPrefix(condition.Type, brokerv1beta1.LinkUpConditionTypePrefix) && !keep[condition.Type] {
+			meta.RemoveStatusCondition(&instance.Status.Conditions, condition.Type)
+		}
+	}
+}
+
+// linkClientAddresses gives the IPs that the brokers of the CR have connections
+// from, it is not observed when none of the brokers could be asked
+func linkClientAddresses(crName string, namespace string, client rtclient.Client, reqLogger logr.Logger) (map[string]bool, bool) {
+	resource := types.NamespacedName{Name: crName, Namespace: namespace}
+	ssInfos := ss.GetDeployedStatefulSetNames(client, namespace, []types.NamespacedName{resource})
+
+	clients := map[string]bool{}
+	observed := false
+	for _, jk := range jolokia_client.GetBrokers(resource, ssInfos, client) {
+		data, err := jk.Artemis.ListConnections()
+		if err != nil || data == nil {
+			reqLogger.V(2).Info("unable to list connections", "IP", jk.IP, "Ordinal", jk.Ordinal, "error", err)
+			continue
+		}
+		infos := []connectionInfo{}
+		if err := json.Unmarshal([]byte(data.Value), &infos); err != nil {
+			reqLogger.V(2).Info("unable to parse connections", "Ordinal", jk.Ordinal, "value", data.Value, "error", err)
+			continue
+		}
+		observed = true
+		for _, info := range infos {
+			// the address is /ip:port
+			if host, _, err := net.SplitHostPort(strings.TrimPrefix(info.ClientAddress, "/")); err == nil {
+				clients[host] = true
+			}
+		}
+	}
+	return clients, observed
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ActiveMQArtemisFederationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&brokerv1beta1.ActiveMQArtemisFederation{}).
+		Complete(r)
+}
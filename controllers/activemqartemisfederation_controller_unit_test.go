@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"testing"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/certutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func newFederation(spec brokerv1beta1.ActiveMQArtemisFederationSpec) *brokerv1beta1.ActiveMQArtemisFederation {
+	return &brokerv1beta1.ActiveMQArtemisFederation{
+		ObjectMeta: metav1.ObjectMeta{Name: "fed", Namespace: "test"},
+		Spec:       spec,
+	}
+}
+
+func TestFederationUpstreamFromCr(t *testing.T) {
+	federated := newTestBroker("br", 2)
+	upstream := newTestBroker("central", 2, brokerv1beta1.AcceptorType{Name: "core", Port: 61616, Protocols: "CORE,AMQP"})
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "central-credentials-secret", Namespace: "test"},
+		Data:       map[string][]byte{"AMQ_USER": []byte("admin"), "AMQ_PASSWORD": []byte("secret")},
+	}
+	instance := newFederation(brokerv1beta1.ActiveMQArtemisFederationSpec{
+		Upstreams: []brokerv1beta1.FederationUpstreamType{{Name: "central", CrName: "central", AcceptorName: "core"}},
+		AddressPolicies: []brokerv1beta1.FederationAddressPolicyType{{
+			Name:     "news",
+			Includes: []string{"news.#"},
+			Excludes: []string{"news.internal"},
+			MaxHops:  &[]int32{1}[0],
+		}},
+		QueuePolicies: []brokerv1beta1.FederationQueuePolicyType{{
+			Name:               "orders",
+			Includes:           []brokerv1beta1.FederationQueueMatchType{{AddressMatch: "orders.#", QueueMatch: "orders"}},
+			PriorityAdjustment: &[]int32{-2}[0],
+		}},
+	})
+
+	resolved, condition := resolveFederation(instance, federated, newTestClient(upstream, credentials))
+	assert.Nil(t, condition)
+
+	assert.Contains(t, resolved.props, "connectorConfigurations.fed-central-1.factoryClassName="+federationConnectorFactory)
+	assert.Contains(t, resolved.props, "connectorConfigurations.fed-central-1.params.host=central-ss-1.central-hdls-svc.test.svc.cluster.local")
+	assert.Contains(t, resolved.props, "connectorConfigurations.fed-central-1.params.port=61616")
+
+	upstreamKey := "federationConfigurations.fed.upstreamConfigurations.central."
+	assert.Contains(t, resolved.props, upstreamKey+"connectionConfiguration.staticConnectors=fed-central-0,fed-central-1")
+	assert.Contains(t, resolved.props, upstreamKey+"connectionConfiguration.username=admin")
+	assert.Contains(t, resolved.props, upstreamKey+"connectionConfiguration.password=secret")
+	assert.Contains(t, resolved.props, upstreamKey+"policyRefs=news,orders")
+
+	assert.Contains(t, resolved.props, "federationConfigurations.fed.addressPolicies.news.includes.include0.addressMatch=news.#")
+	assert.Contains(t, resolved.props, "federationConfigurations.fed.addressPolicies.news.excludes.exclude0.addressMatch=news.internal")
+	assert.Contains(t, resolved.props, "federationConfigurations.fed.addressPolicies.news.maxHops=1")
+	assert.Contains(t, resolved.props, "federationConfigurations.fed.queuePolicies.orders.includes.include0.addressMatch=orders.#")
+	assert.Contains(t, resolved.props, "federationConfigurations.fed.queuePolicies.orders.includes.include0.queueMatch=orders")
+	assert.Contains(t, resolved.props, "federationConfigurations.fed.queuePolicies.orders.priorityAdjustment=-2")
+
+	// upstream properties are the same for every federated broker
+	for _, prop := range resolved.props {
+		assert.NotContains(t, prop, OrdinalPrefix)
+	}
+	assert.Empty(t, resolved.trustSecrets)
+}
+
+func TestFederationDownstreamToServiceWithTLS(t *testing.T) {
+	federated := newTestBroker("br", 2, brokerv1beta1.AcceptorType{Name: "federation", Port: 61617, SSLEnabled: true})
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "hub", Namespace: "test"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "web", Port: 8161}, {Name: "core", Port: 61616}}},
+	}
+	bundle := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hub-ca", Namespace: "test", Annotations: map[string]string{certutil.Bundle_annotation_key: "hash"}},
+		Data:       map[string][]byte{"ca.pem": []byte("pem")},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hub-user", Namespace: "test"},
+		Data:       map[string][]byte{"user": []byte("federation"), "password": []byte("pass")},
+	}
+	instance := newFederation(brokerv1beta1.ActiveMQArtemisFederationSpec{
+		Downstreams: []brokerv1beta1.FederationDownstreamType{{
+			FederationUpstreamType: brokerv1beta1.FederationUpstreamType{
+				Name:              "hub",
+				ServiceName:       "hub",
+				ServicePort:       61616,
+				SSLEnabled:        true,
+				TrustSecret:       "hub-ca",
+				CredentialsSecret: "hub-user",
+				ReconnectAttempts: &[]int32{-1}[0],
+			},
+			UpstreamAcceptorName: "federation",
+		}},
+		AddressPolicies: []brokerv1beta1.FederationAddressPolicyType{{Name: "all", Includes: []string{"#"}}},
+	})
+
+	resolved, condition := resolveFederation(instance, federated, newTestClient(service, bundle, credentials))
+	assert.Nil(t, condition)
+
+	assert.Contains(t, resolved.props, "connectorConfigurations.fed-hub-0.params.host=hub.test.svc.cluster.local")
+	assert.Contains(t, resolved.props, "connectorConfigurations.fed-hub-0.params.port=61616")
+	assert.Contains(t, resolved.props, "connectorConfigurations.fed-hub-0.params.sslEnabled=true")
+	assert.Contains(t, resolved.props, "connectorConfigurations.fed-hub-0.params.trustStorePath=/etc/hub-ca-volume/ca.pem")
+	assert.Contains(t, resolved.props, "connectorConfigurations.fed-hub-0.params.trustStoreType=PEM")
+
+	downstreamKey := "federationConfigurations.fed.downstreamConfigurations.hub."
+	assert.Contains(t, resolved.props, downstreamKey+"connectionConfiguration.staticConnectors=fed-hub-0")
+	assert.Contains(t, resolved.props, downstreamKey+"connectionConfiguration.username=federation")
+	assert.Contains(t, resolved.props, downstreamKey+"connectionConfiguration.reconnectAttempts=-1")
+	assert.Contains(t, resolved.props, downstreamKey+"policyRefs=all")
+
+	// the downstream connects back to the broker that told it to
+	assert.Contains(t, resolved.props, downstreamKey+"upstreamConfigurationRef=fed-hub-upstream")
+	assert.Contains(t, resolved.props, "connectorConfigurations.fed-hub-upstream.params.port=61617")
+	assert.Contains(t, resolved.props, "connectorConfigurations.fed-hub-upstream.params.sslEnabled=true")
+	assert.Contains(t, resolved.props, "broker-1.connectorConfigurations.fed-hub-upstream.params.host=br-ss-1.br-hdls-svc.test.svc.cluster.local")
+	assert.Equal(t, []string{"hub-ca"}, resolved.trustSecrets)
+
+	// the federated brokers get the properties and the trust secret mounted
+	namer := MakeNamers(federated)
+	reconciler := NewActiveMQArtemisReconcilerImpl(federated, ctrl.Log.WithName("federation_test"), nil)
+	reconciler.federations = []*federation{resolved}
+
+	_, _, data, err := reconciler.addResourceForBrokerProperties(federated, *namer)
+	assert.NoError(t, err)
+	assert.Contains(t, data[BrokerPropertiesName], downstreamKey+"connectionConfiguration.password=pass\n")
+	assert.Contains(t, data["broker-0."+BrokerPropertiesName], "connectorConfigurations.fed-hub-upstream.params.host=br-ss-0.br-hdls-svc.test.svc.cluster.local\n")
+
+	podVolumes, err := reconciler.MakeVolumes(federated, *namer)
+	assert.NoError(t, err)
+	found := false
+	for _, volume := range podVolumes {
+		if volume.Name == "hub-ca-volume" {
+			found = true
+			assert.Equal(t, "hub-ca", volume.Secret.SecretName)
+		}
+	}
+	assert.True(t, found)
+
+	mounts, err := reconciler.MakeVolumeMounts(federated, *namer)
+	assert.NoError(t, err)
+	assert.Contains(t, mounts, corev1.VolumeMount{Name: "hub-ca-volume", MountPath: "/etc/hub-ca-volume", ReadOnly: true})
+}
+
+func TestResolveFederationsForApplyToCrNames(t *testing.T) {
+	federated := newTestBroker("br", 1)
+	all := newFederation(brokerv1beta1.ActiveMQArtemisFederationSpec{
+		Upstreams: []brokerv1beta1.FederationUpstreamType{{Name: "up", Endpoints: []string{"central:61616"}}},
+	})
+	all.Name = "all"
+	mine := all.DeepCopy()
+	mine.Name = "mine"
+	mine.Spec.ApplyToCrNames = []string{"other", "br"}
+	other := all.DeepCopy()
+	other.Name = "other"
+	other.Spec.ApplyToCrNames = []string{"other"}
+	invalid := all.DeepCopy()
+	invalid.Name = "invalid"
+	invalid.Spec.Upstreams[0].Endpoints = []string{"central"}
+
+	resolved := resolveFederationsFor(federated, newTestClient(all, mine, other, invalid), ctrl.Log)
+	if assert.Len(t, resolved, 2) {
+		assert.Equal(t, "all", resolved[0].name)
+		assert.Equal(t, "mine", resolved[1].name)
+	}
+}
+
+func TestFederationInvalid(t *testing.T) {
+	federated := newTestBroker("br", 1, brokerv1beta1.AcceptorType{Name: "amqp", Protocols: "AMQP"})
+	upstream := newTestBroker("central", 1,
+		brokerv1beta1.AcceptorType{Name: "core", Port: 61616},
+		brokerv1beta1.AcceptorType{Name: "amqp", Port: 5672, Protocols: "AMQP"},
+		brokerv1beta1.AcceptorType{Name: "tls", Port: 61617, SSLEnabled: true})
+	client := newTestClient(upstream)
+
+	endpoint := brokerv1beta1.FederationUpstreamType{Name: "up", Endpoints: []string{"central:61616"}, CredentialsSecret: "creds"}
+	for name, test := range map[string]struct {
+		spec   brokerv1beta1.ActiveMQArtemisFederationSpec
+		reason string
+	}{
+		"no target": {brokerv1beta1.ActiveMQArtemisFederationSpec{
+			Upstreams: []brokerv1beta1.FederationUpstreamType{{Name: "up"}}}, brokerv1beta1.ValidConditionInvalidLinkReason},
+		"cr and endpoints": {brokerv1beta1.ActiveMQArtemisFederationSpec{
+			Upstreams: []brokerv1beta1.FederationUpstreamType{{Name: "up", CrName: "central", Endpoints: []string{"central:61616"}}}}, brokerv1beta1.ValidConditionInvalidLinkReason},
+		"duplicate link": {brokerv1beta1.ActiveMQArtemisFederationSpec{
+			Upstreams:   []brokerv1beta1.FederationUpstreamType{{Name: "up", Endpoints: []string{"central:61616"}}},
+			Downstreams: []brokerv1beta1.FederationDownstreamType{{FederationUpstreamType: brokerv1beta1.FederationUpstreamType{Name: "up", Endpoints: []string{"central:61616"}}}}}, brokerv1beta1.ValidConditionInvalidLinkReason},
+		"dotted link": {brokerv1beta1.ActiveMQArtemisFederationSpec{
+			Upstreams: []brokerv1beta1.FederationUpstreamType{{Name: "to.central", Endpoints: []string{"central:61616"}}}}, brokerv1beta1.ValidConditionInvalidLinkReason},
+		"missing cr": {brokerv1beta1.ActiveMQArtemisFederationSpec{
+			Upstreams: []brokerv1beta1.FederationUpstreamType{{Name: "up", CrName: "missing", AcceptorName: "core"}}}, brokerv1beta1.ValidConditionMissingResourcesReason},
+		"acceptor without core": {brokerv1beta1.ActiveMQArtemisFederationSpec{
+			Upstreams: []brokerv1beta1.FederationUpstreamType{{Name: "up", CrName: "central", AcceptorName: "amqp", CredentialsSecret: "creds"}}}, brokerv1beta1.ValidConditionInvalidLinkReason},
+		"tls without trust": {brokerv1beta1.ActiveMQArtemisFederationSpec{
+			Upstreams: []brokerv1beta1.FederationUpstreamType{{Name: "up", Endpoints: []string{"central:61617"}, SSLEnabled: true}}}, brokerv1beta1.ValidConditionInvalidLinkReason},
+		"missing service": {brokerv1beta1.ActiveMQArtemisFederationSpec{
+			Upstreams: []brokerv1beta1.FederationUpstreamType{{Name: "up", ServiceName: "hub"}}}, brokerv1beta1.ValidConditionMissingResourcesReason},
+		"missing credentials": {brokerv1beta1.ActiveMQArtemisFederationSpec{
+			Upstreams: []brokerv1beta1.FederationUpstreamType{endpoint}}, brokerv1beta1.ValidConditionMissingResourcesReason},
+		"undefined policy": {brokerv1beta1.ActiveMQArtemisFederationSpec{
+			Upstreams: []brokerv1beta1.FederationUpstreamType{{Name: "up", Endpoints: []string{"central:61616"}, PolicyRefs: []string{"news"}}}}, brokerv1beta1.ValidConditionInvalidPolicyReason},
+		"duplicate policy": {brokerv1beta1.ActiveMQArtemisFederationSpec{
+			AddressPolicies: []brokerv1beta1.FederationAddressPolicyType{{Name: "news"}},
+			QueuePolicies:   []brokerv1beta1.FederationQueuePolicyType{{Name: "news"}}}, brokerv1beta1.ValidConditionInvalidPolicyReason},
+		"downstream acceptor without core": {brokerv1beta1.ActiveMQArtemisFederationSpec{
+			Downstreams: []brokerv1beta1.FederationDownstreamType{{
+				FederationUpstreamType: brokerv1beta1.FederationUpstreamType{Name: "down", Endpoints: []string{"central:61616"}},
+				UpstreamAcceptorName:   "amqp"}}}, brokerv1beta1.ValidConditionInvalidLinkReason},
+	} {
+		resolved, condition := resolveFederation(newFederation(test.spec), federated, client)
+		assert.Nil(t, resolved, name)
+		if assert.NotNil(t, condition, name) {
+			assert.Equal(t, metav1.ConditionFalse, condition.Status, name)
+			assert.Equal(t, test.reason, condition.Reason, name)
+		}
+	}
+
+	dotted := newFederation(brokerv1beta1.ActiveMQArtemisFederationSpec{})
+	dotted.Name = "to.central"
+	_, condition := resolveFederation(dotted, federated, client)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidNameReason, condition.Reason)
+	}
+}
+
+func TestFederationLinkUpConditions(t *testing.T) {
+	federated := newTestBroker("br", 1)
+	instance := newFederation(brokerv1beta1.ActiveMQArtemisFederationSpec{
+		Upstreams: []brokerv1beta1.FederationUpstreamType{
+			{Name: "external", Endpoints: []string{"central.example.com:61616"}},
+			{Name: "central", CrName: "central", AcceptorName: "core"},
+		},
+	})
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{Type: brokerv1beta1.LinkUpConditionTypePrefix + "removed", Status: metav1.ConditionTrue, Reason: brokerv1beta1.LinkUpConditionConnectedReason})
+
+	processFederationStatus(instance, []*brokerv1beta1.ActiveMQArtemis{federated}, newTestClient(federated))
+
+	external := meta.FindStatusCondition(instance.Status.Conditions, brokerv1beta1.LinkUpConditionTypePrefix+"external")
+	if assert.NotNil(t, external) {
+		assert.Equal(t, metav1.ConditionUnknown, external.Status)
+		assert.Equal(t, brokerv1beta1.LinkUpConditionNotObservableReason, external.Reason)
+	}
+	// no broker is running to ask
+	central := meta.FindStatusCondition(instance.Status.Conditions, brokerv1beta1.LinkUpConditionTypePrefix+"central")
+	if assert.NotNil(t, central) {
+		assert.Equal(t, metav1.ConditionUnknown, central.Status)
+		assert.Equal(t, brokerv1beta1.LinkUpConditionUnknownReason, central.Reason)
+	}
+	assert.Nil(t, meta.FindStatusCondition(instance.Status.Conditions, brokerv1beta1.LinkUpConditionTypePrefix+"removed"))
+}
@@ -18,41 +18,34 @@ package controllers
 
 import (
 	"context"
-	"os"
-	"time"
 
 	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
 	"github.com/artemiscloud/activemq-artemis-operator/pkg/draincontroller"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/namer"
 	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
-	kubeinformers "k8s.io/client-go/informers"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-var StopCh chan struct{}
-
-var controllers map[string]*draincontroller.Controller = make(map[string]*draincontroller.Controller)
-
-var kubeClient *kubernetes.Clientset
-
 // ActiveMQArtemisScaledownReconciler reconciles a ActiveMQArtemisScaledown object
 type ActiveMQArtemisScaledownReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
-	Config *rest.Config
 	log    logr.Logger
+	drain  *draincontroller.Controller
 }
 
-func NewActiveMQArtemisScaledownReconciler(client client.Client, scheme *runtime.Scheme, config *rest.Config, logger logr.Logger) *ActiveMQArtemisScaledownReconciler {
+func NewActiveMQArtemisScaledownReconciler(client client.Client, scheme *runtime.Scheme, logger logr.Logger) *ActiveMQArtemisScaledownReconciler {
 	return &ActiveMQArtemisScaledownReconciler{
 		Client: client,
 		Scheme: scheme,
-		Config: config,
 		log:    logger,
 	}
 }
@@ -89,76 +82,24 @@ func (r *ActiveMQArtemisScaledownReconciler) Reconcile(ctx context.Context, requ
 
 	reqLogger.V(2).Info("scaling down", "localOnly:", instance.Spec.LocalOnly)
 
-	kubeClient, err = kubernetes.NewForConfig(r.Config)
-	if err != nil {
-		reqLogger.Error(err, "Error building kubernetes clientset")
-	}
-
-	kubeInformerFactory, drainControllerInstance, isNewController := r.getDrainController(instance.Spec.LocalOnly, request.Namespace, kubeClient, instance)
-
-	if isNewController {
-		reqLogger.V(2).Info("Starting async factory...")
-		go kubeInformerFactory.Start(*drainControllerInstance.GetStopCh())
-
-		reqLogger.V(2).Info("Running drain controller async so multiple controllers can run...")
-		go r.runDrainController(drainControllerInstance)
-	}
-
-	reqLogger.V(1).Info("OK, return result")
-	return ctrl.Result{}, nil
+	return r.drain.Reconcile(instance)
 }
 
-func (r *ActiveMQArtemisScaledownReconciler) getDrainController(localOnly bool, namespace string, kubeClient *kubernetes.Clientset, instance *brokerv1beta1.ActiveMQArtemisScaledown) (kubeinformers.SharedInformerFactory, *draincontroller.Controller, bool) {
-	var kubeInformerFactory kubeinformers.SharedInformerFactory
-	var controllerInstance *draincontroller.Controller
-	controllerKey := "*"
-	if localOnly {
-		if namespace == "" {
-			bytes, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
-			if err != nil {
-				r.log.Error(err, "Using --localOnly without --namespace, but unable to determine namespace")
-			}
-			namespace = string(bytes)
-			r.log.V(2).Info("reading ns from file", "namespace", namespace)
-		}
-		controllerKey = namespace
-	}
-	if inst, ok := controllers[controllerKey]; ok {
-		r.log.V(2).Info("Drain controller already exists", "namespace", namespace)
-		inst.AddInstance(instance)
-		return nil, nil, false
-	}
-
-	if localOnly {
-		// localOnly means there is only one target namespace and it is the same as operator's
-		r.log.V(2).Info("getting localOnly informer factory", "namespace", controllerKey)
-		r.log.V(2).Info("Configured to only operate on StatefulSets", "namespace", namespace)
-		kubeInformerFactory = kubeinformers.NewFilteredSharedInformerFactory(kubeClient, time.Second*30, namespace, nil)
-	} else {
-		r.log.V(2).Info("Creating informer factory to operate on StatefulSets across all namespaces")
-		kubeInformerFactory = kubeinformers.NewSharedInformerFactory(kubeClient, time.Second*30)
-	}
-
-	r.log.V(2).Info("new drain controller...", "labels", instance.Labels)
-	controllerInstance = draincontroller.NewController(controllerKey, kubeClient, kubeInformerFactory, namespace, r.Client, instance, r.log)
-	controllers[controllerKey] = controllerInstance
-
-	r.log.V(2).Info("Adding scaledown instance to controller", "controller", controllerInstance, "scaledown", instance)
-	controllerInstance.AddInstance(instance)
-
-	return kubeInformerFactory, controllerInstance, true
-}
-
-func (r *ActiveMQArtemisScaledownReconciler) runDrainController(controller *draincontroller.Controller) {
-	if err := controller.Run(1); err != nil {
-		r.log.Error(err, "Error running controller")
-	}
-}
-
-// SetupWithManager sets up the controller with the Manager.
+// SetupWithManager sets up the controller with the Manager. The statefulset a
+// scaledown CR drains is named after the broker CR like the scaledown CR, its
+// broker pods and drain pods lead to it.
 func (r *ActiveMQArtemisScaledownReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.drain = draincontroller.NewController(r.Client, mgr.GetEventRecorderFor(draincontroller.ControllerAgentName), r.log.WithName("draincontroller"))
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&brokerv1beta1.ActiveMQArtemisScaledown{}).
-		Owns(&corev1.Pod{}).
+		Watches(&appsv1.StatefulSet{}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: namer.SSToCr(obj.GetName())}}}
+		})).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []reconcile.Request {
+			if key := draincontroller.ScaledownKey(obj.(*corev1.Pod)); key != nil {
+				return []reconcile.Request{{NamespacedName: *key}}
+			}
+			return nil
+		})).
 		Complete(r)
 }
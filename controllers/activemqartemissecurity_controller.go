@@ -32,11 +32,14 @@ import (
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 // ActiveMQArtemisSecurityReconciler reconciles a ActiveMQArtemisSecurity object
@@ -93,11 +96,31 @@ func (r *ActiveMQArtemisSecurityReconciler) Reconcile(ctx context.Context, reque
 		return ctrl.Result{RequeueAfter: common.GetReconcileResyncPeriod()}, nil
 	}
 
+	condition := validateSecurity(instance)
+	var ldapPasswords map[string]string
+	if condition == nil {
+		ldapPasswords, condition = validateSecurityResources(instance, r.Client)
+	}
+	if condition != nil {
+		// the brokers keep the last valid config
+		reqLogger.V(1).Info("invalid security CR", "reason", condition.Message)
+		condition.ObservedGeneration = instance.Generation
+		meta.SetStatusCondition(&instance.Status.Conditions, *condition)
+		return ctrl.Result{}, r.updateStatus(instance)
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               brokerv1beta1.ValidConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             brokerv1beta1.ValidConditionSuccessReason,
+		ObservedGeneration: instance.Generation,
+	})
+
 	toReconcile := true
 	newHandler := &ActiveMQArtemisSecurityConfigHandler{
-		instance,
-		request.NamespacedName,
-		r,
+		SecurityCR:     instance,
+		NamespacedName: request.NamespacedName,
+		owner:          r,
+		ldapPasswords:  ldapPasswords,
 	}
 
 	if securityHandler := GetBrokerConfigHandler(request.NamespacedName); securityHandler == nil {
@@ -109,11 +132,11 @@ func (r *ActiveMQArtemisSecurityReconciler) Reconcile(ctx context.Context, reque
 				toReconcile = false
 			}
 		}
-	} else {
-		if reflect.DeepEqual(securityHandler, newHandler) {
-			reqLogger.V(1).Info("Will not reconcile the same security config")
-			return ctrl.Result{RequeueAfter: common.GetReconcileResyncPeriod()}, nil
-		}
+	} else if existingHandler, ok := namespaceToConfigHandler[request.NamespacedName].(*ActiveMQArtemisSecurityConfigHandler); ok && reflect.DeepEqual(existingHandler.SecurityCR.Spec, instance.Spec) && reflect.DeepEqual(existingHandler.ldapPasswords, ldapPasswords) {
+		// a status update doesn't change the config of the brokers, a new bind password does
+		reqLogger.V(1).Info("Will not reconcile the same security config")
+		existingHandler.SecurityCR = instance
+		return r.reconcileStatus(existingHandler, reqLogger)
 	}
 
 	if err := r.BrokerReconciler.AddBrokerConfigHandler(request.NamespacedName, newHandler, toReconcile); err != nil {
@@ -126,26 +149,51 @@ func (r *ActiveMQArtemisSecurityReconciler) Reconcile(ctx context.Context, reque
 		reqLogger.Error(merr, "failed to marshal cr")
 	}
 
-	instanceWithPasswords := newHandler.processCrPasswords()
+	instanceWithPasswords, maskedLdapPasswords, err := newHandler.processCrMaskedPasswords(ldapPasswords)
+	if err != nil {
+		reqLogger.Error(err, "failed to mask the passwords of the cr")
+		return ctrl.Result{}, err
+	}
 
 	// remove superfluous data that can trip up the shell
 	instanceWithPasswords.ObjectMeta = metav1.ObjectMeta{}
 
-	data, err := yaml.Marshal(instanceWithPasswords)
+	data, err := yaml.Marshal(withoutOperatorLoginModules(instanceWithPasswords))
 	if err != nil {
 		reqLogger.Error(merr, "failed to marshal cr with passwords")
 	}
 
-	lsrcrs.StoreLastSuccessfulReconciledCR(instance, instance.Name, instance.Namespace, "security",
-		crstr, string(data), instance.ResourceVersion, getLabels(instance), r.Client, r.Scheme)
+	lsrcrs.StoreLastSuccessfulReconciledCRWithFiles(instance, instance.Name, instance.Namespace, "security",
+		crstr, string(data), instance.ResourceVersion, renderOperatorLoginModules(instance, maskedLdapPasswords), getLabels(instance), r.Client, r.Scheme)
 
+	return r.reconcileStatus(newHandler, reqLogger)
+}
+
+// reconcileStatus reports the brokers that run the config, the reconcile is
+// requeued to follow the rollout
+func (r *ActiveMQArtemisSecurityReconciler) reconcileStatus(handler *ActiveMQArtemisSecurityConfigHandler, reqLogger logr.Logger) (ctrl.Result, error) {
+	if err := r.updateSecurityStatus(handler, reqLogger); err != nil {
+		reqLogger.Error(err, "unable to check the brokers of the security CR")
+		return ctrl.Result{}, err
+	}
+	if err := r.updateStatus(handler.SecurityCR); err != nil {
+		reqLogger.V(1).Info("unable to update status", "error", err)
+		return ctrl.Result{}, err
+	}
 	return ctrl.Result{RequeueAfter: common.GetReconcileResyncPeriod()}, nil
 }
 
+func (r *ActiveMQArtemisSecurityReconciler) updateStatus(desired *brokerv1beta1.ActiveMQArtemisSecurity) error {
+	common.SetReadyCondition(&desired.Status.Conditions)
+	return resources.UpdateStatus(r.Client, desired)
+}
+
 type ActiveMQArtemisSecurityConfigHandler struct {
 	SecurityCR     *brokerv1beta1.ActiveMQArtemisSecurity
 	NamespacedName types.NamespacedName
 	owner          *ActiveMQArtemisSecurityReconciler
+	// the bind passwords the login config was rendered with
+	ldapPasswords map[string]string
 }
 
 func getLabels(cr *brokerv1beta1.ActiveMQArtemisSecurity) map[string]string {
@@ -200,7 +248,8 @@ func (r *ActiveMQArtemisSecurityConfigHandler) processCrPasswords() *brokerv1bet
 	}
 
 	if len(result.Spec.LoginModules.KeycloakLoginModules) > 0 {
-		for _, pm := range result.Spec.LoginModules.KeycloakLoginModules {
+		for index := range result.Spec.LoginModules.KeycloakLoginModules {
+			pm := &result.Spec.LoginModules.KeycloakLoginModules[index]
 			keycloakSecretName := "security-keycloak-" + pm.Name
 			if pm.Configuration.ClientKeyStore != nil {
 				if pm.Configuration.ClientKeyPassword == nil {
@@ -237,6 +286,10 @@ func (r *ActiveMQArtemisSecurityConfigHandler) GetDefaultLabels() map[string]str
 
 // retrive value from secret, generate value if not exist.
 func (r *ActiveMQArtemisSecurityConfigHandler) getPassword(secretName string, key string) *string {
+	return r.getGeneratedValue(secretName, key, 8)
+}
+
+func (r *ActiveMQArtemisSecurityConfigHandler) getGeneratedValue(secretName string, key string, length int) *string {
 	//check if the secret exists.
 	namespacedName := types.NamespacedName{
 		Name:      secretName,
@@ -262,7 +315,7 @@ func (r *ActiveMQArtemisSecurityConfigHandler) getPassword(secretName string, ke
 		}
 	}
 	//now need generate value
-	value := random.GenerateRandomString(8)
+	value := random.GenerateRandomString(length)
 	//update the secret
 	if secretDefinition.Data == nil {
 		secretDefinition.Data = make(map[string][]byte)
@@ -277,6 +330,14 @@ func (r *ActiveMQArtemisSecurityConfigHandler) getPassword(secretName string, ke
 
 func (r *ActiveMQArtemisSecurityConfigHandler) Config(initContainers []corev1.Container, outputDirRoot string, yacfgProfileVersion string, yacfgProfileName string) (value []string) {
 	r.owner.log.V(1).Info("Reconciling", "cr", r.SecurityCR.Name)
+	if deliveredBySecrets(r.SecurityCR) {
+		// the broker reconciler renders the config, the brokers only restart for a new login.config or new resource limits
+		environments.Create(initContainers, &corev1.EnvVar{
+			Name:  securityConfigChecksumEnvVar,
+			Value: securityRestartChecksum(r.SecurityCR, r.owner.Client),
+		})
+		return nil
+	}
 	outputDir := outputDirRoot + "/security"
 	var configCmds = []string{"echo \"making dir " + outputDir + "\"", "mkdir -p " + outputDir}
 	filePath := outputDir + "/security-config.yaml"
@@ -285,6 +346,7 @@ func (r *ActiveMQArtemisSecurityConfigHandler) Config(initContainers []corev1.Co
 	r.owner.log.V(2).Info("get the command", "value", cmdPersistCRAsYaml)
 	configCmds = append(configCmds, cmdPersistCRAsYaml)
 	configCmds = append(configCmds, "/opt/amq-broker/script/cfg/config-security.sh")
+	configCmds = append(configCmds, operatorLoginConfigCommands(r.SecurityCR, "/etc/"+securitySecretVolumeName, brokerConfigRoot+"/etc")...)
 	envVarName := "SECURITY_CFG_YAML"
 	envVar := corev1.EnvVar{
 		Name:      envVarName,
@@ -313,9 +375,42 @@ func (r *ActiveMQArtemisSecurityConfigHandler) Config(initContainers []corev1.Co
 	return configCmds
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// SetupWithManager sets up the controller with the Manager. A change to a
+// secret a security CR refers to is validated and applied like a change to
+// the CR.
 func (r *ActiveMQArtemisSecurityReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&brokerv1beta1.ActiveMQArtemisSecurity{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.securityRequestsForSecret)).
 		Complete(r)
 }
+
+func (r *ActiveMQArtemisSecurityReconciler) securityRequestsForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	list := &brokerv1beta1.ActiveMQArtemisSecurityList{}
+	if err := r.Client.List(ctx, list, client.InNamespace(secret.GetNamespace())); err != nil {
+		r.log.V(1).Info("unable to list the security CRs of a secret", "secret", secret.GetName(), "error", err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for index := range list.Items {
+		if containsString(securityReferencedSecrets(&list.Items[index]), secret.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: secret.GetNamespace(), Name: list.Items[index].Name}})
+		}
+	}
+	return requests
+}
+
+// securityReferencedSecrets are the secrets the config of the CR is read from
+func securityReferencedSecrets(instance *brokerv1beta1.ActiveMQArtemisSecurity) []string {
+	names := []string{}
+	for _, module := range instance.Spec.LoginModules.LdapLoginModules {
+		names = append(names, module.BindPasswordSecret, module.TrustSecret)
+	}
+	for _, module := range instance.Spec.LoginModules.CertificateLoginModules {
+		names = append(names, module.MappingsSecret)
+	}
+	if instance.Spec.PasswordMasking != nil {
+		names = append(names, getPasswordCodecKeySecretName(instance))
+	}
+	return names
+}
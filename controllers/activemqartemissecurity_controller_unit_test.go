@@ -0,0 +1,866 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blowfish"
+	"golang.org/x/crypto/pbkdf2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newSecurityTestCr() *brokerv1beta1.ActiveMQArtemisSecurity {
+	moduleName := "prop-module"
+	flag := "sufficient"
+	return &brokerv1beta1.ActiveMQArtemisSecurity{
+		ObjectMeta: metav1.ObjectMeta{Name: "sec", Namespace: "test", Generation: 3},
+		Spec: brokerv1beta1.ActiveMQArtemisSecuritySpec{
+			LoginModules: brokerv1beta1.LoginModulesType{
+				PropertiesLoginModules: []brokerv1beta1.PropertiesLoginModuleType{
+					{Name: moduleName, Users: []brokerv1beta1.UserType{{Name: "morty", Roles: []string{"admin"}}}},
+				},
+			},
+			SecurityDomains: brokerv1beta1.SecurityDomainsType{
+				BrokerDomain: brokerv1beta1.BrokerDomainType{
+					LoginModules: []brokerv1beta1.LoginModuleReferenceType{{Name: &moduleName, Flag: &flag}},
+				},
+			},
+			SecuritySettings: brokerv1beta1.SecuritySettingsType{
+				Broker: []brokerv1beta1.BrokerSecuritySettingType{
+					{Match: "#", Permissions: []brokerv1beta1.PermissionType{{OperationType: "send", Roles: []string{"admin"}}}},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateSecurity(t *testing.T) {
+	cr := newSecurityTestCr()
+	assert.Nil(t, validateSecurity(cr))
+
+	cr.Spec.LoginModules.GuestLoginModules = []brokerv1beta1.GuestLoginModuleType{{Name: "prop-module"}}
+	condition := validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidLoginModuleReason, condition.Reason)
+		assert.Equal(t, "login module prop-module is defined more than once", condition.Message)
+	}
+
+	cr = newSecurityTestCr()
+	cr.Spec.LoginModules.PropertiesLoginModules[0].Users = append(cr.Spec.LoginModules.PropertiesLoginModules[0].Users, brokerv1beta1.UserType{Name: "morty"})
+	condition = validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "user morty of login module prop-module is defined more than once", condition.Message)
+	}
+
+	cr = newSecurityTestCr()
+	missing := "ldap-module"
+	cr.Spec.SecurityDomains.ConsoleDomain.LoginModules = []brokerv1beta1.LoginModuleReferenceType{{Name: &missing}}
+	condition = validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidSecurityDomainReason, condition.Reason)
+		assert.Equal(t, "consoleDomain refers to login module \"ldap-module\" that is not defined", condition.Message)
+	}
+
+	cr = newSecurityTestCr()
+	flag := "mandatory"
+	cr.Spec.SecurityDomains.BrokerDomain.LoginModules[0].Flag = &flag
+	condition = validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Contains(t, condition.Message, "flag mandatory is not one of")
+	}
+
+	cr = newSecurityTestCr()
+	cr.Spec.SecuritySettings.Broker[0].Permissions[0].OperationType = "write"
+	condition = validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Contains(t, condition.Message, "security setting # operationType write is not one of")
+	}
+}
+
+func TestSecurityConfigChecksum(t *testing.T) {
+	cr := newLdapSecurityTestCr()
+	bind := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ldap-bind", Namespace: "test"},
+		Data:       map[string][]byte{ldapBindPasswordKey: []byte("secret")},
+	}
+	client := newTestClient(bind)
+	checksum := securityConfigChecksum(cr, client)
+	assert.NotEmpty(t, checksum)
+
+	// the status and the metadata are not part of the config
+	cr.ResourceVersion = "10"
+	cr.Status.MatchedBrokers = []string{"ex-aao"}
+	assert.Equal(t, checksum, securityConfigChecksum(cr, client))
+
+	// the login config holds the bind password
+	bind.Data[ldapBindPasswordKey] = []byte("changed")
+	assert.NoError(t, client.Update(context.TODO(), bind))
+	assert.NotEqual(t, checksum, securityConfigChecksum(cr, client))
+
+	checksum = securityConfigChecksum(cr, client)
+	cr.Spec.LoginModules.PropertiesLoginModules[0].Users[0].Roles = []string{"admin", "viewer"}
+	assert.NotEqual(t, checksum, securityConfigChecksum(cr, client))
+}
+
+func TestSecurityBrokerStatuses(t *testing.T) {
+	cr := newSecurityTestCr()
+	changed := metav1.Now()
+	broker := newTestBroker("ex-aao", 3)
+
+	// the init container only renders the config when the broker starts
+	stale := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ex-aao-ss-0", Namespace: "test"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "ex-aao-container-init"}},
+			Containers:     []corev1.Container{{Name: "ex-aao-container"}},
+		},
+		Status: corev1.PodStatus{StartTime: &metav1.Time{Time: changed.Add(-time.Minute)}},
+	}
+	failed := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ex-aao-ss-1", Namespace: "test"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "ex-aao-container-init"}},
+			Containers:     []corev1.Container{{Name: "ex-aao-container"}},
+		},
+		Status: corev1.PodStatus{
+			StartTime: &metav1.Time{Time: changed.Add(time.Minute)},
+			InitContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "ex-aao-container-init",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "invalid yaml"}},
+			}},
+		},
+	}
+	client := newTestClient(broker, stale, failed)
+
+	runsConfig := func(pod *corev1.Pod) bool {
+		return pod.Status.StartTime != nil && !pod.Status.StartTime.Before(&changed)
+	}
+	statuses := securityBrokerStatuses(broker, cr, runsConfig, client, ctrl.Log)
+
+	if assert.Len(t, statuses, 3) {
+		assert.Equal(t, brokerv1beta1.SecurityBrokerPending, statuses[0].Result)
+		assert.Equal(t, "waiting for the broker to restart with the security config", statuses[0].Message)
+		assert.Equal(t, brokerv1beta1.SecurityBrokerFailed, statuses[1].Result)
+		assert.Equal(t, "the init container failed to generate the config, exit code 1 invalid yaml", statuses[1].Message)
+		assert.Equal(t, "2", statuses[2].Ordinal)
+		assert.Equal(t, brokerv1beta1.SecurityBrokerPending, statuses[2].Result)
+		assert.Equal(t, "waiting for the broker pod", statuses[2].Message)
+	}
+}
+
+func TestSecurityConfigChangeTime(t *testing.T) {
+	cr := newSecurityTestCr()
+	cr.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	client := newTestClient(cr)
+	reconciler := NewActiveMQArtemisSecurityReconciler(client, nil, nil, ctrl.Log)
+	handler := &ActiveMQArtemisSecurityConfigHandler{SecurityCR: cr, NamespacedName: types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}, owner: reconciler}
+
+	// the config of a CR seen for the first time is the one it was created with
+	assert.NoError(t, reconciler.updateSecurityStatus(handler, ctrl.Log))
+	assert.Equal(t, securityConfigChecksum(cr, client), cr.Status.ConfigChecksum)
+	assert.Equal(t, cr.CreationTimestamp, *cr.Status.ConfigChangeTime)
+
+	assert.NoError(t, reconciler.updateSecurityStatus(handler, ctrl.Log))
+	assert.Equal(t, cr.CreationTimestamp, *cr.Status.ConfigChangeTime)
+
+	cr.Spec.LoginModules.PropertiesLoginModules[0].Users[0].Roles = []string{"admin", "viewer"}
+	assert.NoError(t, reconciler.updateSecurityStatus(handler, ctrl.Log))
+	assert.Equal(t, securityConfigChecksum(cr, client), cr.Status.ConfigChecksum)
+	assert.True(t, cr.CreationTimestamp.Before(cr.Status.ConfigChangeTime))
+}
+
+func TestSecurityRequestsForSecret(t *testing.T) {
+	ldap := newLdapSecurityTestCr()
+	other := newSecurityTestCr()
+	other.Name = "other"
+	reconciler := NewActiveMQArtemisSecurityReconciler(newTestClient(ldap, other), nil, nil, ctrl.Log)
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "ldap-bind", Namespace: "test"}}
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "test", Name: ldap.Name}}}, reconciler.securityRequestsForSecret(context.TODO(), secret))
+
+	secret.Name = "ldap-trust"
+	assert.Len(t, reconciler.securityRequestsForSecret(context.TODO(), secret), 1)
+
+	secret.Namespace = "other"
+	assert.Empty(t, reconciler.securityRequestsForSecret(context.TODO(), secret))
+
+	secret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "test"}}
+	assert.Empty(t, reconciler.securityRequestsForSecret(context.TODO(), secret))
+}
+
+func TestSecurityAppliedCondition(t *testing.T) {
+	cr := newSecurityTestCr()
+	setSecurityAppliedCondition(cr, nil)
+	condition := meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.SecurityAppliedConditionType)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.SecurityAppliedConditionNoBrokersReason, condition.Reason)
+	}
+
+	cr.Status.MatchedBrokers = []string{"ex-aao"}
+	cr.Status.Brokers = []brokerv1beta1.SecurityBrokerStatus{
+		{CrName: "ex-aao", Ordinal: "0", Result: brokerv1beta1.SecurityBrokerApplied},
+		{CrName: "ex-aao", Ordinal: "1", Result: brokerv1beta1.SecurityBrokerPending},
+	}
+	setSecurityAppliedCondition(cr, nil)
+	condition = meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.SecurityAppliedConditionType)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, brokerv1beta1.SecurityAppliedConditionPendingReason, condition.Reason)
+		assert.Equal(t, "applied to 1 of 2 brokers, pending on 1", condition.Message)
+		assert.Equal(t, int64(3), condition.ObservedGeneration)
+	}
+
+	cr.Status.Brokers[1].Result = brokerv1beta1.SecurityBrokerFailed
+	setSecurityAppliedCondition(cr, nil)
+	condition = meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.SecurityAppliedConditionType)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.SecurityAppliedConditionFailedReason, condition.Reason)
+		assert.Equal(t, "applied to 1 of 2 brokers, failed on ex-aao-1", condition.Message)
+	}
+
+	setSecurityAppliedCondition(cr, []string{"other uses other-sec"})
+	condition = meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.SecurityAppliedConditionType)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.SecurityAppliedConditionConflictReason, condition.Reason)
+	}
+
+	cr.Status.Brokers[1].Result = brokerv1beta1.SecurityBrokerApplied
+	setSecurityAppliedCondition(cr, nil)
+	assert.True(t, meta.IsStatusConditionTrue(cr.Status.Conditions, brokerv1beta1.SecurityAppliedConditionType))
+}
+
+func newLdapSecurityTestCr() *brokerv1beta1.ActiveMQArtemisSecurity {
+	cr := newSecurityTestCr()
+	moduleName := "ldap-module"
+	flag := "required"
+	consoleRealm := "console"
+	subtree := true
+	cr.Spec.LoginModules.LdapLoginModules = []brokerv1beta1.LdapLoginModuleType{{
+		Name:               moduleName,
+		ConnectionURL:      "ldaps://ldap.example.com:636",
+		BindDN:             "cn=admin,dc=example,dc=com",
+		BindPasswordSecret: "ldap-bind",
+		UserBase:           "ou=users,dc=example,dc=com",
+		UserSearchMatching: "(uid={0})",
+		UserSearchSubtree:  &subtree,
+		RoleBase:           "ou=groups,dc=example,dc=com",
+		RoleName:           "cn",
+		RoleSearchMatching: "(member={0})",
+		TrustSecret:        "ldap-trust",
+	}}
+	cr.Spec.SecurityDomains.BrokerDomain.LoginModules = append(cr.Spec.SecurityDomains.BrokerDomain.LoginModules, brokerv1beta1.LoginModuleReferenceType{Name: &moduleName, Flag: &flag})
+	cr.Spec.SecurityDomains.ConsoleDomain = brokerv1beta1.BrokerDomainType{
+		Name:         &consoleRealm,
+		LoginModules: []brokerv1beta1.LoginModuleReferenceType{{Name: &moduleName, Flag: &flag}},
+	}
+	return cr
+}
+
+func TestValidateLdapLoginModules(t *testing.T) {
+	cr := newLdapSecurityTestCr()
+	assert.Nil(t, validateSecurity(cr))
+
+	cr.Spec.LoginModules.LdapLoginModules[0].ConnectionURL = "http://ldap.example.com"
+	condition := validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidLoginModuleReason, condition.Reason)
+		assert.Equal(t, "LDAP login module ldap-module requires an ldap:// or ldaps:// connectionURL", condition.Message)
+	}
+
+	cr = newLdapSecurityTestCr()
+	cr.Spec.LoginModules.LdapLoginModules[0].ConnectionURL = "ldap://ldap.example.com"
+	condition = validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "LDAP login module ldap-module has a trustSecret without an ldaps:// connectionURL", condition.Message)
+	}
+
+	cr = newLdapSecurityTestCr()
+	cr.Spec.LoginModules.LdapLoginModules = append(cr.Spec.LoginModules.LdapLoginModules, cr.Spec.LoginModules.LdapLoginModules[0])
+	cr.Spec.LoginModules.LdapLoginModules[1].Name = "other-ldap"
+	cr.Spec.LoginModules.LdapLoginModules[1].TrustSecret = "other-trust"
+	condition = validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "LDAP login module other-ldap trustSecret differs from the one of the other LDAP login modules", condition.Message)
+	}
+
+	cr = newLdapSecurityTestCr()
+	cr.Spec.SecurityDomains.ConsoleDomain.Name = nil
+	condition = validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidSecurityDomainReason, condition.Reason)
+		assert.Equal(t, "consoleDomain requires a name to use an LDAP login module", condition.Message)
+	}
+}
+
+func TestRenderLdapLoginModules(t *testing.T) {
+	cr := newLdapSecurityTestCr()
+	client := newTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ldap-bind", Namespace: "test"},
+		Data:       map[string][]byte{"password": []byte(`se"cret`)},
+	})
+
+	passwords, condition := validateSecurityResources(cr, client)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionMissingResourcesReason, condition.Reason)
+		assert.Contains(t, condition.Message, "unable to find the LDAP trust secret ldap-trust")
+	}
+	cr.Spec.LoginModules.LdapLoginModules[0].TrustSecret = ""
+	passwords, condition = validateSecurityResources(cr, client)
+	assert.Nil(t, condition)
+
+	files := renderOperatorLoginModules(cr, passwords)
+	assert.Len(t, files, 2)
+	expected := `    org.apache.activemq.artemis.spi.core.security.jaas.LDAPLoginModule required
+        initialContextFactory=com.sun.jndi.ldap.LdapCtxFactory
+        connectionURL="ldaps://ldap.example.com:636"
+        authentication=simple
+        connectionUsername="cn=admin,dc=example,dc=com"
+        connectionPassword="se\"cret"
+        userBase="ou=users,dc=example,dc=com"
+        userSearchMatching="(uid={0})"
+        userSearchSubtree=true
+        roleBase="ou=groups,dc=example,dc=com"
+        roleName="cn"
+        roleSearchMatching="(member={0})";
+`
+	assert.Equal(t, expected, files["login-modules-activemq.config"])
+	assert.Equal(t, expected, files["login-modules-console.config"])
+
+	// the entries are added after config-security.sh generates login.config
+	commands := operatorLoginConfigCommands(cr, "/etc/secret-security-sec-volume", "/amq/init/config/etc")
+	if assert.Len(t, commands, 2) {
+		assert.Contains(t, commands[0], `-v realm="activemq" -v entries="/etc/secret-security-sec-volume/login-modules-activemq.config"`)
+		assert.Contains(t, commands[1], `-v realm="console"`)
+	}
+
+	// yacfg still renders the properties login module
+	stripped := withoutOperatorLoginModules(cr)
+	assert.Empty(t, stripped.Spec.LoginModules.LdapLoginModules)
+	assert.Len(t, stripped.Spec.SecurityDomains.BrokerDomain.LoginModules, 1)
+	assert.Equal(t, "prop-module", *stripped.Spec.SecurityDomains.BrokerDomain.LoginModules[0].Name)
+	assert.Empty(t, stripped.Spec.SecurityDomains.ConsoleDomain.LoginModules)
+	assert.Len(t, cr.Spec.SecurityDomains.BrokerDomain.LoginModules, 2)
+}
+
+func TestResolveSecurityTrust(t *testing.T) {
+	cr := newLdapSecurityTestCr()
+	client := newTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ldap-trust", Namespace: "test"},
+		Data:       map[string][]byte{"client.ts": []byte("store"), "trustStorePassword": []byte("changeme")},
+	})
+
+	resolved, condition := resolveSecuritySecrets(cr, client)
+	assert.Nil(t, condition)
+	if assert.NotNil(t, resolved) {
+		assert.Equal(t, []string{"ldap-trust"}, resolved.secrets)
+		// the password stays in the secret and the JVM keeps its default trust store
+		assert.Equal(t, "-Dldap.trustStorePath=/etc/ldap-trust-volume/client.ts -Dldap.trustStorePassword=${LDAP_TRUST_STORE_PASSWORD}", resolved.javaArgs)
+		assert.NotContains(t, resolved.javaArgs, "changeme")
+		assert.Equal(t, []corev1.EnvVar{{
+			Name: "LDAP_TRUST_STORE_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "ldap-trust"},
+				Key:                  "trustStorePassword",
+			}},
+		}}, resolved.env)
+	}
+
+	// the login modules refer to the trust store
+	ldap := renderLdapLoginModule(&cr.Spec.LoginModules.LdapLoginModules[0], "required", false, "")
+	assert.Contains(t, ldap, `java.naming.ldap.factory.socket="org.apache.activemq.artemis.spi.core.security.jaas.LDAPLoginSSLSocketFactory"`)
+	assert.Contains(t, ldap, `trustStorePath="${ldap.trustStorePath}"`)
+	assert.Contains(t, ldap, `trustStorePassword="${ldap.trustStorePassword}";`)
+
+	cr.Spec.LoginModules.LdapLoginModules[0].TrustSecret = ""
+	resolved, condition = resolveSecuritySecrets(cr, client)
+	assert.Nil(t, condition)
+	assert.Nil(t, resolved)
+}
+
+func newCertificateSecurityTestCr() *brokerv1beta1.ActiveMQArtemisSecurity {
+	cr := newSecurityTestCr()
+	moduleName := "cert-module"
+	cr.Spec.LoginModules.CertificateLoginModules = []brokerv1beta1.CertificateLoginModuleType{{
+		Name: moduleName,
+		Users: []brokerv1beta1.CertificateUserType{
+			{Name: "app", SubjectDN: "CN=app, O=Example", Roles: []string{"producers", "consumers"}},
+			{Name: "any client", SubjectDNRegex: "CN=.*, O=Clients", Roles: []string{"consumers"}},
+		},
+	}}
+	flag := "sufficient"
+	cr.Spec.SecurityDomains.BrokerDomain.LoginModules = append([]brokerv1beta1.LoginModuleReferenceType{{Name: &moduleName, Flag: &flag}}, cr.Spec.SecurityDomains.BrokerDomain.LoginModules...)
+	return cr
+}
+
+func TestValidateCertificateLoginModules(t *testing.T) {
+	cr := newCertificateSecurityTestCr()
+	assert.Nil(t, validateSecurity(cr))
+
+	cr.Spec.LoginModules.CertificateLoginModules[0].MappingsSecret = "cert-mappings"
+	condition := validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidLoginModuleReason, condition.Reason)
+		assert.Equal(t, "certificate login module cert-module can't have both users and a mappingsSecret", condition.Message)
+	}
+
+	cr = newCertificateSecurityTestCr()
+	cr.Spec.LoginModules.CertificateLoginModules[0].Users[1].SubjectDN = "CN=other"
+	condition = validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "certificate login module cert-module user any client requires either a subjectDN or a subjectDNRegex", condition.Message)
+	}
+
+	cr = newCertificateSecurityTestCr()
+	consoleName := "console"
+	cr.Spec.SecurityDomains.ConsoleDomain = brokerv1beta1.BrokerDomainType{
+		Name:         &consoleName,
+		LoginModules: []brokerv1beta1.LoginModuleReferenceType{{Name: &cr.Spec.LoginModules.CertificateLoginModules[0].Name}},
+	}
+	condition = validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidSecurityDomainReason, condition.Reason)
+		assert.Equal(t, "certificate login module cert-module can only be used by the brokerDomain", condition.Message)
+	}
+}
+
+func TestRenderCertificateLoginModules(t *testing.T) {
+	cr := newCertificateSecurityTestCr()
+
+	files := renderOperatorLoginModules(cr, nil)
+	assert.Len(t, files, 3)
+	assert.Equal(t, `    org.apache.activemq.artemis.spi.core.security.jaas.TextFileCertificateLoginModule sufficient
+        reload=true
+        org.apache.activemq.jaas.textfiledn.user="cert-cert-module-users.properties"
+        org.apache.activemq.jaas.textfiledn.role="cert-cert-module-roles.properties";
+`, files["login-modules-activemq.config"])
+	assert.Equal(t, "app=CN=app, O=Example\nany\\ client=/CN=.*, O=Clients/\n", files["cert-cert-module-users.properties"])
+	assert.Equal(t, "consumers=app,any client\nproducers=app\n", files["cert-cert-module-roles.properties"])
+
+	// the inline files are copied before the entries are added
+	commands := operatorLoginConfigCommands(cr, "/etc/secret-security-sec-volume", "/amq/init/config/etc")
+	if assert.Len(t, commands, 3) {
+		assert.Equal(t, "cp /etc/secret-security-sec-volume/cert-cert-module-roles.properties /amq/init/config/etc/cert-cert-module-roles.properties", commands[0])
+		assert.Contains(t, commands[2], `-v realm="activemq"`)
+	}
+
+	// a mappings secret is mounted on the brokers
+	cr.Spec.LoginModules.CertificateLoginModules[0].Users = nil
+	cr.Spec.LoginModules.CertificateLoginModules[0].MappingsSecret = "cert-mappings"
+	client := newTestClient()
+	_, condition := validateSecurityResources(cr, client)
+	if assert.NotNil(t, condition) {
+		assert.Contains(t, condition.Message, "unable to find the mappings secret cert-mappings")
+	}
+	client = newTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cert-mappings", Namespace: "test"},
+		Data:       map[string][]byte{"users.properties": []byte("app=CN=app"), "roles.properties": []byte("producers=app")},
+	})
+	_, condition = validateSecurityResources(cr, client)
+	assert.Nil(t, condition)
+
+	files = renderOperatorLoginModules(cr, nil)
+	assert.Len(t, files, 1)
+	assert.Contains(t, files["login-modules-activemq.config"], `baseDir="/etc/cert-mappings-volume"`)
+	assert.Contains(t, files["login-modules-activemq.config"], `org.apache.activemq.jaas.textfiledn.user="users.properties"`)
+	resolved, _ := resolveSecuritySecrets(cr, client)
+	if assert.NotNil(t, resolved) {
+		assert.Equal(t, []string{"cert-mappings"}, resolved.secrets)
+		assert.Empty(t, resolved.javaArgs)
+	}
+
+	stripped := withoutOperatorLoginModules(cr)
+	assert.Empty(t, stripped.Spec.LoginModules.CertificateLoginModules)
+	assert.Len(t, stripped.Spec.SecurityDomains.BrokerDomain.LoginModules, 1)
+}
+
+func newSecretsDeliveryTestCr() *brokerv1beta1.ActiveMQArtemisSecurity {
+	cr := newSecurityTestCr()
+	mode := brokerv1beta1.SecurityDeliveryModes.Secrets
+	cr.Spec.DeliveryMode = &mode
+	password := "pickle"
+	cr.Spec.LoginModules.PropertiesLoginModules[0].Users[0].Password = &password
+	guestName, guestUser, guestRole := "guest-module", "guest", "viewers"
+	cr.Spec.LoginModules.GuestLoginModules = []brokerv1beta1.GuestLoginModuleType{{Name: guestName, GuestUser: &guestUser, GuestRole: &guestRole}}
+	consoleName := "console"
+	cr.Spec.SecurityDomains.ConsoleDomain = brokerv1beta1.BrokerDomainType{
+		Name:         &consoleName,
+		LoginModules: []brokerv1beta1.LoginModuleReferenceType{{Name: &guestName}},
+	}
+	cr.Spec.SecuritySettings.Broker = append(cr.Spec.SecuritySettings.Broker, brokerv1beta1.BrokerSecuritySettingType{
+		Match: "orders.#", Permissions: []brokerv1beta1.PermissionType{{OperationType: "consume", Roles: []string{"admin", "viewers"}}},
+	})
+	return cr
+}
+
+func TestValidateSecretsDelivery(t *testing.T) {
+	cr := newSecretsDeliveryTestCr()
+	assert.Nil(t, validateSecurity(cr))
+
+	hawtioRoles := []string{"admin"}
+	cr.Spec.SecuritySettings.Management.HawtioRoles = hawtioRoles
+	condition := validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidSecurityDomainReason, condition.Reason)
+		assert.Equal(t, "management security settings require the InitContainer delivery mode", condition.Message)
+	}
+
+	cr = newSecretsDeliveryTestCr()
+	brokerName := "other"
+	cr.Spec.SecurityDomains.BrokerDomain.Name = &brokerName
+	condition = validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "brokerDomain name has to be activemq, the realm of the brokers, with the Secrets delivery mode", condition.Message)
+	}
+
+	cr = newSecretsDeliveryTestCr()
+	cr.Spec.SecurityDomains.ConsoleDomain.Name = nil
+	condition = validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "consoleDomain requires a name other than activemq with the Secrets delivery mode", condition.Message)
+	}
+
+	cr = newSecretsDeliveryTestCr()
+	cr.Spec.LoginModules.KeycloakLoginModules = []brokerv1beta1.KeycloakLoginModuleType{{Name: "keycloak"}}
+	condition = validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, "keycloak login module keycloak moduleType has to be directAccess or bearerToken with the Secrets delivery mode", condition.Message)
+	}
+
+	// yacfg renders the management settings
+	cr = newSecretsDeliveryTestCr()
+	cr.Spec.DeliveryMode = nil
+	cr.Spec.SecuritySettings.Management.HawtioRoles = hawtioRoles
+	assert.Nil(t, validateSecurity(cr))
+}
+
+func TestRenderSecurityJaasConfig(t *testing.T) {
+	cr := newSecretsDeliveryTestCr()
+	moduleType, realm := "bearerToken", "artemis"
+	cr.Spec.LoginModules.KeycloakLoginModules = []brokerv1beta1.KeycloakLoginModuleType{{
+		Name:          "keycloak",
+		ModuleType:    &moduleType,
+		Configuration: brokerv1beta1.KeycloakModuleConfigurationType{Realm: &realm},
+	}}
+	keycloakName := "keycloak"
+	cr.Spec.SecurityDomains.BrokerDomain.LoginModules = append(cr.Spec.SecurityDomains.BrokerDomain.LoginModules, brokerv1beta1.LoginModuleReferenceType{Name: &keycloakName})
+
+	files := renderSecurityJaasConfig(cr, nil, "/amq/extra/secrets/br-security-jaas-config")
+	assert.Len(t, files, 4)
+	assert.Equal(t, `activemq {
+    org.apache.activemq.artemis.spi.core.security.jaas.PropertiesLoginModule sufficient
+        reload=true
+        org.apache.activemq.jaas.properties.user="artemis-users.properties"
+        org.apache.activemq.jaas.properties.role="artemis-roles.properties"
+        baseDir="/home/jboss/amq-broker/etc";
+    org.apache.activemq.artemis.spi.core.security.jaas.PropertiesLoginModule sufficient
+        reload=true
+        org.apache.activemq.jaas.properties.user="prop-module-users.properties"
+        org.apache.activemq.jaas.properties.role="prop-module-roles.properties";
+    org.keycloak.adapters.jaas.BearerTokenLoginModule required
+        keycloak-config-file="/amq/extra/secrets/br-security-jaas-config/_keycloak-keycloak.json"
+        role-principal-class=org.apache.activemq.artemis.spi.core.security.jaas.RolePrincipal;
+    org.apache.activemq.artemis.spi.core.security.jaas.PrincipalConversionLoginModule required
+        principalClassList=org.keycloak.KeycloakPrincipal;
+};
+console {
+    org.apache.activemq.artemis.spi.core.security.jaas.PropertiesLoginModule sufficient
+        reload=true
+        org.apache.activemq.jaas.properties.user="artemis-users.properties"
+        org.apache.activemq.jaas.properties.role="artemis-roles.properties"
+        baseDir="/home/jboss/amq-broker/etc";
+    org.apache.activemq.artemis.spi.core.security.jaas.GuestLoginModule required
+        org.apache.activemq.jaas.guest.user="guest"
+        org.apache.activemq.jaas.guest.role="viewers";
+};
+`, files["login.config"])
+	assert.Equal(t, "morty=pickle\n", files["prop-module-users.properties"])
+	assert.Equal(t, "admin=morty\n", files["prop-module-roles.properties"])
+	assert.Equal(t, "{\n  \"realm\": \"artemis\"\n}\n", files["_keycloak-keycloak.json"])
+
+	assert.Equal(t, []string{
+		"securityRoles.#.admin.send=true",
+		`securityRoles."orders.#".admin.consume=true`,
+		`securityRoles."orders.#".viewers.consume=true`,
+	}, securitySettingsProperties(cr))
+
+	// a new password is reloaded, a new login module restarts the brokers
+	client := newTestClient()
+	checksum := securityRestartChecksum(cr, client)
+	password := "rick"
+	cr.Spec.LoginModules.PropertiesLoginModules[0].Users[0].Password = &password
+	assert.Equal(t, checksum, securityRestartChecksum(cr, client))
+	cr.Spec.SecurityDomains.BrokerDomain.LoginModules = cr.Spec.SecurityDomains.BrokerDomain.LoginModules[:1]
+	assert.NotEqual(t, checksum, securityRestartChecksum(cr, client))
+}
+
+func TestSecurityRestartChecksumFollowsBindPassword(t *testing.T) {
+	cr := newLdapSecurityTestCr()
+	mode := brokerv1beta1.SecurityDeliveryModes.Secrets
+	cr.Spec.DeliveryMode = &mode
+	bind := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ldap-bind", Namespace: "test"},
+		Data:       map[string][]byte{ldapBindPasswordKey: []byte("secret")},
+	}
+	client := newTestClient(bind)
+	checksum := securityRestartChecksum(cr, client)
+
+	// login.config holds the bind password, the JVM only reads it when it starts
+	bind.Data[ldapBindPasswordKey] = []byte("changed")
+	assert.NoError(t, client.Update(context.TODO(), bind))
+	assert.NotEqual(t, checksum, securityRestartChecksum(cr, client))
+}
+
+func TestSecurityDeliverySecrets(t *testing.T) {
+	broker := newTestBroker("br", 1)
+	instance := newSecretsDeliveryTestCr()
+	client := newTestClient(broker, instance)
+	securityName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	namespaceToConfigHandler[securityName] = &ActiveMQArtemisSecurityConfigHandler{
+		SecurityCR:     instance,
+		NamespacedName: securityName,
+		owner:          NewActiveMQArtemisSecurityReconciler(client, nil, nil, ctrl.Log),
+	}
+	defer delete(namespaceToConfigHandler, securityName)
+
+	reconciler := NewActiveMQArtemisReconcilerImpl(broker, ctrl.Log.WithName("security_test"), nil)
+	reconciler.securityDelivery = resolveSecurityDeliveryFor(broker, client, reconciler.log)
+	if assert.NotNil(t, reconciler.securityDelivery) {
+		assert.Equal(t, "console", reconciler.securityDelivery.consoleRealm)
+		assert.Equal(t, "-Djava.security.auth.login.config=/amq/extra/secrets/br-security-jaas-config/login.config -Dhawtio.realm=console",
+			securityJaasConfigArgs(reconciler.securityDelivery, "br-security-jaas-config"))
+	}
+	jaasConfigName, propertiesName := reconciler.addResourceForSecurity(broker, *MakeNamers(broker))
+	assert.Equal(t, "br-security-jaas-config", jaasConfigName)
+	assert.Equal(t, "br-security-props", propertiesName)
+	jaasConfig := reconciler.requestedResources[reflect.TypeOf(&corev1.Secret{})][jaasConfigName].(*corev1.Secret)
+	assert.Equal(t, "morty=pickle\n", jaasConfig.StringData["prop-module-users.properties"])
+	properties := reconciler.requestedResources[reflect.TypeOf(&corev1.Secret{})][propertiesName].(*corev1.Secret)
+	assert.Contains(t, properties.StringData[SecurityPropertiesName], "securityRoles.#.admin.send=true\n")
+
+	name, found := getJaasConfigSecretName(broker)
+	assert.True(t, found)
+	assert.Equal(t, "br-security-jaas-config", name)
+
+	// the jaas config of the broker CR wins, the security settings still apply
+	broker.Spec.DeploymentPlan.ExtraMounts.Secrets = []string{"own-jaas-config"}
+	reconciler.securityDelivery = resolveSecurityDeliveryFor(broker, client, reconciler.log)
+	if assert.NotNil(t, reconciler.securityDelivery) {
+		assert.Nil(t, reconciler.securityDelivery.jaasConfig)
+		assert.Len(t, reconciler.securityDelivery.properties, 3)
+	}
+
+	// the yacfg mode renders nothing in the broker reconciler
+	instance.Spec.DeliveryMode = nil
+	assert.Nil(t, resolveSecurityDeliveryFor(broker, client, reconciler.log))
+}
+
+func TestDeliveredFilesStatus(t *testing.T) {
+	secretName := types.NamespacedName{Namespace: "test", Name: "br-security-jaas-config"}
+	client := newTestClient(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName.Name, Namespace: secretName.Namespace},
+		Data: map[string][]byte{
+			"login.config":                 []byte("activemq {};"),
+			"prop-module-users.properties": []byte("morty=pickle\n"),
+		},
+	})
+
+	// not visible before the first login
+	pending, failed := deliveredFilesStatus(secretName, map[string]propertiesStatus{}, client)
+	assert.Empty(t, pending)
+	assert.Empty(t, failed)
+
+	reported := map[string]propertiesStatus{"prop-module-users.properties": {Alder32: "1"}}
+	pending, failed = deliveredFilesStatus(secretName, reported, client)
+	assert.Equal(t, "waiting for the broker to reload prop-module-users.properties", pending)
+	assert.Empty(t, failed)
+
+	reported["prop-module-users.properties"] = propertiesStatus{Alder32: alder32FromData([]byte("morty=pickle\n"))}
+	pending, failed = deliveredFilesStatus(secretName, reported, client)
+	assert.Empty(t, pending)
+	assert.Empty(t, failed)
+
+	reported["prop-module-users.properties"] = propertiesStatus{ApplyErrors: []applyError{{PropKeyValue: "morty", Reason: "invalid"}}}
+	_, failed = deliveredFilesStatus(secretName, reported, client)
+	assert.Contains(t, failed, "prop-module-users.properties has errors")
+}
+
+func TestValidateResourceLimits(t *testing.T) {
+	cr := newSecurityTestCr()
+	connections := int32(10)
+	cr.Spec.ResourceLimits = []brokerv1beta1.ResourceLimitType{{User: "morty", MaxConnections: &connections}}
+	assert.Nil(t, validateSecurity(cr))
+
+	cr.Spec.ResourceLimits = append(cr.Spec.ResourceLimits, brokerv1beta1.ResourceLimitType{User: "morty", MaxConnections: &connections})
+	condition := validateSecurity(cr)
+	if assert.NotNil(t, condition) {
+		assert.Equal(t, brokerv1beta1.ValidConditionInvalidResourceLimitReason, condition.Reason)
+		assert.Equal(t, "user morty has more than one resource limit", condition.Message)
+	}
+
+	cr.Spec.ResourceLimits = []brokerv1beta1.ResourceLimitType{{User: "morty"}}
+	assert.Equal(t, "resource limit of user morty requires maxConnections or maxQueues", validateResourceLimits(cr))
+
+	queues := int32(-2)
+	cr.Spec.ResourceLimits = []brokerv1beta1.ResourceLimitType{{User: "morty", MaxQueues: &queues}}
+	assert.Equal(t, "resource limit of user morty maxQueues has to be -1 or more", validateResourceLimits(cr))
+
+	cr.Spec.ResourceLimits = []brokerv1beta1.ResourceLimitType{{MaxQueues: &connections}}
+	assert.Equal(t, "a resource limit requires a user", validateResourceLimits(cr))
+}
+
+func TestResourceLimitsDelivery(t *testing.T) {
+	broker := newTestBroker("br", 1)
+	instance := newSecurityTestCr()
+	connections := int32(10)
+	queues := int32(-1)
+	instance.Spec.ResourceLimits = []brokerv1beta1.ResourceLimitType{
+		{User: "morty", MaxConnections: &connections, MaxQueues: &queues},
+		{User: "tenant.a", MaxConnections: &connections},
+	}
+	assert.Equal(t, []string{
+		"resourceLimitSettings.morty.match=morty",
+		"resourceLimitSettings.morty.maxConnections=10",
+		"resourceLimitSettings.morty.maxQueues=-1",
+		`resourceLimitSettings."tenant.a".match=tenant.a`,
+		`resourceLimitSettings."tenant.a".maxConnections=10`,
+	}, resourceLimitProperties(instance))
+
+	client := newTestClient(broker, instance)
+	securityName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	namespaceToConfigHandler[securityName] = &ActiveMQArtemisSecurityConfigHandler{
+		SecurityCR:     instance,
+		NamespacedName: securityName,
+		owner:          NewActiveMQArtemisSecurityReconciler(client, nil, nil, ctrl.Log),
+	}
+	defer delete(namespaceToConfigHandler, securityName)
+
+	// the init container renders the rest, the resource limits are properties
+	reconciler := NewActiveMQArtemisReconcilerImpl(broker, ctrl.Log.WithName("security_test"), nil)
+	reconciler.securityDelivery = resolveSecurityDeliveryFor(broker, client, reconciler.log)
+	if assert.NotNil(t, reconciler.securityDelivery) {
+		assert.Nil(t, reconciler.securityDelivery.jaasConfig)
+		assert.Equal(t, resourceLimitProperties(instance), reconciler.securityDelivery.properties)
+	}
+	jaasConfigName, propertiesName := reconciler.addResourceForSecurity(broker, *MakeNamers(broker))
+	assert.Empty(t, jaasConfigName)
+	assert.Equal(t, "br-security-props", propertiesName)
+	properties := reconciler.requestedResources[reflect.TypeOf(&corev1.Secret{})][propertiesName].(*corev1.Secret)
+	assert.Contains(t, properties.StringData[SecurityPropertiesName], "resourceLimitSettings.morty.maxConnections=10\n")
+
+	// the brokers only read the limits when they start
+	secrets := newSecretsDeliveryTestCr()
+	checksum := securityRestartChecksum(secrets, client)
+	secrets.Spec.ResourceLimits = instance.Spec.ResourceLimits
+	assert.NotEqual(t, checksum, securityRestartChecksum(secrets, client))
+}
+
+func unmaskTestPassword(t *testing.T, masked string, key string) string {
+	value, ok := new(big.Int).SetString(strings.TrimSuffix(strings.TrimPrefix(masked, "ENC("), ")"), 16)
+	assert.True(t, ok)
+	length := (value.BitLen()/8/blowfish.BlockSize + 1) * blowfish.BlockSize
+	if value.Sign() < 0 {
+		value.Add(value, new(big.Int).Lsh(big.NewInt(1), uint(length*8)))
+	}
+	data := value.FillBytes(make([]byte, length))
+	cipher, err := blowfish.NewCipher([]byte(key))
+	assert.NoError(t, err)
+	for block := 0; block < len(data); block += blowfish.BlockSize {
+		cipher.Decrypt(data[block:block+blowfish.BlockSize], data[block:block+blowfish.BlockSize])
+	}
+	return string(data[:len(data)-int(data[len(data)-1])])
+}
+
+func TestMaskSecurityPasswords(t *testing.T) {
+	key := "a-key-of-the-codec"
+	cr := newSecurityTestCr()
+	password := "rick"
+	storePassword := "store-secret"
+	cr.Spec.LoginModules.PropertiesLoginModules[0].Users[0].Password = &password
+	cr.Spec.SecuritySettings.Management.Connector.KeyStorePassword = &storePassword
+	ldapPasswords := map[string]string{"ldap-module": "bind-secret"}
+
+	// nothing changes without masking
+	result, passwords, err := maskSecurityPasswords(cr, ldapPasswords, key)
+	assert.NoError(t, err)
+	assert.Same(t, cr, result)
+	assert.Equal(t, ldapPasswords, passwords)
+
+	cr.Spec.PasswordMasking = &brokerv1beta1.PasswordMaskingType{}
+	result, passwords, err = maskSecurityPasswords(cr, ldapPasswords, key)
+	assert.NoError(t, err)
+	assert.Equal(t, "rick", *cr.Spec.LoginModules.PropertiesLoginModules[0].Users[0].Password)
+
+	// the hash only changes with the password, the key and the user
+	hashed := *result.Spec.LoginModules.PropertiesLoginModules[0].Users[0].Password
+	assert.Regexp(t, "^ENC\\(1024:[0-9a-f]{64}:[0-9a-f]{128}\\)$", hashed)
+	assert.Equal(t, hashed, hashPassword("rick", key, "prop-module/morty"))
+	assert.NotEqual(t, hashed, hashPassword("rick", key, "prop-module/summer"))
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(hashed, "ENC("), ")"), ":")
+	salt, _ := hex.DecodeString(parts[1])
+	assert.Equal(t, parts[2], hex.EncodeToString(pbkdf2.Key([]byte("rick"), salt, 1024, 64, sha1.New)))
+
+	masked := *result.Spec.SecuritySettings.Management.Connector.KeyStorePassword
+	assert.True(t, isPasswordMasked(masked))
+	assert.Equal(t, "store-secret", unmaskTestPassword(t, masked, key))
+	assert.Nil(t, result.Spec.SecuritySettings.Management.Connector.TrustStorePassword)
+	assert.Equal(t, "bind-secret", unmaskTestPassword(t, passwords["ldap-module"], key))
+	assert.Equal(t, "bind-secret", ldapPasswords["ldap-module"])
+
+	// a masked password is kept, as are the passwords of a connector with its own codec
+	alreadyMasked := "ENC(1024:ab:cd)"
+	codec := "com.example.Codec"
+	cr.Spec.LoginModules.PropertiesLoginModules[0].Users[0].Password = &alreadyMasked
+	cr.Spec.SecuritySettings.Management.Connector.PasswordCodec = &codec
+	result, _, err = maskSecurityPasswords(cr, nil, key)
+	assert.NoError(t, err)
+	assert.Equal(t, alreadyMasked, *result.Spec.LoginModules.PropertiesLoginModules[0].Users[0].Password)
+	assert.Equal(t, "store-secret", *result.Spec.SecuritySettings.Management.Connector.KeyStorePassword)
+}
+
+func TestPasswordCodecKey(t *testing.T) {
+	cr := newSecurityTestCr()
+	assert.Equal(t, "sec-password-codec", getPasswordCodecKeySecretName(cr))
+	cr.Spec.PasswordMasking = &brokerv1beta1.PasswordMaskingType{KeySecret: "my-codec"}
+	assert.Equal(t, "my-codec", getPasswordCodecKeySecretName(cr))
+
+	// a missing key is generated, a key blowfish can't use is invalid
+	client := newTestClient(cr)
+	assert.NoError(t, checkPasswordCodecKey(cr, client))
+	keySecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-codec", Namespace: cr.Namespace},
+		Data:       map[string][]byte{passwordCodecKeyKey: []byte(strings.Repeat("k", 57))},
+	}
+	client = newTestClient(cr, keySecret)
+	assert.EqualError(t, checkPasswordCodecKey(cr, client), "the codec-key key of the password codec key secret my-codec has to be 1 to 56 bytes")
+
+	// the brokers get the key of the security CR that applies to them
+	broker := newTestBroker("br", 1)
+	assert.Nil(t, securityCodecKeyEnvVar(broker))
+	securityName := types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}
+	namespaceToConfigHandler[securityName] = &ActiveMQArtemisSecurityConfigHandler{
+		SecurityCR:     cr,
+		NamespacedName: securityName,
+		owner:          NewActiveMQArtemisSecurityReconciler(client, nil, nil, ctrl.Log),
+	}
+	defer delete(namespaceToConfigHandler, securityName)
+	envVar := securityCodecKeyEnvVar(broker)
+	if assert.NotNil(t, envVar) {
+		assert.Equal(t, "ARTEMIS_DEFAULT_SENSITIVE_STRING_CODEC_KEY", envVar.Name)
+		assert.Equal(t, "my-codec", envVar.ValueFrom.SecretKeyRef.Name)
+		assert.Equal(t, passwordCodecKeyKey, envVar.ValueFrom.SecretKeyRef.Key)
+	}
+}
@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	mgmt "github.com/artemiscloud/activemq-artemis-operator/pkg/utils/artemis"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia"
+	jc "github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia_client"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/lsrcrs"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// An address CR with removeFromBrokerOnDelete holds a finalizer, so that it
+// stays until its queues are removed from the brokers the way its delete
+// policy says, even when the brokers are not reachable at the time.
+
+const (
+	addressFinalizer            = "broker.amq.io/remove-from-brokers"
+	defaultAddressDeleteTimeout = 10 * time.Minute
+	// the pause before the delete policy is checked again
+	addressDeleteInterval = 10 * time.Second
+)
+
+func addressDeletePolicy(instance *brokerv1beta1.ActiveMQArtemisAddress) brokerv1beta1.AddressDeletePolicy {
+	if instance.Spec.DeletePolicy == nil || instance.Spec.DeletePolicy.Policy == "" {
+		return brokerv1beta1.AddressDeletePolicies.Force
+	}
+	return instance.Spec.DeletePolicy.Policy
+}
+
+func addressDeleteTimeout(instance *brokerv1beta1.ActiveMQArtemisAddress) time.Duration {
+	if instance.Spec.DeletePolicy == nil || instance.Spec.DeletePolicy.Timeout == nil {
+		return defaultAddressDeleteTimeout
+	}
+	return instance.Spec.DeletePolicy.Timeout.Duration
+}
+
+// addressDeleteTimedOut tells if the policy stopped waiting for the queues to
+// be empty. A move is bounded like a drain, it leaves the messages of an
+// address without a queue in the CR and producers may keep sending
+func addressDeleteTimedOut(instance *brokerv1beta1.ActiveMQArtemisAddress, deletion *brokerv1beta1.AddressDeletionStatus) bool {
+	policy := addressDeletePolicy(instance)
+	if policy != brokerv1beta1.AddressDeletePolicies.WaitForConsumersToDrain && policy != brokerv1beta1.AddressDeletePolicies.MoveMessagesTo {
+		return false
+	}
+	return time.Since(deletion.StartTime.Time) > addressDeleteTimeout(instance)
+}
+
+// syncAddressFinalizer has the CR hold the finalizer only when its queues are
+// removed on delete. It tells if the CR changed
+func syncAddressFinalizer(instance *brokerv1beta1.ActiveMQArtemisAddress) bool {
+	if instance.Spec.RemoveFromBrokerOnDelete {
+		return controllerutil.AddFinalizer(instance, addressFinalizer)
+	}
+	return controllerutil.RemoveFinalizer(instance, addressFinalizer)
+}
+
+// finalizeAddress removes the queues of a deleted CR from the brokers and then
+// releases the CR, the status tells what the removal waits for meanwhile
+func (r *ActiveMQArtemisAddressReconciler) finalizeAddress(instance *brokerv1beta1.ActiveMQArtemisAddress, request ctrl.Request, reqLogger logr.Logger) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(instance, addressFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	r.undeliver(request.NamespacedName, reqLogger)
+
+	deployment := AddressDeployment{
+		AddressResource:      *instance,
+		SsTargetNameBuilders: r.createNameBuilders(instance),
+	}
+	definitions, condition := addressDefinitions(instance, r.Client)
+	if condition != nil {
+		// the ConfigMap may be gone with the CR, the last applied definitions are removed then
+		previous, found := namespacedNameToAddressName[request.NamespacedName]
+		if !found {
+			reqLogger.Info("unable to know the queues to remove from the brokers", "reason", condition.Message)
+		}
+		definitions = previous.Definitions
+	}
+	deployment.Definitions = definitions
+
+	wait, err := r.removeFromBrokers(&deployment, request, r.Client)
+	if wait || err != nil {
+		instance.Status = deployment.AddressResource.Status
+		if statusErr := r.updateStatus(instance); statusErr != nil {
+			reqLogger.V(1).Info("unable to update status", "error", statusErr)
+		}
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: addressDeleteInterval}, nil
+	}
+
+	delete(namespacedNameToAddressName, request.NamespacedName)
+	lsrcrs.DeleteLastSuccessfulReconciledCR(request.NamespacedName, "address", getAddressLabels(instance), r.Client)
+	controllerutil.RemoveFinalizer(instance, addressFinalizer)
+	reqLogger.V(1).Info("Address resource removed from the brokers")
+	return ctrl.Result{}, r.Update(context.TODO(), instance)
+}
+
+// removeFromBrokers removes the definitions from all the target brokers once
+// the delete policy allows it. It tells if the removal has to wait
+func (r *ActiveMQArtemisAddressReconciler) removeFromBrokers(instance *AddressDeployment, request ctrl.Request, client rtclient.Client) (bool, error) {
+	addressRes := &instance.AddressResource
+	policy := addressDeletePolicy(addressRes)
+	status := &addressRes.Status
+	if status.Deletion == nil || status.Deletion.Policy != policy {
+		status.Deletion = &brokerv1beta1.AddressDeletionStatus{Policy: policy, StartTime: metav1.Now()}
+	}
+	deletion := status.Deletion
+	deletion.MessageCount, deletion.ConsumerCount = 0, 0
+	failed := func(err error) (bool, error) {
+		deletion.State = brokerv1beta1.AddressDeletionFailed
+		deletion.Message = err.Error()
+		return true, err
+	}
+
+	artemisArray, brokerCount, err := r.getPodBrokers(instance, request, client)
+	if err != nil {
+		// the target brokers are unknown, the finalizer stays
+		return failed(err)
+	}
+	if int32(len(artemisArray)) < brokerCount {
+		deletion.State = brokerv1beta1.AddressDeletionWaiting
+		deletion.Message = fmt.Sprintf("%d of %d brokers are reachable", len(artemisArray), brokerCount)
+		return true, nil
+	}
+
+	timedOut := addressDeleteTimedOut(addressRes, deletion)
+	waiting := []string{}
+	for _, definition := range instance.Definitions {
+		// Force removes the queues whatever they hold
+		if policy == brokerv1beta1.AddressDeletePolicies.Force {
+			break
+		}
+		for _, a := range artemisArray {
+			messages, consumers, found, err := queueUsage(a, definition)
+			if err != nil {
+				return failed(err)
+			}
+			if !found || messages == 0 {
+				continue
+			}
+			if policy == brokerv1beta1.AddressDeletePolicies.MoveMessagesTo && definition.Spec.QueueName != nil {
+				if messages, err = moveQueueMessages(a, definition, addressRes.Spec.DeletePolicy.MoveMessagesTo); err != nil {
+					return failed(err)
+				}
+			}
+			deletion.MessageCount += messages
+			deletion.ConsumerCount += consumers
+			if messages > 0 && !timedOut {
+				waiting = append(waiting, fmt.Sprintf("%s on %s-%s", addressEntryKey(definition), a.CrName, a.Ordinal))
+			}
+		}
+	}
+	if len(waiting) > 0 {
+		deletion.State = brokerv1beta1.AddressDeletionWaiting
+		deletion.Message = fmt.Sprintf("%d messages left in %s", deletion.MessageCount, strings.Join(waiting, ", "))
+		return true, nil
+	}
+
+	for _, definition := range instance.Definitions {
+		if err := r.deleteDefinition(definition, artemisArray, request); err != nil {
+			return failed(err)
+		}
+	}
+	return false, nil
+}
+
+// queueUsage reads the messages and consumers of the queue of a definition, or
+// the messages of an address without one. It tells if the queue is still there
+func queueUsage(a *jc.JkInfo, definition *brokerv1beta1.ActiveMQArtemisAddress) (int64, int64, bool, error) {
+	addressName := definition.Spec.AddressName
+	read := func(response *jolokia.ResponseData, err error) (int64, bool, error) {
+		if err != nil || response == nil {
+			if mgmt.IsInstanceNotFound(response) {
+				return 0, false, nil
+			}
+			return 0, false, fmt.Errorf("unable to read %s on %s-%s, %v", addressEntryKey(definition), a.CrName, a.Ordinal, err)
+		}
+		// jolokia decodes numbers as float64
+		count, err := strconv.ParseFloat(response.Value, 64)
+		return int64(count), true, err
+	}
+
+	if definition.Spec.QueueName == nil {
+		messages, found, err := read(a.Artemis.GetAddressAttribute(addressName, "MessageCount"))
+		return messages, 0, found, err
+	}
+	routingType := addressRoutingType(definition)
+	messages, found, err := read(a.Artemis.GetQueueAttribute(addressName, *definition.Spec.QueueName, routingType, "MessageCount"))
+	if err != nil || !found {
+		return 0, 0, found, err
+	}
+	consumers, found, err := read(a.Artemis.GetQueueAttribute(addressName, *definition.Spec.QueueName, routingType, "ConsumerCount"))
+	return messages, consumers, found, err
+}
+
+// moveQueueMessages moves the messages of the queue of a definition to the
+// target address, through one of its queues. It gives the messages left
+func moveQueueMessages(a *jc.JkInfo, definition *brokerv1beta1.ActiveMQArtemisAddress, target string) (int64, error) {
+	response, err := a.Artemis.GetAddressAttribute(target, "QueueNames")
+	if err != nil || response == nil {
+		return 0, fmt.Errorf("unable to find address %s on %s-%s to move the messages of %s to, %v", target, a.CrName, a.Ordinal, addressEntryKey(definition), err)
+	}
+	// the queue names come back as [name1 name2]
+	queueNames := strings.Fields(strings.Trim(response.Value, "[]"))
+	if len(queueNames) == 0 {
+		return 0, fmt.Errorf("address %s on %s-%s has no queue to move the messages of %s to", target, a.CrName, a.Ordinal, addressEntryKey(definition))
+	}
+
+	routingType := addressRoutingType(definition)
+	if response, err = a.Artemis.MoveMessages(definition.Spec.AddressName, *definition.Spec.QueueName, routingType, queueNames[0]); err != nil {
+		message := err.Error()
+		if response != nil && response.Error != "" {
+			message = response.Error
+		}
+		return 0, fmt.Errorf("unable to move the messages of %s on %s-%s to %s, %s", addressEntryKey(definition), a.CrName, a.Ordinal, target, message)
+	}
+
+	// producers may still be sending to the queue
+	messages, _, _, err := queueUsage(a, definition)
+	return messages, err
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	mgmt "github.com/artemiscloud/activemq-artemis-operator/pkg/utils/artemis"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia"
+	jc "github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia_client"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// An address or queue can go away from a broker, or be changed, through the
+// console or the broker losing it. Between CR changes the address controller
+// compares each broker with the CR every drift repair period and re-applies the
+// address on the brokers that no longer match.
+
+const (
+	AddressDriftRepairedReason     = "DriftRepaired"
+	AddressDriftRepairFailedReason = "DriftRepairFailed"
+)
+
+type queueAttribute struct {
+	name  string
+	value string
+}
+
+// desiredQueueAttributes are the queue mbean attributes that the CR sets and
+// that an update of the queue can put back
+func desiredQueueAttributes(addressRes *brokerv1beta1.ActiveMQArtemisAddress) []queueAttribute {
+	config := addressRes.Spec.QueueConfiguration
+	if config == nil {
+		return nil
+	}
+	attributes := []queueAttribute{}
+	addString := func(name string, value *string) {
+		if value != nil {
+			attributes = append(attributes, queueAttribute{name, *value})
+		}
+	}
+	addBool := func(name string, value *bool) {
+		if value != nil {
+			attributes = append(attributes, queueAttribute{name, strconv.FormatBool(*value)})
+		}
+	}
+	addInt := func(name string, value *int64) {
+		if value != nil {
+			attributes = append(attributes, queueAttribute{name, strconv.FormatInt(*value, 10)})
+		}
+	}
+	addInt32 := func(name string, value *int32) {
+		if value != nil {
+			attributes = append(attributes, queueAttribute{name, strconv.Itoa(int(*value))})
+		}
+	}
+
+	addString("Filter", config.FilterString)
+	addString("User", config.User)
+	addInt32("MaxConsumers", config.MaxConsumers)
+	addBool("Exclusive", config.Exclusive)
+	addBool("GroupRebalance", config.GroupRebalance)
+	addBool("GroupRebalancePauseDispatch", config.GroupRebalancePauseDispatch)
+	addInt32("GroupBuckets", config.GroupBuckets)
+	addString("GroupFirstKey", config.GroupFirstKey)
+	addBool("PurgeOnNoConsumers", config.PurgeOnNoConsumers)
+	addBool("Enabled", config.Enabled)
+	addInt32("ConsumersBeforeDispatch", config.ConsumersBeforeDispatch)
+	addInt("DelayBeforeDispatch", config.DelayBeforeDispatch)
+	addInt("RingSize", config.RingSize)
+	return attributes
+}
+
+// attributeMatches compares an attribute read through jolokia, which gives
+// numbers as floats, with the CR value
+func attributeMatches(actual string, desired string) bool {
+	if actual == desired {
+		return true
+	}
+	actualNumber, err := strconv.ParseFloat(actual, 64)
+	if err != nil {
+		return false
+	}
+	desiredNumber, err := strconv.ParseFloat(desired, 64)
+	return err == nil && actualNumber == desiredNumber
+}
+
+// addressDrift describes how a broker differs from the CR, it is empty when the
+// broker matches. An error means the broker couldn't be checked
+func addressDrift(a *jc.JkInfo, addressRes *brokerv1beta1.ActiveMQArtemisAddress) (string, error) {
+	addressName := addressRes.Spec.AddressName
+	routingType := strings.ToUpper(addressRoutingType(addressRes))
+
+	if addressRes.Spec.QueueName == nil || *addressRes.Spec.QueueName == "" {
+		response, err := a.Artemis.GetAddressAttribute(addressName, "RoutingTypes")
+		if err != nil {
+			if mgmt.IsInstanceNotFound(response) {
+				return fmt.Sprintf("address %s is missing", addressName), nil
+			}
+			return "", err
+		}
+		if !strings.Contains(strings.ToUpper(response.Value), routingType) {
+			return fmt.Sprintf("address %s routing types %s don't include %s", addressName, response.Value, routingType), nil
+		}
+		return "", nil
+	}
+
+	queueName := *addressRes.Spec.QueueName
+	bindings, err := a.Artemis.ListBindingsForAddress(addressName)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(bindings.Value, "name="+queueName+",") {
+		return fmt.Sprintf("queue %s is missing from address %s", queueName, addressName), nil
+	}
+
+	for _, attribute := range desiredQueueAttributes(addressRes) {
+		var response *jolokia.ResponseData
+		response, err = a.Artemis.GetQueueAttribute(addressName, queueName, routingType, attribute.name)
+		if err != nil {
+			if mgmt.IsInstanceNotFound(response) {
+				// the queue is bound to the address with another routing type
+				return fmt.Sprintf("queue %s is not %s", queueName, routingType), nil
+			}
+			return "", err
+		}
+		if !attributeMatches(response.Value, attribute.value) {
+			return fmt.Sprintf("queue %s %s is %q rather than %q", queueName, attribute.name, response.Value, attribute.value), nil
+		}
+	}
+	return "", nil
+}
+
+// repairQueue re-applies the address on the brokers that have drifted from the
+// CR, or that it is not yet applied to, and refreshes the counts of the others
+func (r *ActiveMQArtemisAddressReconciler) repairQueue(instance *AddressDeployment, request ctrl.Request, client client.Client) error {
+	reqLogger := r.log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	artemisArray, brokerCount, err := r.getPodBrokers(instance, request, client)
+	if err != nil {
+		return err
+	}
+	status := &instance.AddressResource.Status
+	previous := status.Brokers
+	status.Brokers = nil
+	status.BrokerCount = brokerCount
+
+	for _, a := range artemisArray {
+		var applied *brokerv1beta1.AddressBrokerStatus
+		for i := range previous {
+			if previous[i].CrName == a.CrName && previous[i].Ordinal == a.Ordinal && previous[i].Result == brokerv1beta1.AddressBrokerApplied {
+				applied = &previous[i]
+			}
+		}
+
+		drift, checkErr := addressDrift(a, &instance.AddressResource)
+		if checkErr != nil {
+			reqLogger.V(1).Info("unable to check the broker for drift", "broker", a.IP, "error", checkErr)
+			if applied != nil {
+				setAddressBrokerStatus(status, *applied)
+			}
+			continue
+		}
+
+		if drift == "" && applied != nil {
+			brokerStatus := newAddressBrokerStatus(a, &instance.AddressResource, nil, nil)
+			brokerStatus.LastAttemptTime = applied.LastAttemptTime
+			setAddressBrokerStatus(status, brokerStatus)
+			continue
+		}
+
+		var response *jolokia.ResponseData
+		response, err = createAddressResource(a, &instance.AddressResource, r.log)
+		setAddressBrokerStatus(status, newAddressBrokerStatus(a, &instance.AddressResource, response, err))
+
+		if drift != "" && r.recorder != nil {
+			if err == nil {
+				r.recorder.Eventf(&instance.AddressResource, corev1.EventTypeNormal, AddressDriftRepairedReason,
+					"%s on broker %s-%s, re-applied", drift, a.CrName, a.Ordinal)
+			} else {
+				r.recorder.Eventf(&instance.AddressResource, corev1.EventTypeWarning, AddressDriftRepairFailedReason,
+					"%s on broker %s-%s, unable to re-apply: %v", drift, a.CrName, a.Ordinal, err)
+			}
+		}
+	}
+	setAddressAppliedCondition(&instance.AddressResource)
+
+	return err
+}
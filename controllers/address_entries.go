@@ -0,0 +1,378 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/common"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia"
+	jc "github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia_client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// An address CR can list any number of addresses and queues, in its spec or in
+// a ConfigMap, along with its addressName and queueName. Each address without
+// queues and each queue is expanded to a definition, an address CR of its own
+// with one address and at most one queue, so that it is applied like a CR
+// with just addressName and queueName. The definitions are applied in batches
+// and the result of each is reported as an entry of the status.
+
+const (
+	AddressesConfigMapKey   = "addresses.yaml"
+	defaultAddressBatchSize = 50
+	// the pause before the next batch is applied
+	addressBatchInterval = time.Second
+)
+
+func usesAddressEntries(instance *brokerv1beta1.ActiveMQArtemisAddress) bool {
+	return len(instance.Spec.Addresses) > 0 || instance.Spec.AddressesConfigMap != ""
+}
+
+func addressBatchSize(instance *brokerv1beta1.ActiveMQArtemisAddress) int {
+	if instance.Spec.BatchSize == nil {
+		return defaultAddressBatchSize
+	}
+	return int(*instance.Spec.BatchSize)
+}
+
+// newAddressDefinition is a copy of the CR for one address and queue
+func newAddressDefinition(instance *brokerv1beta1.ActiveMQArtemisAddress, addressName string, routingType *string, queueName *string, queueConfiguration *brokerv1beta1.QueueConfigurationType) *brokerv1beta1.ActiveMQArtemisAddress {
+	definition := instance.DeepCopy()
+	definition.Status = brokerv1beta1.ActiveMQArtemisAddressStatus{}
+	definition.Spec.Addresses = nil
+	definition.Spec.AddressesConfigMap = ""
+	definition.Spec.AddressName = addressName
+	definition.Spec.RoutingType = routingType
+	definition.Spec.QueueName = queueName
+	definition.Spec.QueueConfiguration = queueConfiguration.DeepCopy()
+	return definition
+}
+
+func addressEntryKey(definition *brokerv1beta1.ActiveMQArtemisAddress) string {
+	if definition.Spec.QueueName == nil {
+		return definition.Spec.AddressName
+	}
+	return definition.Spec.AddressName + "/" + *definition.Spec.QueueName
+}
+
+// addressDefinitions expands the CR into its definitions, in the order they
+// are listed. A condition is returned when they can't be read or are invalid
+func addressDefinitions(instance *brokerv1beta1.ActiveMQArtemisAddress, client rtclient.Client) ([]*brokerv1beta1.ActiveMQArtemisAddress, *metav1.Condition) {
+	invalid := func(reason string, format string, args ...interface{}) ([]*brokerv1beta1.ActiveMQArtemisAddress, *metav1.Condition) {
+		return nil, &metav1.Condition{
+			Type:    brokerv1beta1.ValidConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+
+	definitions := []*brokerv1beta1.ActiveMQArtemisAddress{}
+	if instance.Spec.AddressName != "" {
+		definitions = append(definitions, newAddressDefinition(instance, instance.Spec.AddressName, instance.Spec.RoutingType, instance.Spec.QueueName, instance.Spec.QueueConfiguration))
+		if instance.Spec.QueueName != nil && *instance.Spec.QueueName == "" {
+			definitions[0].Spec.QueueName = nil
+		}
+	}
+
+	entries := instance.Spec.Addresses
+	if instance.Spec.AddressesConfigMap != "" {
+		configMap := &corev1.ConfigMap{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: instance.Spec.AddressesConfigMap}, configMap); err != nil {
+			return invalid(brokerv1beta1.ValidConditionMissingResourcesReason, "unable to find the ConfigMap %s, %v", instance.Spec.AddressesConfigMap, err)
+		}
+		var configMapEntries []brokerv1beta1.AddressEntryType
+		if err := yaml.UnmarshalStrict([]byte(configMap.Data[AddressesConfigMapKey]), &configMapEntries); err != nil {
+			return invalid(brokerv1beta1.ValidConditionInvalidAddressReason, "the %s key of the ConfigMap %s is not a list of addresses, %v", AddressesConfigMapKey, instance.Spec.AddressesConfigMap, err)
+		}
+		entries = append(append([]brokerv1beta1.AddressEntryType{}, entries...), configMapEntries...)
+	}
+
+	for _, entry := range entries {
+		if len(entry.Queues) == 0 {
+			definitions = append(definitions, newAddressDefinition(instance, entry.AddressName, entry.RoutingType, nil, nil))
+		}
+		for index := range entry.Queues {
+			queue := entry.Queues[index]
+			definitions = append(definitions, newAddressDefinition(instance, entry.AddressName, entry.RoutingType, &queue.QueueName, queue.QueueConfiguration))
+		}
+	}
+
+	if addressDeletePolicy(instance) == brokerv1beta1.AddressDeletePolicies.MoveMessagesTo {
+		// the target would be removed along with the addresses it takes the messages of
+		for _, definition := range definitions {
+			if definition.Spec.AddressName == instance.Spec.DeletePolicy.MoveMessagesTo {
+				return invalid(brokerv1beta1.ValidConditionInvalidAddressReason, "%s: .Spec.DeletePolicy.MoveMessagesTo can't be an address of the CR", addressEntryKey(definition))
+			}
+		}
+	}
+
+	keys := map[string]bool{}
+	for _, definition := range definitions {
+		if condition := validateAddress(definition); condition != nil {
+			condition.Message = addressEntryKey(definition) + ": " + condition.Message
+			return nil, condition
+		}
+		if definition.Spec.QueueName != nil && *definition.Spec.QueueName == "" {
+			return invalid(brokerv1beta1.ValidConditionInvalidAddressReason, "%s: a queue requires a queueName", definition.Spec.AddressName)
+		}
+		if keys[addressEntryKey(definition)] {
+			return invalid(brokerv1beta1.ValidConditionInvalidAddressReason, "%s is defined more than once", addressEntryKey(definition))
+		}
+		keys[addressEntryKey(definition)] = true
+	}
+	return definitions, nil
+}
+
+func definitionSpecs(definitions []*brokerv1beta1.ActiveMQArtemisAddress) []brokerv1beta1.ActiveMQArtemisAddressSpec {
+	specs := []brokerv1beta1.ActiveMQArtemisAddressSpec{}
+	for _, definition := range definitions {
+		specs = append(specs, definition.Spec)
+	}
+	return specs
+}
+
+// addressEntriesChanged tells if the definitions differ from the ones last
+// applied, all of them are then applied again
+func addressEntriesChanged(instance *AddressDeployment, previous *AddressDeployment) bool {
+	if previous == nil {
+		// after a restart the status tells if the CR changed
+		condition := meta.FindStatusCondition(instance.AddressResource.Status.Conditions, brokerv1beta1.AddressAppliedConditionType)
+		return condition == nil || condition.ObservedGeneration != instance.AddressResource.Generation
+	}
+	return previous.AddressResource.Generation != instance.AddressResource.Generation ||
+		!reflect.DeepEqual(definitionSpecs(previous.Definitions), definitionSpecs(instance.Definitions))
+}
+
+// applyAddressEntries applies the next batch of the definitions that are not
+// applied on all the brokers. Once they all are, the brokers are checked for
+// drift. It tells if more batches are left
+func (r *ActiveMQArtemisAddressReconciler) applyAddressEntries(instance *AddressDeployment, request ctrl.Request, client rtclient.Client, changed bool) (bool, error) {
+	reqLogger := r.log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+
+	status := &instance.AddressResource.Status
+	previous := map[string]brokerv1beta1.AddressEntryStatus{}
+	if !changed {
+		for _, entry := range status.Entries {
+			key := entry.AddressName
+			if entry.QueueName != "" {
+				key += "/" + entry.QueueName
+			}
+			previous[key] = entry
+		}
+	}
+
+	artemisArray, brokerCount, err := r.getPodBrokers(instance, request, client)
+	if err != nil {
+		return false, err
+	}
+	status.BrokerCount = brokerCount
+
+	pendingBefore := false
+	for _, definition := range instance.Definitions {
+		if entry, found := previous[addressEntryKey(definition)]; !found || entry.Result != brokerv1beta1.AddressBrokerApplied {
+			pendingBefore = true
+			break
+		}
+	}
+	if !pendingBefore && common.GetAddressDriftRepairPeriod() == 0 {
+		return false, nil
+	}
+
+	// the first failure on each broker
+	brokerFailures := map[*jc.JkInfo]brokerv1beta1.AddressBrokerStatus{}
+
+	status.Entries = nil
+	applied := 0
+	// entries left for the next batches, the others wait for the next reconcile
+	more := false
+	for _, definition := range instance.Definitions {
+		entry, found := previous[addressEntryKey(definition)]
+		if !found {
+			entry = brokerv1beta1.AddressEntryStatus{AddressName: definition.Spec.AddressName, Result: brokerv1beta1.AddressBrokerPending}
+			if definition.Spec.QueueName != nil {
+				entry.QueueName = *definition.Spec.QueueName
+			}
+		}
+
+		targets := []*jc.JkInfo{}
+		if pendingBefore {
+			if entry.Result != brokerv1beta1.AddressBrokerApplied {
+				if applied < addressBatchSize(&instance.AddressResource) {
+					targets = artemisArray
+					applied++
+				} else {
+					more = len(artemisArray) > 0
+				}
+			}
+		} else {
+			for _, a := range artemisArray {
+				drift, checkErr := addressDrift(a, definition)
+				if checkErr != nil {
+					reqLogger.V(1).Info("unable to check the broker for drift", "broker", a.IP, "entry", addressEntryKey(definition), "error", checkErr)
+				} else if drift != "" {
+					reqLogger.V(1).Info("drift found", "broker", a.IP, "drift", drift)
+					targets = append(targets, a)
+				}
+			}
+		}
+
+		if len(targets) > 0 {
+			entry.AppliedCount, entry.ErrorCode, entry.Message = 0, "", ""
+			for _, a := range artemisArray {
+				if !containsJkInfo(targets, a) {
+					entry.AppliedCount++
+					continue
+				}
+				var response *jolokia.ResponseData
+				var applyErr error
+				if response, applyErr = createAddressResource(a, definition, r.log); applyErr == nil {
+					entry.AppliedCount++
+					continue
+				}
+				err = applyErr
+				brokerStatus := newAddressBrokerStatus(a, definition, response, applyErr)
+				if entry.ErrorCode == "" {
+					entry.ErrorCode, entry.Message = brokerStatus.ErrorCode, brokerStatus.Message
+				}
+				if _, failed := brokerFailures[a]; !failed {
+					brokerFailures[a] = brokerStatus
+				}
+			}
+			if !pendingBefore && r.recorder != nil {
+				if entry.ErrorCode == "" {
+					r.recorder.Eventf(&instance.AddressResource, corev1.EventTypeNormal, AddressDriftRepairedReason,
+						"%s drifted on %d brokers, re-applied", addressEntryKey(definition), len(targets))
+				} else {
+					r.recorder.Eventf(&instance.AddressResource, corev1.EventTypeWarning, AddressDriftRepairFailedReason,
+						"%s drifted on %d brokers, unable to re-apply: %s", addressEntryKey(definition), len(targets), entry.Message)
+				}
+			}
+			switch {
+			case entry.ErrorCode != "":
+				entry.Result = brokerv1beta1.AddressBrokerFailed
+			case len(artemisArray) == 0 || int32(len(artemisArray)) < brokerCount:
+				// applied again when the other brokers start
+				entry.Result = brokerv1beta1.AddressBrokerPending
+			default:
+				entry.Result = brokerv1beta1.AddressBrokerApplied
+			}
+		}
+		status.Entries = append(status.Entries, entry)
+	}
+
+	status.Brokers = nil
+	for _, a := range artemisArray {
+		brokerStatus, failed := brokerFailures[a]
+		if !failed {
+			brokerStatus = brokerv1beta1.AddressBrokerStatus{CrName: a.CrName, Ordinal: a.Ordinal, Result: brokerv1beta1.AddressBrokerApplied, LastAttemptTime: metav1.Now()}
+			if more {
+				brokerStatus.Result = brokerv1beta1.AddressBrokerPending
+			}
+		}
+		setAddressBrokerStatus(status, brokerStatus)
+	}
+	setAddressAppliedCondition(&instance.AddressResource)
+
+	return more, err
+}
+
+func containsJkInfo(jks []*jc.JkInfo, jk *jc.JkInfo) bool {
+	for _, candidate := range jks {
+		if candidate == jk {
+			return true
+		}
+	}
+	return false
+}
+
+// setAddressEntriesDelivered sets the entries of an address delivered by broker
+// properties, the brokers load all of its definitions at once
+func setAddressEntriesDelivered(instance *brokerv1beta1.ActiveMQArtemisAddress, definitions []*brokerv1beta1.ActiveMQArtemisAddress) {
+	status := &instance.Status
+	status.Entries = nil
+	if !usesAddressEntries(instance) {
+		return
+	}
+
+	result := brokerv1beta1.AddressEntryStatus{Result: brokerv1beta1.AddressBrokerApplied}
+	for _, broker := range status.Brokers {
+		switch broker.Result {
+		case brokerv1beta1.AddressBrokerApplied:
+			result.AppliedCount++
+		case brokerv1beta1.AddressBrokerFailed:
+			if result.Result != brokerv1beta1.AddressBrokerFailed {
+				result.Result, result.ErrorCode, result.Message = broker.Result, broker.ErrorCode, broker.Message
+			}
+		default:
+			if result.Result == brokerv1beta1.AddressBrokerApplied {
+				result.Result, result.ErrorCode, result.Message = broker.Result, broker.ErrorCode, broker.Message
+			}
+		}
+	}
+	if len(status.Brokers) == 0 {
+		result.Result = brokerv1beta1.AddressBrokerPending
+	}
+
+	for _, definition := range definitions {
+		entry := result
+		entry.AddressName = definition.Spec.AddressName
+		if definition.Spec.QueueName != nil {
+			entry.QueueName = *definition.Spec.QueueName
+		}
+		status.Entries = append(status.Entries, entry)
+	}
+}
+
+// addressEntriesSummary tells how many entries are applied, and which failed
+func addressEntriesSummary(entries []brokerv1beta1.AddressEntryStatus) (string, bool, bool) {
+	applied, pending := 0, 0
+	failed := []string{}
+	for _, entry := range entries {
+		switch entry.Result {
+		case brokerv1beta1.AddressBrokerApplied:
+			applied++
+		case brokerv1beta1.AddressBrokerPending:
+			pending++
+		default:
+			name := entry.AddressName
+			if entry.QueueName != "" {
+				name += "/" + entry.QueueName
+			}
+			failed = append(failed, name+" "+entry.ErrorCode)
+		}
+	}
+	summary := fmt.Sprintf("%d of %d entries applied", applied, len(entries))
+	if len(failed) > 0 {
+		// there can be hundreds of entries, the status lists them all
+		const listed = 5
+		if len(failed) > listed {
+			failed = append(failed[:listed], fmt.Sprintf("%d more", len(failed)-listed))
+		}
+		summary += ", failed " + strings.Join(failed, ", ")
+	}
+	return summary, len(failed) > 0, pending > 0
+}
+
+func (r *ActiveMQArtemisAddressReconciler) deleteDefinitions(instance *AddressDeployment, request ctrl.Request, client rtclient.Client) error {
+	artemisArray, _, err := r.getPodBrokers(instance, request, client)
+	if err != nil {
+		return err
+	}
+	for _, definition := range instance.Definitions {
+		if deleteErr := r.deleteDefinition(definition, artemisArray, request); deleteErr != nil {
+			err = deleteErr
+		}
+	}
+	return err
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/resources"
 	ss "github.com/artemiscloud/activemq-artemis-operator/pkg/resources/statefulsets"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/common"
 	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/namer"
 	"github.com/go-logr/logr"
 
@@ -120,8 +122,14 @@ func (c *AddressObserver) checkCRsForNewPod(newPod *corev1.Pod) {
 
 	// go over each address instance for the new pod
 	for _, a := range addressInstances.Items {
-		//get the target namespaces
-		targetCrNamespacedNames := createTargetCrNamespacedNames(newPod.Namespace, a.Spec.ApplyToCrNames, c.log)
+		if a.DeletionTimestamp != nil {
+			// being removed from the brokers
+			continue
+		}
+		if deliveredByBrokerProperties(&a) {
+			// the broker starts with it
+			continue
+		}
 		//e.g. ex-aao-ss
 		podSSName, _ := c.getSSNameForPod(newPod)
 		if podSSName == nil {
@@ -133,25 +141,8 @@ func (c *AddressObserver) checkCRsForNewPod(newPod *corev1.Pod) {
 		podCrName := namer.SSToCr(*podSSName)
 		c.log.V(1).Info("got pod's CR name", "value", podCrName)
 		//if the new pod is a target for this address cr, create
-		isTargetPod := false
-		if targetCrNamespacedNames == nil {
-			c.log.V(1).Info("The new pod is the target as the address CR doesn't have applyToCrNames specified.")
-			isTargetPod = true
-		} else {
-
-			newPodSSNamespacedName := types.NamespacedName{
-				Name:      podCrName,
-				Namespace: newPod.Namespace,
-			}
-			for _, ns := range targetCrNamespacedNames {
-				if ns == newPodSSNamespacedName {
-					c.log.V(1).Info("The new pod is the target", "namespace", ns)
-					isTargetPod = true
-					break
-				}
-			}
-		}
-		if isTargetPod {
+		if addressAppliesTo(&a, types.NamespacedName{Name: podCrName, Namespace: newPod.Namespace}, c.opclient, c.log) {
+			c.log.V(1).Info("The new pod is the target", "address", a.Namespace+"/"+a.Name)
 			podNamespacedName := types.NamespacedName{
 				Name:      newPod.Name,
 				Namespace: newPod.Namespace,
@@ -160,8 +151,28 @@ func (c *AddressObserver) checkCRsForNewPod(newPod *corev1.Pod) {
 			ssInfos := ss.GetDeployedStatefulSetNames(c.opclient, podNamespacedName.Namespace, []types.NamespacedName{podNamespacedName})
 			jks := jc.GetBrokers(podNamespacedName, ssInfos, c.opclient)
 
+			definitions, condition := addressDefinitions(&a, c.opclient)
+			if condition != nil {
+				c.log.V(1).Info("The address CR is invalid", "address", a.Namespace+"/"+a.Name, "reason", condition.Message)
+				continue
+			}
 			for _, jk := range jks {
-				createAddressResource(jk, &a, c.log)
+				// a broker reports the first definition that failed
+				var brokerStatus brokerv1beta1.AddressBrokerStatus
+				for index, definition := range definitions {
+					response, err := createAddressResource(jk, definition, c.log)
+					definitionStatus := newAddressBrokerStatus(jk, definition, response, err)
+					if index == 0 || (brokerStatus.Result == brokerv1beta1.AddressBrokerApplied && definitionStatus.Result != brokerv1beta1.AddressBrokerApplied) {
+						brokerStatus = definitionStatus
+					}
+				}
+				setAddressBrokerStatus(&a.Status, brokerStatus)
+			}
+			if len(jks) > 0 {
+				setAddressAppliedCondition(&a)
+				common.SetReadyCondition(&a.Status.Conditions)
+				// a conflicting update is not retried, the next reconcile of the address covers the pod
+				resources.UpdateStatus(c.opclient, &a)
 			}
 		}
 	}
@@ -183,11 +194,9 @@ func (c *AddressObserver) getSSNameForPod(newPod *corev1.Pod) (*string, *appsv1.
 
 func (c *AddressObserver) getAddressInstances(newPod *corev1.Pod) (*brokerv1beta1.ActiveMQArtemisAddressList, error) {
 
+	// an address with a brokerSelector can target the pod from another namespace
 	addrList := &brokerv1beta1.ActiveMQArtemisAddressList{}
-	opts := []client.ListOption{
-		client.InNamespace(newPod.Namespace),
-	}
-	if err := c.opclient.List(context.TODO(), addrList, opts...); err != nil {
+	if err := c.opclient.List(context.TODO(), addrList); err != nil {
 		c.log.V(1).Error(err, "failed to list address")
 		return nil, err
 	}
@@ -0,0 +1,423 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/resources/secrets"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/common"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// An address CR with the BrokerProperties delivery mode is not created through
+// jolokia. The broker reconciler compiles the addresses that apply to a CR into
+// a properties secret of its own, mounted next to the broker properties, so
+// the brokers start with the addresses and reload them when they change. The
+// BrokerPropertiesApplied condition of the broker CR tells when they are loaded.
+
+const (
+	addressPropertiesSuffix = "-address-props"
+	AddressPropertiesName   = "address.properties"
+	addressConfigurationKey = "addressConfigurations."
+)
+
+func deliveredByBrokerProperties(instance *brokerv1beta1.ActiveMQArtemisAddress) bool {
+	return instance.Spec.DeliveryMode != nil && *instance.Spec.DeliveryMode == brokerv1beta1.AddressDeliveryModes.BrokerProperties
+}
+
+func getAddressPropertiesSecretName(customResource *brokerv1beta1.ActiveMQArtemis) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: customResource.Namespace,
+		Name:      customResource.Name + addressPropertiesSuffix,
+	}
+}
+
+// propertyKeySegment quotes address and queue names with dots, the broker would
+// otherwise take them as nested properties
+func propertyKeySegment(name string) string {
+	if strings.Contains(name, ".") {
+		return "\"" + name + "\""
+	}
+	return name
+}
+
+func addressRoutingTypesKey(addressName string) string {
+	return addressConfigurationKey + propertyKeySegment(addressName) + ".routingTypes="
+}
+
+// queueConfigProperties configure the queue of an address CR, they are empty
+// for an address without a queue
+func queueConfigProperties(addressRes *brokerv1beta1.ActiveMQArtemisAddress) []string {
+	if addressRes.Spec.QueueName == nil || *addressRes.Spec.QueueName == "" {
+		return nil
+	}
+	prefix := addressConfigurationKey + propertyKeySegment(addressRes.Spec.AddressName) + ".queueConfigs." + propertyKeySegment(*addressRes.Spec.QueueName) + "."
+	props := []string{
+		prefix + "address=" + addressRes.Spec.AddressName,
+		prefix + "routingType=" + strings.ToUpper(addressRoutingType(addressRes)),
+	}
+
+	config := addressRes.Spec.QueueConfiguration
+	if config == nil {
+		return props
+	}
+	addString := func(name string, value *string) {
+		if value != nil {
+			props = append(props, prefix+name+"="+*value)
+		}
+	}
+	addBool := func(name string, value *bool) {
+		if value != nil {
+			props = append(props, prefix+name+"="+strconv.FormatBool(*value))
+		}
+	}
+	addInt := func(name string, value *int64) {
+		if value != nil {
+			props = append(props, prefix+name+"="+strconv.FormatInt(*value, 10))
+		}
+	}
+	addInt32 := func(name string, value *int32) {
+		if value != nil {
+			props = append(props, prefix+name+"="+strconv.Itoa(int(*value)))
+		}
+	}
+
+	addString("filterString", config.FilterString)
+	addBool("durable", config.Durable)
+	addString("user", config.User)
+	addInt32("maxConsumers", config.MaxConsumers)
+	addBool("exclusive", config.Exclusive)
+	addBool("groupRebalance", config.GroupRebalance)
+	addBool("groupRebalancePauseDispatch", config.GroupRebalancePauseDispatch)
+	addInt32("groupBuckets", config.GroupBuckets)
+	addString("groupFirstKey", config.GroupFirstKey)
+	addBool("lastValue", config.LastValue)
+	addString("lastValueKey", config.LastValueKey)
+	addBool("nonDestructive", config.NonDestructive)
+	addBool("purgeOnNoConsumers", config.PurgeOnNoConsumers)
+	addBool("enabled", config.Enabled)
+	addInt32("consumersBeforeDispatch", config.ConsumersBeforeDispatch)
+	addInt("delayBeforeDispatch", config.DelayBeforeDispatch)
+	addInt32("consumerPriority", config.ConsumerPriority)
+	addBool("autoDelete", config.AutoDelete)
+	addInt("autoDeleteDelay", config.AutoDeleteDelay)
+	addInt("autoDeleteMessageCount", config.AutoDeleteMessageCount)
+	addInt("ringSize", config.RingSize)
+	addBool("configurationManaged", config.ConfigurationManaged)
+	addBool("temporary", config.Temporary)
+	addBool("autoCreateAddress", config.AutoCreateAddress)
+	return props
+}
+
+// addressBrokerProperties configure the addresses and queues of the address
+// CRs. The routing types of the CRs with the same address are merged as an
+// address has one set of routing types
+func addressBrokerProperties(addresses []*brokerv1beta1.ActiveMQArtemisAddress) []string {
+	routingTypes := map[string][]string{}
+	queueProps := []string{}
+	for _, addressRes := range addresses {
+		routingType := strings.ToUpper(addressRoutingType(addressRes))
+		if !containsString(routingTypes[addressRes.Spec.AddressName], routingType) {
+			routingTypes[addressRes.Spec.AddressName] = append(routingTypes[addressRes.Spec.AddressName], routingType)
+		}
+		queueProps = append(queueProps, queueConfigProperties(addressRes)...)
+	}
+
+	addressNames := []string{}
+	for addressName := range routingTypes {
+		addressNames = append(addressNames, addressName)
+	}
+	sort.Strings(addressNames)
+
+	props := []string{}
+	for _, addressName := range addressNames {
+		sort.Strings(routingTypes[addressName])
+		props = append(props, addressRoutingTypesKey(addressName)+strings.Join(routingTypes[addressName], ","))
+	}
+	// the address has to be configured ahead of its queues
+	return append(props, queueProps...)
+}
+
+// resolveAddressPropertiesFor gives the broker properties of the address CRs
+// delivered by broker properties that apply to the CR, in any namespace as a
+// broker selector can target other namespaces
+func resolveAddressPropertiesFor(customResource *brokerv1beta1.ActiveMQArtemis, client rtclient.Client, log logr.Logger) []string {
+	list := &brokerv1beta1.ActiveMQArtemisAddressList{}
+	if err := client.List(context.TODO(), list, &rtclient.ListOptions{}); err != nil {
+		log.Error(err, "unable to list addresses")
+		return nil
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		if list.Items[i].Namespace != list.Items[j].Namespace {
+			return list.Items[i].Namespace < list.Items[j].Namespace
+		}
+		return list.Items[i].Name < list.Items[j].Name
+	})
+
+	crName := types.NamespacedName{Namespace: customResource.Namespace, Name: customResource.Name}
+	addresses := []*brokerv1beta1.ActiveMQArtemisAddress{}
+	for index := range list.Items {
+		addressRes := &list.Items[index]
+		if !deliveredByBrokerProperties(addressRes) || addressRes.DeletionTimestamp != nil || validateAddress(addressRes) != nil {
+			continue
+		}
+		if addressAppliesTo(addressRes, crName, client, log) {
+			definitions, condition := addressDefinitions(addressRes, client)
+			if condition != nil {
+				continue
+			}
+			addresses = append(addresses, definitions...)
+		}
+	}
+	if len(addresses) == 0 {
+		return nil
+	}
+	return addressBrokerProperties(addresses)
+}
+
+// addResourceForAddressProperties gives the name of the address properties
+// secret to mount, empty when there is none. Once deployed the secret is kept
+// when the last address goes away, so the pod template doesn't change with it
+func (reconciler *ActiveMQArtemisReconcilerImpl) addResourceForAddressProperties(customResource *brokerv1beta1.ActiveMQArtemis, namer common.Namers) string {
+	resourceName := getAddressPropertiesSecretName(customResource)
+
+	var desired *corev1.Secret
+	if obj := reconciler.cloneOfDeployed(reflect.TypeOf(corev1.Secret{}), resourceName.Name); obj != nil {
+		desired = obj.(*corev1.Secret)
+	} else if len(reconciler.addressProperties) == 0 {
+		return ""
+	}
+
+	buf := &strings.Builder{}
+	fmt.Fprintln(buf, "# generated by crd")
+	fmt.Fprintln(buf, "#")
+	for _, prop := range reconciler.addressProperties {
+		fmt.Fprintln(buf, prop)
+	}
+	data := map[string]string{AddressPropertiesName: buf.String()}
+
+	if desired == nil {
+		secret := secrets.MakeSecret(resourceName, data, namer.LabelBuilder.Labels())
+		desired = &secret
+	} else {
+		desired.StringData = data
+	}
+
+	reconciler.log.V(1).Info("Requesting secret for address properties", "name", resourceName.Name)
+	reconciler.trackDesired(desired)
+	return resourceName.Name
+}
+
+// addressTargetCrs gives the broker CRs an address CR applies to
+func addressTargetCrs(instance *brokerv1beta1.ActiveMQArtemisAddress, client rtclient.Client, log logr.Logger) ([]*brokerv1beta1.ActiveMQArtemis, error) {
+	var names []types.NamespacedName
+	if instance.Spec.BrokerSelector != nil {
+		var err error
+		if names, err = resolveSelectedCrs(instance, client); err != nil {
+			return nil, err
+		}
+	} else {
+		crList := &brokerv1beta1.ActiveMQArtemisList{}
+		if err := client.List(context.TODO(), crList, &rtclient.ListOptions{Namespace: instance.Namespace}); err != nil {
+			return nil, err
+		}
+		for _, cr := range crList.Items {
+			name := types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}
+			if addressAppliesTo(instance, name, client, log) {
+				names = append(names, name)
+			}
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return names[i].String() < names[j].String()
+		})
+	}
+
+	targets := []*brokerv1beta1.ActiveMQArtemis{}
+	for _, name := range names {
+		cr := &brokerv1beta1.ActiveMQArtemis{}
+		if err := client.Get(context.TODO(), name, cr); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		targets = append(targets, cr)
+	}
+	return targets, nil
+}
+
+// addressInSecret tells if the properties secret of a broker CR configures the
+// address and queue of the CR
+func addressInSecret(addressRes *brokerv1beta1.ActiveMQArtemisAddress, secret *corev1.Secret) bool {
+	lines := strings.Split(string(secret.Data[AddressPropertiesName]), "\n")
+	routingTypesKey := addressRoutingTypesKey(addressRes.Spec.AddressName)
+	routingType := strings.ToUpper(addressRoutingType(addressRes))
+
+	found := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, routingTypesKey) && containsString(strings.Split(line[len(routingTypesKey):], ","), routingType) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+	for _, prop := range queueConfigProperties(addressRes) {
+		if !containsString(lines, prop) {
+			return false
+		}
+	}
+	return true
+}
+
+func definitionsInSecret(definitions []*brokerv1beta1.ActiveMQArtemisAddress, secret *corev1.Secret) bool {
+	for _, definition := range definitions {
+		if !addressInSecret(definition, secret) {
+			return false
+		}
+	}
+	return true
+}
+
+// deliverByBrokerProperties has the target brokers reconciled when the
+// properties the address contributes change and reports, for each broker,
+// whether the broker has loaded them
+func (r *ActiveMQArtemisAddressReconciler) deliverByBrokerProperties(instance *AddressDeployment, request ctrl.Request, client rtclient.Client) error {
+	reqLogger := r.log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	addressRes := &instance.AddressResource
+
+	targets, err := addressTargetCrs(addressRes, client, reqLogger)
+	if err != nil {
+		reqLogger.Error(err, "unable to find the target crs")
+		return err
+	}
+	targetNames := []types.NamespacedName{}
+	for _, cr := range targets {
+		targetNames = append(targetNames, types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name})
+	}
+
+	// the brokers no longer targeted drop the address, the targets pick up any change
+	toReconcile := []types.NamespacedName{}
+	for _, name := range r.delivered[request.NamespacedName] {
+		if !containsNamespacedName(targetNames, name) {
+			toReconcile = append(toReconcile, name)
+		}
+	}
+	checksum := alder32StringValue(alder32Of(addressBrokerProperties(instance.Definitions)))
+	if r.checksums[request.NamespacedName] != checksum {
+		toReconcile = append(toReconcile, targetNames...)
+	}
+	if len(toReconcile) > 0 {
+		reqLogger.V(1).Info("address properties changed, reconciling brokers", "brokers", toReconcile)
+		if err = r.reconcileBrokers(toReconcile, reqLogger); err != nil {
+			return err
+		}
+		r.checksums[request.NamespacedName] = checksum
+	}
+	r.delivered[request.NamespacedName] = targetNames
+
+	status := &addressRes.Status
+	status.Brokers = nil
+	status.BrokerCount = 0
+	for _, cr := range targets {
+		for _, brokerStatus := range addressPropertiesBrokerStatus(instance.Definitions, cr, client) {
+			setAddressBrokerStatus(status, brokerStatus)
+		}
+		status.BrokerCount += common.GetDeploymentSize(cr)
+	}
+	setAddressEntriesDelivered(addressRes, instance.Definitions)
+	setAddressAppliedCondition(addressRes)
+	return nil
+}
+
+// addressPropertiesBrokerStatus gives the status of the brokers of a CR from
+// its BrokerPropertiesApplied condition, that covers the address properties
+// secret once the broker reconciler has put the definitions in it
+func addressPropertiesBrokerStatus(definitions []*brokerv1beta1.ActiveMQArtemisAddress, cr *brokerv1beta1.ActiveMQArtemis, client rtclient.Client) []brokerv1beta1.AddressBrokerStatus {
+	result := brokerv1beta1.AddressBrokerStatus{
+		CrName:          cr.Name,
+		Result:          brokerv1beta1.AddressBrokerPending,
+		LastAttemptTime: metav1.Now(),
+	}
+
+	secret := &corev1.Secret{}
+	condition := meta.FindStatusCondition(cr.Status.Conditions, brokerv1beta1.ConfigAppliedConditionType)
+	if err := client.Get(context.TODO(), getAddressPropertiesSecretName(cr), secret); err != nil || !definitionsInSecret(definitions, secret) {
+		result.Message = "waiting for the broker properties to include the address"
+	} else if !externalConfigApplied(cr, secret) || condition == nil {
+		result.Message = "waiting for the brokers to load the broker properties"
+	} else {
+		result.LastAttemptTime = condition.LastTransitionTime
+		switch {
+		case condition.Status == metav1.ConditionTrue && condition.Reason == brokerv1beta1.ConfigAppliedConditionSynchedReason:
+			result.Result = brokerv1beta1.AddressBrokerApplied
+		case condition.Reason == brokerv1beta1.ConfigAppliedConditionSynchedWithErrorReason:
+			result.Result = brokerv1beta1.AddressBrokerFailed
+			result.ErrorCode = condition.Reason
+			result.Message = condition.Message
+		default:
+			result.ErrorCode = condition.Reason
+			result.Message = condition.Message
+		}
+	}
+
+	statuses := []brokerv1beta1.AddressBrokerStatus{}
+	for ordinal := int32(0); ordinal < common.GetDeploymentSize(cr); ordinal++ {
+		result.Ordinal = strconv.Itoa(int(ordinal))
+		statuses = append(statuses, result)
+	}
+	return statuses
+}
+
+// externalConfigApplied tells if the broker CR status reports the version of
+// the secret as in sync
+func externalConfigApplied(cr *brokerv1beta1.ActiveMQArtemis, secret *corev1.Secret) bool {
+	for _, config := range cr.Status.ExternalConfigs {
+		if config.Name == secret.Name {
+			return config.ResourceVersion == secret.ResourceVersion
+		}
+	}
+	return false
+}
+
+// undeliver has the brokers the address was delivered to drop it from their properties
+func (r *ActiveMQArtemisAddressReconciler) undeliver(addressName types.NamespacedName, reqLogger logr.Logger) {
+	if delivered, found := r.delivered[addressName]; found {
+		delete(r.delivered, addressName)
+		delete(r.checksums, addressName)
+		r.reconcileBrokers(delivered, reqLogger)
+	}
+}
+
+func (r *ActiveMQArtemisAddressReconciler) reconcileBrokers(names []types.NamespacedName, reqLogger logr.Logger) error {
+	var result error
+	for _, name := range names {
+		if err := r.BrokerReconciler.ReconcileBroker(name); err != nil {
+			reqLogger.Error(err, "failed to reconcile target broker", "broker", name)
+			result = err
+		}
+	}
+	return result
+}
+
+func containsNamespacedName(names []types.NamespacedName, name types.NamespacedName) bool {
+	for _, candidate := range names {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
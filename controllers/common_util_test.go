@@ -43,6 +43,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"software.sslmate.com/src/go-pkcs12"
 
 	cmv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
@@ -206,6 +207,26 @@ func newArtemisSpecWithFastProbes() brokerv1beta1.ActiveMQArtemisSpec {
 	return spec
 }
 
+// newTestBroker returns a broker CR in the test namespace for the unit tests,
+// which set whatever else they exercise
+func newTestBroker(name string, size int32, acceptors ...brokerv1beta1.AcceptorType) *brokerv1beta1.ActiveMQArtemis {
+	return &brokerv1beta1.ActiveMQArtemis{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+		Spec: brokerv1beta1.ActiveMQArtemisSpec{
+			DeploymentPlan: brokerv1beta1.DeploymentPlanType{Size: &size},
+			Acceptors:      acceptors,
+		},
+	}
+}
+
+// newTestClient returns a fake client that knows the broker types for the unit tests
+func newTestClient(objects ...client.Object) client.WithWatch {
+	testScheme := runtime.NewScheme()
+	scheme.AddToScheme(testScheme)
+	brokerv1beta1.AddToScheme(testScheme)
+	return fake.NewClientBuilder().WithScheme(testScheme).WithObjects(objects...).Build()
+}
+
 func generateArtemisSpec(namespace string) brokerv1beta1.ActiveMQArtemis {
 
 	toCreate := brokerv1beta1.ActiveMQArtemis{
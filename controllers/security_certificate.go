@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	certificateLoginModuleClass = "org.apache.activemq.artemis.spi.core.security.jaas.TextFileCertificateLoginModule"
+	certificateUsersKey         = "users.properties"
+	certificateRolesKey         = "roles.properties"
+)
+
+func validateCertificateLoginModule(module *brokerv1beta1.CertificateLoginModuleType) string {
+	if len(module.Users) > 0 && module.MappingsSecret != "" {
+		return fmt.Sprintf("certificate login module %s can't have both users and a mappingsSecret", module.Name)
+	}
+	if len(module.Users) == 0 && module.MappingsSecret == "" {
+		return fmt.Sprintf("certificate login module %s requires users or a mappingsSecret", module.Name)
+	}
+	users := map[string]bool{}
+	for _, user := range module.Users {
+		if user.Name == "" {
+			return fmt.Sprintf("certificate login module %s has a user without a name", module.Name)
+		}
+		if users[user.Name] {
+			return fmt.Sprintf("certificate login module %s has more than one user %s", module.Name, user.Name)
+		}
+		users[user.Name] = true
+		if (user.SubjectDN == "") == (user.SubjectDNRegex == "") {
+			return fmt.Sprintf("certificate login module %s user %s requires either a subjectDN or a subjectDNRegex", module.Name, user.Name)
+		}
+		if user.SubjectDNRegex != "" {
+			if _, err := regexp.Compile(user.SubjectDNRegex); err != nil {
+				return fmt.Sprintf("certificate login module %s user %s subjectDNRegex is invalid, %v", module.Name, user.Name, err)
+			}
+		}
+	}
+	return ""
+}
+
+func findCertificateLoginModule(instance *brokerv1beta1.ActiveMQArtemisSecurity, name *string) *brokerv1beta1.CertificateLoginModuleType {
+	if name == nil {
+		return nil
+	}
+	for i, module := range instance.Spec.LoginModules.CertificateLoginModules {
+		if module.Name == *name {
+			return &instance.Spec.LoginModules.CertificateLoginModules[i]
+		}
+	}
+	return nil
+}
+
+func checkCertificateMappingsSecrets(instance *brokerv1beta1.ActiveMQArtemisSecurity, client rtclient.Client) error {
+	for _, module := range instance.Spec.LoginModules.CertificateLoginModules {
+		if module.MappingsSecret == "" {
+			continue
+		}
+		secret := &corev1.Secret{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: module.MappingsSecret}, secret); err != nil {
+			return fmt.Errorf("unable to find the mappings secret %s of certificate login module %s, %v", module.MappingsSecret, module.Name, err)
+		}
+		for _, key := range []string{certificateUsersKey, certificateRolesKey} {
+			if _, found := secret.Data[key]; !found {
+				return fmt.Errorf("the mappings secret %s of certificate login module %s has no %s key", module.MappingsSecret, module.Name, key)
+			}
+		}
+	}
+	return nil
+}
+
+// certificateFilePrefix names the files of the inline mappings, that are
+// copied next to login.config
+func certificateFilePrefix(module *brokerv1beta1.CertificateLoginModuleType) string {
+	return "cert-" + module.Name + "-"
+}
+
+func renderCertificateLoginModule(module *brokerv1beta1.CertificateLoginModuleType, flag string, debug bool) string {
+	options := []string{}
+	if debug {
+		options = append(options, "debug=true")
+	}
+	options = append(options, "reload=true")
+	usersFile := certificateFilePrefix(module) + certificateUsersKey
+	rolesFile := certificateFilePrefix(module) + certificateRolesKey
+	if module.MappingsSecret != "" {
+		options = append(options, jaasQuoted("baseDir", "/etc/"+module.MappingsSecret+"-volume"))
+		usersFile, rolesFile = certificateUsersKey, certificateRolesKey
+	}
+	options = append(options, jaasQuoted("org.apache.activemq.jaas.textfiledn.user", usersFile))
+	options = append(options, jaasQuoted("org.apache.activemq.jaas.textfiledn.role", rolesFile))
+	return renderLoginModuleEntry(certificateLoginModuleClass, flag, options)
+}
+
+// certificateUsersFiles gives the users and roles files of the inline
+// mappings, the users map to a subject DN or to a /regex/ of it
+func certificateUsersFiles(module *brokerv1beta1.CertificateLoginModuleType) map[string]string {
+	if len(module.Users) == 0 {
+		return nil
+	}
+	var users strings.Builder
+	roles := map[string][]string{}
+	for _, user := range module.Users {
+		subject := user.SubjectDN
+		if user.SubjectDNRegex != "" {
+			subject = "/" + user.SubjectDNRegex + "/"
+		}
+		users.WriteString(propertiesKey(user.Name) + "=" + propertiesValue(subject) + "\n")
+		for _, role := range user.Roles {
+			roles[role] = append(roles[role], user.Name)
+		}
+	}
+	return map[string]string{
+		certificateFilePrefix(module) + certificateUsersKey: users.String(),
+		certificateFilePrefix(module) + certificateRolesKey: renderRolesProperties(roles),
+	}
+}
+
+func propertiesKey(key string) string {
+	return strings.NewReplacer(`\`, `\\`, " ", `\ `, ":", `\:`, "=", `\=`).Replace(key)
+}
+
+func propertiesValue(value string) string {
+	return strings.ReplaceAll(value, `\`, `\\`)
+}
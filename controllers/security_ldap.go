@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/certutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The trust store of the ldaps:// URLs is given to the SSL socket factory of
+// the LDAP login modules, the default trust store of the broker JVM is left
+// alone. login.config refers to its path and password through system
+// properties, the password comes from the trust secret through an env var.
+
+const (
+	ldapLoginModuleClass           = "org.apache.activemq.artemis.spi.core.security.jaas.LDAPLoginModule"
+	ldapSSLSocketFactoryClass      = "org.apache.activemq.artemis.spi.core.security.jaas.LDAPLoginSSLSocketFactory"
+	ldapBindPasswordKey            = "password"
+	ldapTrustStorePasswordKey      = "trustStorePassword"
+	ldapTrustStorePathProperty     = "ldap.trustStorePath"
+	ldapTrustStorePasswordProperty = "ldap.trustStorePassword"
+	ldapTrustStorePasswordEnvVar   = "LDAP_TRUST_STORE_PASSWORD"
+)
+
+func validateLdapLoginModule(module *brokerv1beta1.LdapLoginModuleType) string {
+	connectionURL, err := url.Parse(module.ConnectionURL)
+	if module.ConnectionURL == "" || err != nil || (connectionURL.Scheme != "ldap" && connectionURL.Scheme != "ldaps") {
+		return fmt.Sprintf("LDAP login module %s requires an ldap:// or ldaps:// connectionURL", module.Name)
+	}
+	if module.BindPasswordSecret != "" && module.BindDN == "" {
+		return fmt.Sprintf("LDAP login module %s has a bindPasswordSecret without a bindDN", module.Name)
+	}
+	if module.TrustSecret != "" && connectionURL.Scheme != "ldaps" {
+		return fmt.Sprintf("LDAP login module %s has a trustSecret without an ldaps:// connectionURL", module.Name)
+	}
+	if module.UserBase == "" || module.UserSearchMatching == "" {
+		return fmt.Sprintf("LDAP login module %s requires a userBase and a userSearchMatching", module.Name)
+	}
+	if module.Referral != nil && !containsString([]string{"ignore", "follow", "throw"}, *module.Referral) {
+		return fmt.Sprintf("LDAP login module %s referral %s is not one of ignore, follow or throw", module.Name, *module.Referral)
+	}
+	return ""
+}
+
+// ldapTrustSecret is the trust store for the ldaps:// URLs, the LDAP login
+// modules share its system properties so they have to share it
+func ldapTrustSecret(instance *brokerv1beta1.ActiveMQArtemisSecurity) (string, string, error) {
+	var secret, storeType string
+	for _, module := range instance.Spec.LoginModules.LdapLoginModules {
+		if module.TrustSecret == "" {
+			continue
+		}
+		if secret != "" && (module.TrustSecret != secret || module.TrustStoreType != storeType) {
+			return "", "", fmt.Errorf("LDAP login module %s trustSecret differs from the one of the other LDAP login modules", module.Name)
+		}
+		secret, storeType = module.TrustSecret, module.TrustStoreType
+	}
+	return secret, storeType, nil
+}
+
+func findLdapLoginModule(instance *brokerv1beta1.ActiveMQArtemisSecurity, name *string) *brokerv1beta1.LdapLoginModuleType {
+	if name == nil {
+		return nil
+	}
+	for i, module := range instance.Spec.LoginModules.LdapLoginModules {
+		if module.Name == *name {
+			return &instance.Spec.LoginModules.LdapLoginModules[i]
+		}
+	}
+	return nil
+}
+
+func readLdapBindPasswords(instance *brokerv1beta1.ActiveMQArtemisSecurity, client rtclient.Client) (map[string]string, error) {
+	passwords := map[string]string{}
+	for _, module := range instance.Spec.LoginModules.LdapLoginModules {
+		if module.BindPasswordSecret == "" {
+			continue
+		}
+		secret := &corev1.Secret{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: module.BindPasswordSecret}, secret); err != nil {
+			return nil, fmt.Errorf("unable to find the bind password secret %s of LDAP login module %s, %v", module.BindPasswordSecret, module.Name, err)
+		}
+		password, found := secret.Data[ldapBindPasswordKey]
+		if !found {
+			return nil, fmt.Errorf("the bind password secret %s of LDAP login module %s has no %s key", module.BindPasswordSecret, module.Name, ldapBindPasswordKey)
+		}
+		passwords[module.Name] = string(password)
+	}
+	return passwords, nil
+}
+
+func renderLdapLoginModule(module *brokerv1beta1.LdapLoginModuleType, flag string, debug bool, password string) string {
+	options := []string{}
+	quoted := func(name string, value string) {
+		options = append(options, jaasQuoted(name, value))
+	}
+	unquoted := func(name string, value string) {
+		options = append(options, name+"="+value)
+	}
+
+	if debug {
+		unquoted("debug", "true")
+	}
+	unquoted("initialContextFactory", "com.sun.jndi.ldap.LdapCtxFactory")
+	quoted("connectionURL", module.ConnectionURL)
+	if module.BindDN != "" {
+		unquoted("authentication", "simple")
+		quoted("connectionUsername", module.BindDN)
+		if password != "" {
+			quoted("connectionPassword", password)
+		}
+	} else {
+		unquoted("authentication", "none")
+	}
+	if module.ConnectionTimeout != nil {
+		quoted("connectionTimeout", strconv.FormatInt(*module.ConnectionTimeout, 10))
+	}
+	if module.ReadTimeout != nil {
+		quoted("readTimeout", strconv.FormatInt(*module.ReadTimeout, 10))
+	}
+	quoted("userBase", module.UserBase)
+	quoted("userSearchMatching", module.UserSearchMatching)
+	if module.UserSearchSubtree != nil {
+		unquoted("userSearchSubtree", strconv.FormatBool(*module.UserSearchSubtree))
+	}
+	if module.RoleBase != "" {
+		quoted("roleBase", module.RoleBase)
+	}
+	if module.RoleName != "" {
+		quoted("roleName", module.RoleName)
+	}
+	if module.RoleSearchMatching != "" {
+		quoted("roleSearchMatching", module.RoleSearchMatching)
+	}
+	if module.RoleSearchSubtree != nil {
+		unquoted("roleSearchSubtree", strconv.FormatBool(*module.RoleSearchSubtree))
+	}
+	if module.Referral != nil {
+		unquoted("referral", *module.Referral)
+	}
+	if module.TrustSecret != "" {
+		quoted("java.naming.ldap.factory.socket", ldapSSLSocketFactoryClass)
+		quoted("trustStorePath", "${"+ldapTrustStorePathProperty+"}")
+		quoted("trustStorePassword", "${"+ldapTrustStorePasswordProperty+"}")
+		if module.TrustStoreType != "" {
+			quoted("trustStoreType", module.TrustStoreType)
+		}
+	}
+
+	return renderLoginModuleEntry(ldapLoginModuleClass, flag, options)
+}
+
+// resolveLdapTrust gives the trust secret of the LDAP login modules, the java
+// args of the system properties login.config refers to and the env var of the
+// trust store password
+func resolveLdapTrust(instance *brokerv1beta1.ActiveMQArtemisSecurity, client rtclient.Client) (string, string, *corev1.EnvVar, *metav1.Condition) {
+	invalid := func(reason string, format string, args ...interface{}) (string, string, *corev1.EnvVar, *metav1.Condition) {
+		return "", "", nil, &metav1.Condition{
+			Type:    brokerv1beta1.ValidConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+
+	secretName, storeType, err := ldapTrustSecret(instance)
+	if err != nil {
+		return invalid(brokerv1beta1.ValidConditionInvalidLoginModuleReason, err.Error())
+	}
+	if secretName == "" {
+		return "", "", nil, nil
+	}
+	secret := &corev1.Secret{}
+	if err := client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: secretName}, secret); err != nil {
+		return invalid(brokerv1beta1.ValidConditionMissingResourcesReason, "unable to find the LDAP trust secret %s, %v", secretName, err)
+	}
+	trustArgs, err := certutil.GetTrustArgumentsFromSecret(secret, storeType)
+	if err != nil {
+		return invalid(brokerv1beta1.ValidConditionInvalidCertSecretReason, "the LDAP trust secret %s can't be used, %v", secretName, err)
+	}
+	// the socket factory of the login modules can't load a PEM bundle, the other trust secrets have a password
+	if trustArgs.TrustStoreType == "PEM" || trustArgs.TrustStorePassword == nil {
+		return invalid(brokerv1beta1.ValidConditionInvalidCertSecretReason, "the LDAP trust secret %s has to hold a JKS or PKCS12 trust store", secretName)
+	}
+
+	// the password stays out of the pod spec, the launch script expands the env var
+	javaArgs := "-D" + ldapTrustStorePathProperty + "=" + trustArgs.TrustStorePath + " -D" + ldapTrustStorePasswordProperty + "=${" + ldapTrustStorePasswordEnvVar + "}"
+	password := &corev1.EnvVar{Name: ldapTrustStorePasswordEnvVar, Value: *trustArgs.TrustStorePassword}
+	if _, found := secret.Data[ldapTrustStorePasswordKey]; found {
+		password.Value = ""
+		password.ValueFrom = &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  ldapTrustStorePasswordKey,
+			},
+		}
+	}
+	return secretName, javaArgs, password, nil
+}
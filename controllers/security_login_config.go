@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The yacfg profile of the init image doesn't know the LDAP and certificate
+// login modules. The operator renders their login.config entries into the
+// security secret, and the init container adds them to the realms that
+// config-security.sh generates.
+
+const defaultBrokerRealm = "activemq"
+
+var securityRealmRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// securityRealms gives the domain of each realm, the broker domain defaults
+// to the realm of the broker
+func securityRealms(instance *brokerv1beta1.ActiveMQArtemisSecurity) map[string]*brokerv1beta1.BrokerDomainType {
+	realms := map[string]*brokerv1beta1.BrokerDomainType{}
+	domains := &instance.Spec.SecurityDomains
+	brokerRealm := defaultBrokerRealm
+	if domains.BrokerDomain.Name != nil {
+		brokerRealm = *domains.BrokerDomain.Name
+	}
+	realms[brokerRealm] = &domains.BrokerDomain
+	if domains.ConsoleDomain.Name != nil {
+		realms[*domains.ConsoleDomain.Name] = &domains.ConsoleDomain
+	}
+	return realms
+}
+
+// isOperatorLoginModule tells if the operator renders the referenced login
+// module rather than yacfg
+func isOperatorLoginModule(instance *brokerv1beta1.ActiveMQArtemisSecurity, name *string) bool {
+	return findLdapLoginModule(instance, name) != nil || findCertificateLoginModule(instance, name) != nil
+}
+
+// validateOperatorRealms checks that a domain with login modules rendered by
+// the operator has a realm the init container can add them to
+func validateOperatorRealms(instance *brokerv1beta1.ActiveMQArtemisSecurity) string {
+	domains := &instance.Spec.SecurityDomains
+	for domainName, domain := range map[string]*brokerv1beta1.BrokerDomainType{"brokerDomain": &domains.BrokerDomain, "consoleDomain": &domains.ConsoleDomain} {
+		usesOperatorModules := false
+		for _, reference := range domain.LoginModules {
+			usesOperatorModules = usesOperatorModules || isOperatorLoginModule(instance, reference.Name)
+			if domainName == "consoleDomain" && findCertificateLoginModule(instance, reference.Name) != nil {
+				return fmt.Sprintf("certificate login module %s can only be used by the brokerDomain", *reference.Name)
+			}
+		}
+		if !usesOperatorModules {
+			continue
+		}
+		if domain.Name == nil && domainName == "consoleDomain" {
+			return "consoleDomain requires a name to use an LDAP login module"
+		}
+		if domain.Name != nil && !securityRealmRegexp.MatchString(*domain.Name) {
+			return fmt.Sprintf("%s name %s can only have letters, digits, _, . and -", domainName, *domain.Name)
+		}
+	}
+	return ""
+}
+
+// validateSecurityResources checks the secrets the login modules rendered by
+// the operator refer to, it gives the LDAP bind passwords
+func validateSecurityResources(instance *brokerv1beta1.ActiveMQArtemisSecurity, client rtclient.Client) (map[string]string, *metav1.Condition) {
+	passwords, err := readLdapBindPasswords(instance, client)
+	if err == nil {
+		err = checkCertificateMappingsSecrets(instance, client)
+	}
+	if err == nil {
+		err = checkPasswordCodecKey(instance, client)
+	}
+	if err != nil {
+		return nil, &metav1.Condition{
+			Type:    brokerv1beta1.ValidConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  brokerv1beta1.ValidConditionMissingResourcesReason,
+			Message: err.Error(),
+		}
+	}
+	if _, condition := resolveSecuritySecrets(instance, client); condition != nil {
+		return nil, condition
+	}
+	return passwords, nil
+}
+
+func loginModulesKey(realm string) string {
+	return "login-modules-" + realm + ".config"
+}
+
+// renderOperatorLoginModules gives the login.config entries of each realm and
+// the files they refer to, keyed by the name they are stored under
+func renderOperatorLoginModules(instance *brokerv1beta1.ActiveMQArtemisSecurity, passwords map[string]string) map[string]string {
+	files := map[string]string{}
+	for realm, domain := range securityRealms(instance) {
+		entries := []string{}
+		for _, reference := range domain.LoginModules {
+			flag := "required"
+			if reference.Flag != nil {
+				flag = *reference.Flag
+			}
+			debug := reference.Debug != nil && *reference.Debug
+			if module := findLdapLoginModule(instance, reference.Name); module != nil {
+				debug = debug || (module.Debug != nil && *module.Debug)
+				entries = append(entries, renderLdapLoginModule(module, flag, debug, passwords[module.Name]))
+			}
+			if module := findCertificateLoginModule(instance, reference.Name); module != nil {
+				debug = debug || (module.Debug != nil && *module.Debug)
+				entries = append(entries, renderCertificateLoginModule(module, flag, debug))
+			}
+		}
+		if len(entries) > 0 {
+			files[loginModulesKey(realm)] = strings.Join(entries, "")
+		}
+	}
+	for i := range instance.Spec.LoginModules.CertificateLoginModules {
+		for key, value := range certificateUsersFiles(&instance.Spec.LoginModules.CertificateLoginModules[i]) {
+			files[key] = value
+		}
+	}
+	return files
+}
+
+// renderLoginModuleEntry gives a login.config entry with an option per line
+func renderLoginModuleEntry(class string, flag string, options []string) string {
+	var entry strings.Builder
+	entry.WriteString("    " + class + " " + flag + "\n")
+	for i, option := range options {
+		entry.WriteString("        " + option)
+		if i == len(options)-1 {
+			entry.WriteString(";")
+		}
+		entry.WriteString("\n")
+	}
+	return entry.String()
+}
+
+// jaasQuoted quotes an option value of login.config
+func jaasQuoted(name string, value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return fmt.Sprintf(`%s="%s"`, name, strings.ReplaceAll(value, `"`, `\"`))
+}
+
+// withoutOperatorLoginModules is the CR yacfg renders, it can't render the
+// login modules the operator does
+func withoutOperatorLoginModules(instance *brokerv1beta1.ActiveMQArtemisSecurity) *brokerv1beta1.ActiveMQArtemisSecurity {
+	loginModules := &instance.Spec.LoginModules
+	if len(loginModules.LdapLoginModules) == 0 && len(loginModules.CertificateLoginModules) == 0 {
+		return instance
+	}
+	result := instance.DeepCopy()
+	for _, domain := range []*brokerv1beta1.BrokerDomainType{&result.Spec.SecurityDomains.BrokerDomain, &result.Spec.SecurityDomains.ConsoleDomain} {
+		references := []brokerv1beta1.LoginModuleReferenceType{}
+		for _, reference := range domain.LoginModules {
+			if !isOperatorLoginModule(instance, reference.Name) {
+				references = append(references, reference)
+			}
+		}
+		domain.LoginModules = references
+	}
+	result.Spec.LoginModules.LdapLoginModules = nil
+	result.Spec.LoginModules.CertificateLoginModules = nil
+	return result
+}
+
+// operatorLoginConfigCommands copy the files of the login modules next to the
+// login.config generated by config-security.sh and add their entries at the
+// end of their realm, or add the realm
+func operatorLoginConfigCommands(instance *brokerv1beta1.ActiveMQArtemisSecurity, secretDir string, etcDir string) []string {
+	files := renderOperatorLoginModules(instance, nil)
+	realmCommands := []string{}
+	fileCommands := []string{}
+	loginConfig := etcDir + "/login.config"
+	for key := range files {
+		if !strings.HasPrefix(key, "login-modules-") {
+			fileCommands = append(fileCommands, "cp "+secretDir+"/"+key+" "+etcDir+"/"+key)
+			continue
+		}
+		realm := strings.TrimSuffix(strings.TrimPrefix(key, "login-modules-"), ".config")
+		entries := secretDir + "/" + key
+		realmCommands = append(realmCommands, fmt.Sprintf(`awk -v realm="%s" -v entries="%s" '`+
+			`$1 == realm || $1 == realm "{" { inRealm = 1; found = 1 } `+
+			`inRealm && /^[[:space:]]*};/ { while ((getline line < entries) > 0) print line; inRealm = 0 } `+
+			`{ print } `+
+			`END { if (!found) { print realm " {"; while ((getline line < entries) > 0) print line; print "};" } }' `+
+			`%s > %s.operator && mv %s.operator %s`, realm, entries, loginConfig, loginConfig, loginConfig, loginConfig))
+	}
+	// the maps have no order
+	sort.Strings(fileCommands)
+	sort.Strings(realmCommands)
+	return append(fileCommands, realmCommands...)
+}
+
+// securitySecrets are the secrets of the login modules rendered by the
+// operator that the brokers mount, with the java args and the env vars that
+// refer to them
+type securitySecrets struct {
+	secrets  []string
+	javaArgs string
+	env      []corev1.EnvVar
+}
+
+// resolveSecuritySecretsFor gives the secrets of the security CR that applies
+// to the broker CR
+func resolveSecuritySecretsFor(customResource *brokerv1beta1.ActiveMQArtemis, client rtclient.Client, log logr.Logger) *securitySecrets {
+	handler, ok := GetBrokerConfigHandler(types.NamespacedName{Namespace: customResource.Namespace, Name: customResource.Name}).(*ActiveMQArtemisSecurityConfigHandler)
+	if !ok {
+		return nil
+	}
+	resolved, condition := resolveSecuritySecrets(handler.SecurityCR, client)
+	if condition != nil {
+		log.V(1).Info("skipping the secrets of the login modules", "security", handler.SecurityCR.Name, "reason", condition.Message)
+		return nil
+	}
+	return resolved
+}
+
+func resolveSecuritySecrets(instance *brokerv1beta1.ActiveMQArtemisSecurity, client rtclient.Client) (*securitySecrets, *metav1.Condition) {
+	resolved := &securitySecrets{}
+	trustSecret, javaArgs, trustPassword, condition := resolveLdapTrust(instance, client)
+	if condition != nil {
+		return nil, condition
+	}
+	if trustSecret != "" {
+		resolved.secrets = append(resolved.secrets, trustSecret)
+		resolved.javaArgs = javaArgs
+		resolved.env = append(resolved.env, *trustPassword)
+	}
+	for _, module := range instance.Spec.LoginModules.CertificateLoginModules {
+		if module.MappingsSecret != "" && !containsString(resolved.secrets, module.MappingsSecret) {
+			resolved.secrets = append(resolved.secrets, module.MappingsSecret)
+		}
+	}
+	if len(resolved.secrets) == 0 {
+		return nil, nil
+	}
+	return resolved, nil
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"golang.org/x/crypto/blowfish"
+	"golang.org/x/crypto/pbkdf2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// With password masking the brokers never read a password of the CR in plain
+// text. The user passwords are hashed the way the one-way mode of the
+// DefaultSensitiveStringCodec of the broker does, the properties login module
+// checks them without the key. The other passwords have to be given to the
+// broker, they are masked like its two-way mode does with a key that the
+// brokers get in their environment from the key secret.
+
+const (
+	passwordCodecKeySuffix = "-password-codec"
+	passwordCodecKeyKey    = "codec-key"
+	passwordCodecKeyLength = 32
+	passwordCodecKeyEnvVar = "ARTEMIS_DEFAULT_SENSITIVE_STRING_CODEC_KEY"
+	defaultPasswordCodec   = "org.apache.activemq.artemis.utils.DefaultSensitiveStringCodec"
+
+	// the defaults of the one-way mode of the codec
+	passwordHashIterations = 1024
+	passwordHashSaltLength = 32
+	passwordHashKeyLength  = 64
+)
+
+func getPasswordCodecKeySecretName(instance *brokerv1beta1.ActiveMQArtemisSecurity) string {
+	if instance.Spec.PasswordMasking != nil && instance.Spec.PasswordMasking.KeySecret != "" {
+		return instance.Spec.PasswordMasking.KeySecret
+	}
+	return instance.Name + passwordCodecKeySuffix
+}
+
+func isPasswordMasked(password string) bool {
+	return strings.HasPrefix(password, "ENC(") && strings.HasSuffix(password, ")")
+}
+
+// checkPasswordCodecKey fails for a key the codec can't use, a missing key is
+// generated
+func checkPasswordCodecKey(instance *brokerv1beta1.ActiveMQArtemisSecurity, client rtclient.Client) error {
+	if instance.Spec.PasswordMasking == nil {
+		return nil
+	}
+	secret := &corev1.Secret{}
+	name := getPasswordCodecKeySecretName(instance)
+	if err := client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: name}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to read the password codec key secret %s, %v", name, err)
+	}
+	if key, found := secret.Data[passwordCodecKeyKey]; found {
+		if _, err := blowfish.NewCipher(key); err != nil {
+			return fmt.Errorf("the %s key of the password codec key secret %s has to be 1 to 56 bytes", passwordCodecKeyKey, name)
+		}
+	}
+	return nil
+}
+
+// hashPassword gives the ENC(iterations:salt:hash) value of the codec, the salt
+// comes from the key and the user so that the hash only changes with them
+func hashPassword(password string, key string, user string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(user))
+	salt := mac.Sum(nil)[:passwordHashSaltLength]
+	hash := pbkdf2.Key([]byte(password), salt, passwordHashIterations, passwordHashKeyLength, sha1.New)
+	return fmt.Sprintf("ENC(%d:%s:%s)", passwordHashIterations, hex.EncodeToString(salt), hex.EncodeToString(hash))
+}
+
+// maskPassword gives the ENC() value of the codec, blowfish in ECB mode with
+// PKCS5 padding written as the java BigInteger of the encrypted bytes
+func maskPassword(password string, key string) (string, error) {
+	cipher, err := blowfish.NewCipher([]byte(key))
+	if err != nil {
+		return "", err
+	}
+	padding := blowfish.BlockSize - len(password)%blowfish.BlockSize
+	data := append([]byte(password), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	for block := 0; block < len(data); block += blowfish.BlockSize {
+		cipher.Encrypt(data[block:block+blowfish.BlockSize], data[block:block+blowfish.BlockSize])
+	}
+	value := new(big.Int).SetBytes(data)
+	if data[0]&0x80 != 0 {
+		value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(len(data)*8)))
+	}
+	return "ENC(" + value.Text(16) + ")", nil
+}
+
+// maskSecurityPasswords gives the CR and the LDAP bind passwords the way the
+// brokers read them, a password the CR masked already is kept
+func maskSecurityPasswords(instance *brokerv1beta1.ActiveMQArtemisSecurity, ldapPasswords map[string]string, key string) (*brokerv1beta1.ActiveMQArtemisSecurity, map[string]string, error) {
+	if instance.Spec.PasswordMasking == nil {
+		return instance, ldapPasswords, nil
+	}
+	result := instance.DeepCopy()
+	for i := range result.Spec.LoginModules.PropertiesLoginModules {
+		module := &result.Spec.LoginModules.PropertiesLoginModules[i]
+		for j := range module.Users {
+			user := &module.Users[j]
+			if user.Password != nil && !isPasswordMasked(*user.Password) {
+				hashed := hashPassword(*user.Password, key, module.Name+"/"+user.Name)
+				user.Password = &hashed
+			}
+		}
+	}
+
+	mask := func(password *string) (*string, error) {
+		if password == nil || isPasswordMasked(*password) {
+			return password, nil
+		}
+		masked, err := maskPassword(*password, key)
+		return &masked, err
+	}
+	var err error
+	// a connector with its own codec has its passwords masked already
+	connector := &result.Spec.SecuritySettings.Management.Connector
+	if connector.PasswordCodec == nil || *connector.PasswordCodec == defaultPasswordCodec {
+		if connector.KeyStorePassword, err = mask(connector.KeyStorePassword); err != nil {
+			return nil, nil, err
+		}
+		if connector.TrustStorePassword, err = mask(connector.TrustStorePassword); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var maskedLdapPasswords map[string]string
+	if ldapPasswords != nil {
+		maskedLdapPasswords = map[string]string{}
+		for name, password := range ldapPasswords {
+			masked, err := mask(&password)
+			if err != nil {
+				return nil, nil, err
+			}
+			maskedLdapPasswords[name] = *masked
+		}
+	}
+	return result, maskedLdapPasswords, nil
+}
+
+// processCrMaskedPasswords gives the CR with its passwords and the LDAP bind
+// passwords, masked when the CR asks for it
+func (r *ActiveMQArtemisSecurityConfigHandler) processCrMaskedPasswords(ldapPasswords map[string]string) (*brokerv1beta1.ActiveMQArtemisSecurity, map[string]string, error) {
+	instance := r.processCrPasswords()
+	if instance.Spec.PasswordMasking == nil {
+		return instance, ldapPasswords, nil
+	}
+	key := r.getGeneratedValue(getPasswordCodecKeySecretName(instance), passwordCodecKeyKey, passwordCodecKeyLength)
+	return maskSecurityPasswords(instance, ldapPasswords, *key)
+}
+
+// securityCodecKeyEnvVar gives the brokers the key of the masked passwords of
+// the security CR that applies to the broker CR
+func securityCodecKeyEnvVar(customResource *brokerv1beta1.ActiveMQArtemis) *corev1.EnvVar {
+	handler, ok := GetBrokerConfigHandler(types.NamespacedName{Namespace: customResource.Namespace, Name: customResource.Name}).(*ActiveMQArtemisSecurityConfigHandler)
+	if !ok || handler.SecurityCR.Spec.PasswordMasking == nil {
+		return nil
+	}
+	return &corev1.EnvVar{
+		Name: passwordCodecKeyEnvVar,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: getPasswordCodecKeySecretName(handler.SecurityCR)},
+				Key:                  passwordCodecKeyKey,
+			},
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"fmt"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	jc "github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia_client"
+	"github.com/go-logr/logr"
+)
+
+// yacfg doesn't know the resource limits, with both delivery modes they are
+// broker properties of the security properties secret. The brokers only read
+// them when they start, so a change restarts them like a new login.config.
+
+const resourceLimitSettingsKey = "resourceLimitSettings."
+
+func validateResourceLimits(instance *brokerv1beta1.ActiveMQArtemisSecurity) string {
+	users := map[string]bool{}
+	for _, limit := range instance.Spec.ResourceLimits {
+		if limit.User == "" {
+			return "a resource limit requires a user"
+		}
+		if users[limit.User] {
+			return fmt.Sprintf("user %s has more than one resource limit", limit.User)
+		}
+		users[limit.User] = true
+		if limit.MaxConnections == nil && limit.MaxQueues == nil {
+			return fmt.Sprintf("resource limit of user %s requires maxConnections or maxQueues", limit.User)
+		}
+		if limit.MaxConnections != nil && *limit.MaxConnections < -1 {
+			return fmt.Sprintf("resource limit of user %s maxConnections has to be -1 or more", limit.User)
+		}
+		if limit.MaxQueues != nil && *limit.MaxQueues < -1 {
+			return fmt.Sprintf("resource limit of user %s maxQueues has to be -1 or more", limit.User)
+		}
+	}
+	return ""
+}
+
+// resourceLimitProperties give the settings of each user, the broker keys
+// them by the match
+func resourceLimitProperties(instance *brokerv1beta1.ActiveMQArtemisSecurity) []string {
+	props := []string{}
+	for _, limit := range instance.Spec.ResourceLimits {
+		prefix := resourceLimitSettingsKey + propertyKeySegment(limit.User) + "."
+		props = append(props, prefix+"match="+propertiesValue(limit.User))
+		if limit.MaxConnections != nil {
+			props = append(props, fmt.Sprintf("%smaxConnections=%d", prefix, *limit.MaxConnections))
+		}
+		if limit.MaxQueues != nil {
+			props = append(props, fmt.Sprintf("%smaxQueues=%d", prefix, *limit.MaxQueues))
+		}
+	}
+	return props
+}
+
+// securityResourceUsage reads what each user with a resource limit uses on a
+// broker, a user is left out when the broker can't tell
+func securityResourceUsage(instance *brokerv1beta1.ActiveMQArtemisSecurity, jk *jc.JkInfo, reqLogger logr.Logger) []brokerv1beta1.ResourceUsageType {
+	var usage []brokerv1beta1.ResourceUsageType
+	for _, limit := range instance.Spec.ResourceLimits {
+		connections, err := jk.Artemis.CountUserConnections(limit.User)
+		if err != nil {
+			reqLogger.V(1).Info("unable to count the connections of a user", "user", limit.User, "Ordinal", jk.Ordinal, "error", err)
+			continue
+		}
+		queues, err := jk.Artemis.CountUserQueues(limit.User)
+		if err != nil {
+			reqLogger.V(1).Info("unable to count the queues of a user", "user", limit.User, "Ordinal", jk.Ordinal, "error", err)
+			continue
+		}
+		usage = append(usage, brokerv1beta1.ResourceUsageType{
+			User:        limit.User,
+			Connections: connections,
+			Queues:      queues,
+		})
+	}
+	return usage
+}
@@ -0,0 +1,494 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/resources/secrets"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/common"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// A security CR with the Secrets delivery mode is not rendered by yacfg in the
+// init container. The broker reconciler renders the login.config of the CR and
+// the files of its login modules into a jaas config secret, and its security
+// settings into a properties secret mounted next to the broker properties. The
+// brokers reload the users, the roles and the security settings when the
+// secrets change, only a change of login.config restarts them. The
+// JaasPropertiesApplied and BrokerPropertiesApplied conditions of the broker
+// CR tell when they are loaded.
+
+const (
+	securityJaasConfigSuffix = "-security" + jaasConfigSuffix
+	securityPropertiesSuffix = "-security-props"
+	SecurityPropertiesName   = "security.properties"
+	securityRolesKey         = "securityRoles."
+
+	propertiesLoginModuleClass          = "org.apache.activemq.artemis.spi.core.security.jaas.PropertiesLoginModule"
+	guestLoginModuleClass               = "org.apache.activemq.artemis.spi.core.security.jaas.GuestLoginModule"
+	principalConversionLoginModuleClass = "org.apache.activemq.artemis.spi.core.security.jaas.PrincipalConversionLoginModule"
+	rolePrincipalClass                  = "org.apache.activemq.artemis.spi.core.security.jaas.RolePrincipal"
+
+	// the users of the broker instance, the operator is one of them
+	brokerInstanceEtcDir = "/home/jboss/amq-broker/etc"
+)
+
+var keycloakLoginModuleClasses = map[string]string{
+	"directAccess": "org.keycloak.adapters.jaas.DirectAccessGrantsLoginModule",
+	"bearerToken":  "org.keycloak.adapters.jaas.BearerTokenLoginModule",
+}
+
+func deliveredBySecrets(instance *brokerv1beta1.ActiveMQArtemisSecurity) bool {
+	return instance.Spec.DeliveryMode != nil && *instance.Spec.DeliveryMode == brokerv1beta1.SecurityDeliveryModes.Secrets
+}
+
+func getSecurityJaasConfigSecretName(customResource *brokerv1beta1.ActiveMQArtemis) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: customResource.Namespace,
+		Name:      customResource.Name + securityJaasConfigSuffix,
+	}
+}
+
+func getSecurityPropertiesSecretName(customResource *brokerv1beta1.ActiveMQArtemis) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: customResource.Namespace,
+		Name:      customResource.Name + securityPropertiesSuffix,
+	}
+}
+
+// validateSecretsDelivery checks what the operator can't render, the domains
+// have to fit the realms of the broker instance
+func validateSecretsDelivery(instance *brokerv1beta1.ActiveMQArtemisSecurity) string {
+	if !deliveredBySecrets(instance) {
+		return ""
+	}
+	if !reflect.DeepEqual(instance.Spec.SecuritySettings.Management, brokerv1beta1.ManagementSecuritySettingsType{}) {
+		return "management security settings require the InitContainer delivery mode"
+	}
+	domains := &instance.Spec.SecurityDomains
+	if domains.BrokerDomain.Name != nil && *domains.BrokerDomain.Name != defaultBrokerRealm {
+		return fmt.Sprintf("brokerDomain name has to be %s, the realm of the brokers, with the Secrets delivery mode", defaultBrokerRealm)
+	}
+	if len(domains.ConsoleDomain.LoginModules) > 0 && (domains.ConsoleDomain.Name == nil || *domains.ConsoleDomain.Name == defaultBrokerRealm) {
+		return fmt.Sprintf("consoleDomain requires a name other than %s with the Secrets delivery mode", defaultBrokerRealm)
+	}
+	if domains.ConsoleDomain.Name != nil && !securityRealmRegexp.MatchString(*domains.ConsoleDomain.Name) {
+		return fmt.Sprintf("consoleDomain name %s can only have letters, digits, _, . and -", *domains.ConsoleDomain.Name)
+	}
+	// the modules name the files they are rendered to
+	loginModules := &instance.Spec.LoginModules
+	names := []string{}
+	for _, module := range loginModules.PropertiesLoginModules {
+		names = append(names, module.Name)
+	}
+	for _, module := range loginModules.KeycloakLoginModules {
+		if module.ModuleType == nil || keycloakLoginModuleClasses[*module.ModuleType] == "" {
+			return fmt.Sprintf("keycloak login module %s moduleType has to be directAccess or bearerToken with the Secrets delivery mode", module.Name)
+		}
+		names = append(names, module.Name)
+	}
+	for _, module := range loginModules.CertificateLoginModules {
+		names = append(names, module.Name)
+	}
+	for _, name := range names {
+		if !securityRealmRegexp.MatchString(name) {
+			return fmt.Sprintf("login module name %s can only have letters, digits, _, . and - with the Secrets delivery mode", name)
+		}
+	}
+	return ""
+}
+
+func propertiesUsersFile(module *brokerv1beta1.PropertiesLoginModuleType) string {
+	return module.Name + "-users.properties"
+}
+
+func propertiesRolesFile(module *brokerv1beta1.PropertiesLoginModuleType) string {
+	return module.Name + "-roles.properties"
+}
+
+// keycloak configs are not reloaded, the underscore keeps them out of the
+// JaasPropertiesApplied condition
+func keycloakConfigFile(module *brokerv1beta1.KeycloakLoginModuleType) string {
+	return "_" + module.Name + "-keycloak.json"
+}
+
+// renderSecurityJaasConfig gives login.config and the files its login modules
+// refer to, the CR has its passwords and the files are mounted in secretDir
+func renderSecurityJaasConfig(instance *brokerv1beta1.ActiveMQArtemisSecurity, ldapPasswords map[string]string, secretDir string) map[string]string {
+	files := map[string]string{
+		JaasConfigKey: renderSecurityLoginConfig(instance, ldapPasswords, secretDir),
+	}
+	loginModules := &instance.Spec.LoginModules
+	for i := range loginModules.PropertiesLoginModules {
+		module := &loginModules.PropertiesLoginModules[i]
+		var users strings.Builder
+		roles := map[string][]string{}
+		for _, user := range module.Users {
+			password := ""
+			if user.Password != nil {
+				password = *user.Password
+			}
+			users.WriteString(propertiesKey(user.Name) + "=" + propertiesValue(password) + "\n")
+			for _, role := range user.Roles {
+				roles[role] = append(roles[role], user.Name)
+			}
+		}
+		files[propertiesUsersFile(module)] = users.String()
+		files[propertiesRolesFile(module)] = renderRolesProperties(roles)
+	}
+	for i := range loginModules.KeycloakLoginModules {
+		module := &loginModules.KeycloakLoginModules[i]
+		files[keycloakConfigFile(module)] = renderKeycloakConfig(&module.Configuration)
+	}
+	for i := range loginModules.CertificateLoginModules {
+		for key, value := range certificateUsersFiles(&loginModules.CertificateLoginModules[i]) {
+			files[key] = value
+		}
+	}
+	return files
+}
+
+func renderRolesProperties(roles map[string][]string) string {
+	names := []string{}
+	for role := range roles {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+	var rolesFile strings.Builder
+	for _, role := range names {
+		rolesFile.WriteString(propertiesKey(role) + "=" + propertiesValue(strings.Join(roles[role], ",")) + "\n")
+	}
+	return rolesFile.String()
+}
+
+// renderSecurityLoginConfig gives a realm for each domain. The realms start
+// with the users of the broker instance so that the operator keeps its access
+// to the brokers
+func renderSecurityLoginConfig(instance *brokerv1beta1.ActiveMQArtemisSecurity, ldapPasswords map[string]string, secretDir string) string {
+	realms := securityRealms(instance)
+	names := []string{}
+	for realm := range realms {
+		names = append(names, realm)
+	}
+	sort.Strings(names)
+
+	var loginConfig strings.Builder
+	for _, realm := range names {
+		loginConfig.WriteString(realm + " {\n")
+		loginConfig.WriteString(renderLoginModuleEntry(propertiesLoginModuleClass, "sufficient", []string{
+			"reload=true",
+			jaasQuoted("org.apache.activemq.jaas.properties.user", "artemis-users.properties"),
+			jaasQuoted("org.apache.activemq.jaas.properties.role", "artemis-roles.properties"),
+			jaasQuoted("baseDir", brokerInstanceEtcDir),
+		}))
+		usesKeycloak := false
+		for _, reference := range realms[realm].LoginModules {
+			entry, keycloak := renderLoginModuleReference(instance, &reference, ldapPasswords, secretDir)
+			loginConfig.WriteString(entry)
+			usesKeycloak = usesKeycloak || keycloak
+		}
+		if usesKeycloak {
+			loginConfig.WriteString(renderLoginModuleEntry(principalConversionLoginModuleClass, "required", []string{
+				"principalClassList=org.keycloak.KeycloakPrincipal",
+			}))
+		}
+		loginConfig.WriteString("};\n")
+	}
+	return loginConfig.String()
+}
+
+// renderLoginModuleReference gives the entry of a login module of a domain,
+// and whether it is a keycloak one
+func renderLoginModuleReference(instance *brokerv1beta1.ActiveMQArtemisSecurity, reference *brokerv1beta1.LoginModuleReferenceType, ldapPasswords map[string]string, secretDir string) (string, bool) {
+	flag := "required"
+	if reference.Flag != nil {
+		flag = *reference.Flag
+	}
+	debug := reference.Debug != nil && *reference.Debug
+	options := []string{}
+	if debug {
+		options = append(options, "debug=true")
+	}
+
+	if module := findLdapLoginModule(instance, reference.Name); module != nil {
+		return renderLdapLoginModule(module, flag, debug || (module.Debug != nil && *module.Debug), ldapPasswords[module.Name]), false
+	}
+	if module := findCertificateLoginModule(instance, reference.Name); module != nil {
+		return renderCertificateLoginModule(module, flag, debug || (module.Debug != nil && *module.Debug)), false
+	}
+	for i := range instance.Spec.LoginModules.PropertiesLoginModules {
+		module := &instance.Spec.LoginModules.PropertiesLoginModules[i]
+		if module.Name != *reference.Name {
+			continue
+		}
+		if reference.Reload == nil || *reference.Reload {
+			options = append(options, "reload=true")
+		}
+		options = append(options, jaasQuoted("org.apache.activemq.jaas.properties.user", propertiesUsersFile(module)))
+		options = append(options, jaasQuoted("org.apache.activemq.jaas.properties.role", propertiesRolesFile(module)))
+		return renderLoginModuleEntry(propertiesLoginModuleClass, flag, options), false
+	}
+	for _, module := range instance.Spec.LoginModules.GuestLoginModules {
+		if module.Name != *reference.Name {
+			continue
+		}
+		if module.GuestUser != nil {
+			options = append(options, jaasQuoted("org.apache.activemq.jaas.guest.user", *module.GuestUser))
+		}
+		if module.GuestRole != nil {
+			options = append(options, jaasQuoted("org.apache.activemq.jaas.guest.role", *module.GuestRole))
+		}
+		return renderLoginModuleEntry(guestLoginModuleClass, flag, options), false
+	}
+	for i := range instance.Spec.LoginModules.KeycloakLoginModules {
+		module := &instance.Spec.LoginModules.KeycloakLoginModules[i]
+		if module.Name != *reference.Name {
+			continue
+		}
+		// the keycloak adapter doesn't resolve the file against the login.config directory
+		options = append(options, jaasQuoted("keycloak-config-file", secretDir+"/"+keycloakConfigFile(module)))
+		options = append(options, "role-principal-class="+rolePrincipalClass)
+		return renderLoginModuleEntry(keycloakLoginModuleClasses[*module.ModuleType], flag, options), true
+	}
+	return "", false
+}
+
+// renderKeycloakConfig gives the adapter config file of a keycloak login
+// module, with the names the keycloak adapter uses
+func renderKeycloakConfig(configuration *brokerv1beta1.KeycloakModuleConfigurationType) string {
+	config := map[string]interface{}{}
+	set := func(name string, value interface{}) {
+		if !reflect.ValueOf(value).IsNil() {
+			config[name] = value
+		}
+	}
+	keyValues := func(name string, values []brokerv1beta1.KeyValueType) {
+		if len(values) == 0 {
+			return
+		}
+		result := map[string]string{}
+		for _, kv := range values {
+			if kv.Value != nil {
+				result[kv.Key] = *kv.Value
+			}
+		}
+		config[name] = result
+	}
+
+	set("realm", configuration.Realm)
+	set("realm-public-key", configuration.RealmPublicKey)
+	set("auth-server-url", configuration.AuthServerUrl)
+	set("ssl-required", configuration.SslRequired)
+	set("resource", configuration.Resource)
+	set("public-client", configuration.PublicClient)
+	keyValues("credentials", configuration.Credentials)
+	set("use-resource-role-mappings", configuration.UseResourceRoleMappings)
+	set("enable-cors", configuration.EnableCors)
+	set("cors-max-age", configuration.CorsMaxAge)
+	set("cors-allowed-methods", configuration.CorsAllowedMethods)
+	set("cors-allowed-headers", configuration.CorsAllowedHeaders)
+	set("cors-exposed-headers", configuration.CorsExposedHeaders)
+	set("expose-token", configuration.ExposeToken)
+	set("bearer-only", configuration.BearerOnly)
+	set("autodetect-bearer-only", configuration.AutoDetectBearerOnly)
+	set("connection-pool-size", configuration.ConnectionPoolSize)
+	set("allow-any-hostname", configuration.AllowAnyHostName)
+	set("disable-trust-manager", configuration.DisableTrustManager)
+	set("truststore", configuration.TrustStore)
+	set("truststore-password", configuration.TrustStorePassword)
+	set("client-keystore", configuration.ClientKeyStore)
+	set("client-keystore-password", configuration.ClientKeyStorePassword)
+	set("client-key-password", configuration.ClientKeyPassword)
+	set("always-refresh-token", configuration.AlwaysRefreshToken)
+	set("register-node-at-startup", configuration.RegisterNodeAtStartup)
+	set("register-node-period", configuration.RegisterNodePeriod)
+	set("token-store", configuration.TokenStore)
+	set("token-cookie-path", configuration.TokenCookiePath)
+	set("principal-attribute", configuration.PrincipalAttribute)
+	set("proxy-url", configuration.ProxyUrl)
+	set("turn-off-change-session-id-on-login", configuration.TurnOffChangeSessionIdOnLogin)
+	set("token-minimum-time-to-live", configuration.TokenMinimumTimeToLive)
+	set("min-time-between-jwks-requests", configuration.MinTimeBetweenJwksRequests)
+	set("public-key-cache-ttl", configuration.PublicKeyCacheTtl)
+	set("ignore-oauth-query-parameter", configuration.IgnoreOauthQueryParameter)
+	set("verify-token-audience", configuration.VerifyTokenAudience)
+	set("enable-basic-auth", configuration.EnableBasicAuth)
+	set("confidential-port", configuration.ConfidentialPort)
+	keyValues("redirect-rewrite-rules", configuration.RedirectRewriteRules)
+	set("scope", configuration.Scope)
+
+	// the keys of a map are sorted
+	data, _ := json.MarshalIndent(config, "", "  ")
+	return string(data) + "\n"
+}
+
+// securitySettingsProperties give the roles of each match, the settings of a
+// match replace the ones the brokers have for it
+func securitySettingsProperties(instance *brokerv1beta1.ActiveMQArtemisSecurity) []string {
+	props := []string{}
+	for _, setting := range instance.Spec.SecuritySettings.Broker {
+		for _, permission := range setting.Permissions {
+			for _, role := range permission.Roles {
+				props = append(props, securityRolesKey+propertyKeySegment(setting.Match)+"."+propertyKeySegment(role)+"."+permission.OperationType+"=true")
+			}
+		}
+	}
+	return props
+}
+
+// securityDelivery is what the broker reconciler renders into secrets for a
+// security CR with the Secrets delivery mode or with resource limits
+type securityDelivery struct {
+	// empty when the broker CR has its own -jaas-config extra mount
+	jaasConfig   map[string]string
+	properties   []string
+	consoleRealm string
+}
+
+// securityRestartChecksum tells the brokers to restart when login.config, the
+// ldap bind passwords in it or the resource limits change, the JVM doesn't
+// reload login.config
+func securityRestartChecksum(instance *brokerv1beta1.ActiveMQArtemisSecurity, client rtclient.Client) string {
+	content := []string{renderSecurityLoginConfig(instance, nil, ""), strings.Join(resourceLimitProperties(instance), "\n")}
+	return alder32StringValue(alder32Of(append(content, bindPasswordVersions(instance, client)...)))
+}
+
+// resolveSecurityDeliveryFor renders the config of the security CR that
+// applies to the broker CR when it has the Secrets delivery mode, or only its
+// resource limits with the init container
+func resolveSecurityDeliveryFor(customResource *brokerv1beta1.ActiveMQArtemis, client rtclient.Client, log logr.Logger) *securityDelivery {
+	handler, ok := GetBrokerConfigHandler(types.NamespacedName{Namespace: customResource.Namespace, Name: customResource.Name}).(*ActiveMQArtemisSecurityConfigHandler)
+	if !ok {
+		return nil
+	}
+	limits := resourceLimitProperties(handler.SecurityCR)
+	if !deliveredBySecrets(handler.SecurityCR) {
+		if len(limits) == 0 {
+			return nil
+		}
+		return &securityDelivery{properties: limits}
+	}
+
+	delivery := &securityDelivery{properties: append(securitySettingsProperties(handler.SecurityCR), limits...)}
+	if handler.SecurityCR.Spec.SecurityDomains.ConsoleDomain.Name != nil {
+		delivery.consoleRealm = *handler.SecurityCR.Spec.SecurityDomains.ConsoleDomain.Name
+	}
+	if _, name, found := getConfigExtraMount(customResource, jaasConfigSuffix); found {
+		log.V(1).Info("the jaas config extra mount replaces the login modules of the security CR", "security", handler.SecurityCR.Name, "extraMount", name)
+		delivery.consoleRealm = ""
+		return delivery
+	}
+	ldapPasswords, err := readLdapBindPasswords(handler.SecurityCR, client)
+	if err != nil {
+		log.V(1).Info("skipping the login modules of the security CR", "security", handler.SecurityCR.Name, "reason", err.Error())
+		delivery.consoleRealm = ""
+		return delivery
+	}
+	instance, ldapPasswords, err := handler.processCrMaskedPasswords(ldapPasswords)
+	if err != nil {
+		log.V(1).Info("skipping the login modules of the security CR", "security", handler.SecurityCR.Name, "reason", err.Error())
+		delivery.consoleRealm = ""
+		return delivery
+	}
+	secretDir := secretPathBase + getSecurityJaasConfigSecretName(customResource).Name
+	delivery.jaasConfig = renderSecurityJaasConfig(instance, ldapPasswords, secretDir)
+	return delivery
+}
+
+// addResourceForSecurity gives the names of the jaas config secret and of the
+// security properties secret to mount, empty when there is none. Like the
+// address properties, the security properties secret is kept once deployed
+func (reconciler *ActiveMQArtemisReconcilerImpl) addResourceForSecurity(customResource *brokerv1beta1.ActiveMQArtemis, namer common.Namers) (string, string) {
+	var jaasConfigName string
+	delivery := reconciler.securityDelivery
+
+	if delivery != nil && delivery.jaasConfig != nil {
+		resourceName := getSecurityJaasConfigSecretName(customResource)
+		var desired *corev1.Secret
+		if obj := reconciler.cloneOfDeployed(reflect.TypeOf(corev1.Secret{}), resourceName.Name); obj != nil {
+			desired = obj.(*corev1.Secret)
+			desired.Data = nil
+			desired.StringData = delivery.jaasConfig
+		} else {
+			secret := secrets.MakeSecret(resourceName, delivery.jaasConfig, namer.LabelBuilder.Labels())
+			desired = &secret
+		}
+		reconciler.log.V(1).Info("Requesting secret for security jaas config", "name", resourceName.Name)
+		reconciler.trackDesired(desired)
+		jaasConfigName = resourceName.Name
+	}
+
+	resourceName := getSecurityPropertiesSecretName(customResource)
+	var desired *corev1.Secret
+	if obj := reconciler.cloneOfDeployed(reflect.TypeOf(corev1.Secret{}), resourceName.Name); obj != nil {
+		desired = obj.(*corev1.Secret)
+	} else if delivery == nil || len(delivery.properties) == 0 {
+		return jaasConfigName, ""
+	}
+
+	buf := &strings.Builder{}
+	fmt.Fprintln(buf, "# generated by crd")
+	fmt.Fprintln(buf, "#")
+	if delivery != nil {
+		for _, prop := range delivery.properties {
+			fmt.Fprintln(buf, prop)
+		}
+	}
+	data := map[string]string{SecurityPropertiesName: buf.String()}
+
+	if desired == nil {
+		secret := secrets.MakeSecret(resourceName, data, namer.LabelBuilder.Labels())
+		desired = &secret
+	} else {
+		desired.StringData = data
+	}
+	reconciler.log.V(1).Info("Requesting secret for security properties", "name", resourceName.Name)
+	reconciler.trackDesired(desired)
+	return jaasConfigName, resourceName.Name
+}
+
+// securityJaasConfigArgs point the brokers to the login.config of the jaas
+// config secret, with the realm of the console
+func securityJaasConfigArgs(delivery *securityDelivery, jaasConfigName string) string {
+	args := fmt.Sprintf("-Djava.security.auth.login.config=%s%s/%s", secretPathBase, jaasConfigName, JaasConfigKey)
+	if delivery.consoleRealm != "" {
+		args += " -Dhawtio.realm=" + delivery.consoleRealm
+	}
+	return args
+}
+
+// deliveredFilesStatus compares the files of a secret the brokers mount with
+// the ones the broker reports, it tells what is not loaded yet or failed
+func deliveredFilesStatus(secretName types.NamespacedName, reported map[string]propertiesStatus, client rtclient.Client) (pending string, failed string) {
+	secret := &corev1.Secret{}
+	if err := client.Get(context.TODO(), secretName, secret); err != nil {
+		return "", ""
+	}
+	names := []string{}
+	for name := range secret.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		current, present := reported[name]
+		// the property files of a login module are only visible after its first login
+		if !present || name == JaasConfigKey || strings.HasPrefix(name, "_") {
+			continue
+		}
+		if len(current.ApplyErrors) > 0 {
+			return "", fmt.Sprintf("%s has errors %s", name, marshallApplyErrors(current.ApplyErrors))
+		}
+		if current.Alder32 != alder32FromData(secret.Data[name]) {
+			return fmt.Sprintf("waiting for the broker to reload %s", name), ""
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,400 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/common"
+	jc "github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia_client"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/namer"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	rtclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The security config is generated by the init container of the broker pods,
+// the brokers pick up a change when they next start. The status records when
+// the config last changed to tell which of them run it. With the Secrets
+// delivery mode a checksum of login.config and the resource limits in the
+// init container environment restarts the brokers, they reload the rest.
+
+const securityConfigChecksumEnvVar = "SECURITY_CFG_CHECKSUM"
+
+var securityLoginModuleFlags = []string{"required", "requisite", "sufficient", "optional"}
+
+var securityPermissionTypes = []string{
+	"createAddress", "deleteAddress", "createDurableQueue", "deleteDurableQueue", "createNonDurableQueue",
+	"deleteNonDurableQueue", "send", "consume", "browse", "manage", "view", "edit",
+}
+
+// securityConfigChecksum covers the spec and the versions of the bind password
+// secrets, the login config holds the passwords
+func securityConfigChecksum(instance *brokerv1beta1.ActiveMQArtemisSecurity, client rtclient.Client) string {
+	spec, _ := common.ToJson(&instance.Spec)
+	return alder32StringValue(alder32Of(append([]string{spec}, bindPasswordVersions(instance, client)...)))
+}
+
+// bindPasswordVersions tells which version of the ldap bind password secrets
+// the config is rendered with
+func bindPasswordVersions(instance *brokerv1beta1.ActiveMQArtemisSecurity, client rtclient.Client) []string {
+	versions := []string{}
+	for _, module := range instance.Spec.LoginModules.LdapLoginModules {
+		if module.BindPasswordSecret == "" {
+			continue
+		}
+		secret := &corev1.Secret{}
+		if err := client.Get(context.TODO(), types.NamespacedName{Namespace: instance.Namespace, Name: module.BindPasswordSecret}, secret); err == nil {
+			versions = append(versions, module.BindPasswordSecret+"@"+secret.ResourceVersion)
+		}
+	}
+	return versions
+}
+
+// validateSecurity checks what the login config and the security settings are
+// generated from, an invalid CR is not applied to the brokers
+func validateSecurity(instance *brokerv1beta1.ActiveMQArtemisSecurity) *metav1.Condition {
+	invalid := func(reason string, format string, args ...interface{}) *metav1.Condition {
+		return &metav1.Condition{
+			Type:    brokerv1beta1.ValidConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+
+	modules := map[string]bool{}
+	addModule := func(name string) *metav1.Condition {
+		if name == "" {
+			return invalid(brokerv1beta1.ValidConditionInvalidLoginModuleReason, "a login module requires a name")
+		}
+		if modules[name] {
+			return invalid(brokerv1beta1.ValidConditionInvalidLoginModuleReason, "login module %s is defined more than once", name)
+		}
+		modules[name] = true
+		return nil
+	}
+
+	loginModules := &instance.Spec.LoginModules
+	for _, module := range loginModules.PropertiesLoginModules {
+		if condition := addModule(module.Name); condition != nil {
+			return condition
+		}
+		users := map[string]bool{}
+		for _, user := range module.Users {
+			if user.Name == "" {
+				return invalid(brokerv1beta1.ValidConditionInvalidLoginModuleReason, "a user of login module %s requires a name", module.Name)
+			}
+			if users[user.Name] {
+				return invalid(brokerv1beta1.ValidConditionInvalidLoginModuleReason, "user %s of login module %s is defined more than once", user.Name, module.Name)
+			}
+			users[user.Name] = true
+		}
+	}
+	for _, module := range loginModules.GuestLoginModules {
+		if condition := addModule(module.Name); condition != nil {
+			return condition
+		}
+	}
+	for _, module := range loginModules.KeycloakLoginModules {
+		if condition := addModule(module.Name); condition != nil {
+			return condition
+		}
+	}
+	for i := range loginModules.LdapLoginModules {
+		module := &loginModules.LdapLoginModules[i]
+		if condition := addModule(module.Name); condition != nil {
+			return condition
+		}
+		if message := validateLdapLoginModule(module); message != "" {
+			return invalid(brokerv1beta1.ValidConditionInvalidLoginModuleReason, message)
+		}
+	}
+	for i := range loginModules.CertificateLoginModules {
+		module := &loginModules.CertificateLoginModules[i]
+		if condition := addModule(module.Name); condition != nil {
+			return condition
+		}
+		if message := validateCertificateLoginModule(module); message != "" {
+			return invalid(brokerv1beta1.ValidConditionInvalidLoginModuleReason, message)
+		}
+	}
+	if _, _, err := ldapTrustSecret(instance); err != nil {
+		return invalid(brokerv1beta1.ValidConditionInvalidLoginModuleReason, err.Error())
+	}
+
+	domains := map[string]*brokerv1beta1.BrokerDomainType{
+		"brokerDomain":  &instance.Spec.SecurityDomains.BrokerDomain,
+		"consoleDomain": &instance.Spec.SecurityDomains.ConsoleDomain,
+	}
+	for _, domainName := range []string{"brokerDomain", "consoleDomain"} {
+		for _, reference := range domains[domainName].LoginModules {
+			if reference.Name == nil || !modules[*reference.Name] {
+				name := ""
+				if reference.Name != nil {
+					name = *reference.Name
+				}
+				return invalid(brokerv1beta1.ValidConditionInvalidSecurityDomainReason, "%s refers to login module %q that is not defined", domainName, name)
+			}
+			if reference.Flag != nil && !containsString(securityLoginModuleFlags, *reference.Flag) {
+				return invalid(brokerv1beta1.ValidConditionInvalidSecurityDomainReason, "%s login module %s flag %s is not one of %s", domainName, *reference.Name, *reference.Flag, strings.Join(securityLoginModuleFlags, ", "))
+			}
+		}
+	}
+
+	if message := validateOperatorRealms(instance); message != "" {
+		return invalid(brokerv1beta1.ValidConditionInvalidSecurityDomainReason, message)
+	}
+	if message := validateSecretsDelivery(instance); message != "" {
+		return invalid(brokerv1beta1.ValidConditionInvalidSecurityDomainReason, message)
+	}
+	if message := validateResourceLimits(instance); message != "" {
+		return invalid(brokerv1beta1.ValidConditionInvalidResourceLimitReason, message)
+	}
+
+	for _, setting := range instance.Spec.SecuritySettings.Broker {
+		if setting.Match == "" {
+			return invalid(brokerv1beta1.ValidConditionInvalidSecurityDomainReason, "a broker security setting requires a match")
+		}
+		for _, permission := range setting.Permissions {
+			if !containsString(securityPermissionTypes, permission.OperationType) {
+				return invalid(brokerv1beta1.ValidConditionInvalidSecurityDomainReason, "security setting %s operationType %s is not one of %s", setting.Match, permission.OperationType, strings.Join(securityPermissionTypes, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// updateSecurityStatus lists the broker CRs the handler applies to and checks
+// each of their brokers for the current config
+func (r *ActiveMQArtemisSecurityReconciler) updateSecurityStatus(handler *ActiveMQArtemisSecurityConfigHandler, reqLogger logr.Logger) error {
+	instance := handler.SecurityCR
+	status := &instance.Status
+
+	crList := &brokerv1beta1.ActiveMQArtemisList{}
+	if err := r.Client.List(context.TODO(), crList, &rtclient.ListOptions{Namespace: instance.Namespace}); err != nil {
+		return err
+	}
+	sort.Slice(crList.Items, func(i, j int) bool {
+		return crList.Items[i].Name < crList.Items[j].Name
+	})
+
+	if checksum := securityConfigChecksum(instance, r.Client); status.ConfigChecksum != checksum {
+		// the brokers that started before the CR existed have no config of it
+		changed := instance.CreationTimestamp
+		if status.ConfigChecksum != "" {
+			changed = metav1.Now()
+		}
+		status.ConfigChecksum = checksum
+		status.ConfigChangeTime = &changed
+	}
+	runsConfig := func(pod *corev1.Pod) bool {
+		return pod.Status.StartTime != nil && !pod.Status.StartTime.Before(status.ConfigChangeTime)
+	}
+	if deliveredBySecrets(instance) {
+		checksum := securityRestartChecksum(instance, r.Client)
+		runsConfig = func(pod *corev1.Pod) bool {
+			return podSecurityConfigChecksum(pod) == checksum
+		}
+	}
+	status.MatchedBrokers = nil
+	status.Brokers = nil
+	conflicts := []string{}
+	for index := range crList.Items {
+		cr := &crList.Items[index]
+		crName := types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}
+		if !handler.IsApplicableFor(crName) {
+			continue
+		}
+		status.MatchedBrokers = append(status.MatchedBrokers, cr.Name)
+		// only one security CR applies to a broker
+		if applied := GetBrokerConfigHandler(crName); applied != nil && applied.GetCRName() != instance.Name {
+			conflicts = append(conflicts, fmt.Sprintf("%s uses %s", cr.Name, applied.GetCRName()))
+			continue
+		}
+		status.Brokers = append(status.Brokers, securityBrokerStatuses(cr, instance, runsConfig, r.Client, reqLogger)...)
+	}
+	setSecurityAppliedCondition(instance, conflicts)
+	return nil
+}
+
+// securityBrokerStatuses checks that each broker of the CR runs the current
+// config and reports no JAAS property file errors. The brokers
+// also have to reload the secrets the config is delivered in, then they report
+// the usage of the users with resource limits
+func securityBrokerStatuses(cr *brokerv1beta1.ActiveMQArtemis, instance *brokerv1beta1.ActiveMQArtemisSecurity, runsConfig func(*corev1.Pod) bool, client rtclient.Client, reqLogger logr.Logger) []brokerv1beta1.SecurityBrokerStatus {
+	size := common.GetDeploymentSize(cr)
+	jks := map[string]*jc.JkInfo{}
+	for _, jk := range jc.GetBrokersFromDNS(cr.Name, cr.Namespace, size, client) {
+		jks[jk.Ordinal] = jk
+	}
+
+	statuses := []brokerv1beta1.SecurityBrokerStatus{}
+	for ordinal := int32(0); ordinal < size; ordinal++ {
+		status := brokerv1beta1.SecurityBrokerStatus{
+			CrName:  cr.Name,
+			Ordinal: strconv.Itoa(int(ordinal)),
+			Result:  brokerv1beta1.SecurityBrokerPending,
+		}
+		statuses = append(statuses, status)
+		current := &statuses[len(statuses)-1]
+
+		pod := &corev1.Pod{}
+		podName := types.NamespacedName{Namespace: cr.Namespace, Name: namer.CrToSS(cr.Name) + "-" + status.Ordinal}
+		if err := client.Get(context.TODO(), podName, pod); err != nil {
+			if !errors.IsNotFound(err) {
+				reqLogger.V(1).Info("unable to get the broker pod", "pod", podName, "error", err)
+			}
+			current.Message = "waiting for the broker pod"
+			continue
+		}
+		if !runsConfig(pod) {
+			current.Message = "waiting for the broker to restart with the security config"
+			continue
+		}
+		if failure := podFailure(pod); failure != "" {
+			current.Result = brokerv1beta1.SecurityBrokerFailed
+			current.Message = failure
+			continue
+		}
+		if !isPodReady(pod) {
+			current.Message = "waiting for the broker to be ready"
+			continue
+		}
+
+		jk, found := jks[status.Ordinal]
+		if !found {
+			current.Message = "waiting for jolokia to be available"
+			continue
+		}
+		brokerStatus, err := getBrokerStatus(jk, reqLogger)
+		if err != nil {
+			current.Message = fmt.Sprintf("unable to retrieve the broker status, %v", err)
+			continue
+		}
+		// the JAAS status is the one AssertJaasPropertiesStatus checks for a jaas config secret
+		files := []string{}
+		for name, file := range brokerStatus.ServerStatus.Jaas.PropertiesStatus {
+			if len(file.ApplyErrors) > 0 {
+				current.Result = brokerv1beta1.SecurityBrokerFailed
+				current.Message = fmt.Sprintf("JAAS property file %s has errors %s", name, marshallApplyErrors(file.ApplyErrors))
+				break
+			}
+			files = append(files, name)
+		}
+		if current.Result == brokerv1beta1.SecurityBrokerFailed {
+			continue
+		}
+		pending, failed := "", ""
+		if deliveredBySecrets(instance) {
+			pending, failed = deliveredFilesStatus(getSecurityJaasConfigSecretName(cr), brokerStatus.ServerStatus.Jaas.PropertiesStatus, client)
+		}
+		if pending == "" && failed == "" {
+			pending, failed = deliveredFilesStatus(getSecurityPropertiesSecretName(cr), brokerStatus.BrokerConfigStatus.PropertiesStatus, client)
+		}
+		if failed != "" {
+			current.Result = brokerv1beta1.SecurityBrokerFailed
+			current.Message = failed
+			continue
+		}
+		if pending != "" {
+			current.Message = pending
+			continue
+		}
+		current.Result = brokerv1beta1.SecurityBrokerApplied
+		current.ResourceUsage = securityResourceUsage(instance, jk, reqLogger)
+		if len(files) > 0 {
+			sort.Strings(files)
+			current.Message = "JAAS property files loaded: " + strings.Join(files, ", ")
+		}
+	}
+	return statuses
+}
+
+func podSecurityConfigChecksum(pod *corev1.Pod) string {
+	for _, container := range pod.Spec.InitContainers {
+		for _, env := range container.Env {
+			if env.Name == securityConfigChecksumEnvVar {
+				return env.Value
+			}
+		}
+	}
+	return ""
+}
+
+// podFailure tells why the security init or the broker keeps failing to start
+func podFailure(pod *corev1.Pod) string {
+	for _, container := range pod.Status.InitContainerStatuses {
+		if terminated := container.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			return fmt.Sprintf("the init container failed to generate the config, exit code %d %s", terminated.ExitCode, terminated.Message)
+		}
+		if terminated := container.LastTerminationState.Terminated; terminated != nil && terminated.ExitCode != 0 && container.State.Waiting != nil {
+			return fmt.Sprintf("the init container failed to generate the config, exit code %d %s", terminated.ExitCode, terminated.Message)
+		}
+	}
+	for _, container := range pod.Status.ContainerStatuses {
+		if waiting := container.State.Waiting; waiting != nil && waiting.Reason == "CrashLoopBackOff" {
+			return "the broker fails to start, " + waiting.Message
+		}
+	}
+	return ""
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func setSecurityAppliedCondition(instance *brokerv1beta1.ActiveMQArtemisSecurity, conflicts []string) {
+	status := &instance.Status
+	applied := 0
+	pending := 0
+	failed := []string{}
+	for _, broker := range status.Brokers {
+		switch broker.Result {
+		case brokerv1beta1.SecurityBrokerApplied:
+			applied++
+		case brokerv1beta1.SecurityBrokerPending:
+			pending++
+		default:
+			failed = append(failed, fmt.Sprintf("%s-%s", broker.CrName, broker.Ordinal))
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               brokerv1beta1.SecurityAppliedConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             brokerv1beta1.SecurityAppliedConditionSuccessReason,
+		Message:            fmt.Sprintf("applied to %d of %d brokers", applied, len(status.Brokers)),
+		ObservedGeneration: instance.Generation,
+	}
+	if len(status.MatchedBrokers) == 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = brokerv1beta1.SecurityAppliedConditionNoBrokersReason
+		condition.Message = "no broker CR matches"
+	} else if len(conflicts) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = brokerv1beta1.SecurityAppliedConditionConflictReason
+		condition.Message += ", another security CR applies to " + strings.Join(conflicts, ", ")
+	} else if len(failed) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = brokerv1beta1.SecurityAppliedConditionFailedReason
+		condition.Message += ", failed on " + strings.Join(failed, ", ")
+	} else if pending > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = brokerv1beta1.SecurityAppliedConditionPendingReason
+		condition.Message += fmt.Sprintf(", pending on %d", pending)
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
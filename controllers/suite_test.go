@@ -466,21 +466,37 @@ func createControllerManager(disableMetrics bool, watchNamespace string) {
 	err = securityReconciler.SetupWithManager(k8Manager)
 	Expect(err).ToNot(HaveOccurred(), "failed to create security controller")
 
-	addressReconciler := &ActiveMQArtemisAddressReconciler{
-		Client: k8Manager.GetClient(),
-		Scheme: k8Manager.GetScheme(),
-		log:    ctrl.Log,
-	}
+	brokerConnectionReconciler := NewActiveMQArtemisBrokerConnectionReconciler(
+		k8Manager.GetClient(),
+		k8Manager.GetScheme(),
+		brokerReconciler,
+		ctrl.Log)
+
+	err = brokerConnectionReconciler.SetupWithManager(k8Manager)
+	Expect(err).ToNot(HaveOccurred(), "failed to create broker connection controller")
+
+	federationReconciler := NewActiveMQArtemisFederationReconciler(
+		k8Manager.GetClient(),
+		k8Manager.GetScheme(),
+		brokerReconciler,
+		ctrl.Log)
+
+	err = federationReconciler.SetupWithManager(k8Manager)
+	Expect(err).ToNot(HaveOccurred(), "failed to create federation controller")
+
+	addressReconciler := NewActiveMQArtemisAddressReconciler(
+		k8Manager.GetClient(),
+		k8Manager.GetScheme(),
+		brokerReconciler,
+		ctrl.Log)
 
 	err = addressReconciler.SetupWithManager(k8Manager, managerCtx)
 	Expect(err).ToNot(HaveOccurred(), "failed to create address reconciler")
 
-	scaleDownRconciler := &ActiveMQArtemisScaledownReconciler{
-		Client: k8Manager.GetClient(),
-		Scheme: k8Manager.GetScheme(),
-		Config: k8Manager.GetConfig(),
-		log:    ctrl.Log,
-	}
+	scaleDownRconciler := NewActiveMQArtemisScaledownReconciler(
+		k8Manager.GetClient(),
+		k8Manager.GetScheme(),
+		ctrl.Log)
 
 	err = scaleDownRconciler.SetupWithManager(k8Manager)
 	Expect(err).ShouldNot(HaveOccurred(), "failed to create scale down reconciler")
@@ -771,11 +787,6 @@ var _ = AfterSuite(func() {
 			stateManager.Clear()
 		}
 
-		// scaledown controller lifecycle seems a little loose, it does not complete on signal hander like the others
-		for _, drainController := range controllers {
-			close(*drainController.GetStopCh())
-		}
-
 		err := testEnv.Stop()
 		Expect(err).NotTo(HaveOccurred())
 	}
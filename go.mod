@@ -1,6 +1,6 @@
 module github.com/artemiscloud/activemq-artemis-operator
 
-go 1.20
+go 1.21
 
 require (
 	github.com/Azure/go-amqp v0.17.4
@@ -25,6 +25,7 @@ require (
 
 require (
 	github.com/blang/semver/v4 v4.0.0
+	golang.org/x/crypto v0.14.0
 	k8s.io/utils v0.0.0-20230726121419-3b25d923346b
 )
 
@@ -65,7 +66,6 @@ require (
 	github.com/prometheus/procfs v0.10.1 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
-	golang.org/x/crypto v0.14.0 // indirect
 	golang.org/x/exp v0.0.0-20220722155223-a9213eeb770e // indirect
 	golang.org/x/net v0.17.0 // indirect
 	golang.org/x/oauth2 v0.8.0 // indirect
@@ -49,6 +49,7 @@ function writeFile() {
         createFile "$destdir/cluster_role.yaml"
         sed -i.bak 's/kind: Role/kind: ClusterRole/' \
           "$destdir/cluster_role.yaml" && rm "$destdir/cluster_role.yaml.bak"
+        clusterRoleRules >> "$destdir/cluster_role.yaml"
       elif [[ ${resource_name} =~ (leader-election) ]]; then
         createFile "$destdir/election_role.yaml"
       else
@@ -88,6 +89,18 @@ function writeFile() {
     esac
 }
 
+# rules for cluster scoped resources, only the cluster role can grant them
+function clusterRoleRules() {
+  printf "%s\n" \
+    "- apiGroups:" \
+    "  - \"\"" \
+    "  resources:" \
+    "  - namespaces" \
+    "  verbs:" \
+    "  - get" \
+    "  - list"
+}
+
 function createFile() {
   echo "Writing $1"
   rm -rf $1
@@ -115,6 +128,8 @@ function makeSingleInstall() {
     for val in "${singleYaml[@]}"; do
       if [[ "${val}" == "kind: Role" ]]; then
         val="kind: ClusterRole"
+      elif [[ "${val}" == "---" ]]; then
+        clusterRoleRules >> ${SINGLE_INSTALL_YML}
       fi
       printf "%s\n" "${val}" >> ${SINGLE_INSTALL_YML}
     done
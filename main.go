@@ -261,6 +261,7 @@ func main() {
 	addressReconciler := controllers.NewActiveMQArtemisAddressReconciler(
 		mgr.GetClient(),
 		mgr.GetScheme(),
+		brokerReconciler,
 		ctrl.Log.WithName("ActiveMQArtemisAddressReconciler"))
 
 	if err = addressReconciler.SetupWithManager(mgr, context.TODO()); err != nil {
@@ -271,7 +272,6 @@ func main() {
 	scaledownReconciler := controllers.NewActiveMQArtemisScaledownReconciler(
 		mgr.GetClient(),
 		mgr.GetScheme(),
-		mgr.GetConfig(),
 		ctrl.Log.WithName("ActiveMQArtemisScaledownReconciler"))
 
 	if err = scaledownReconciler.SetupWithManager(mgr); err != nil {
@@ -290,9 +290,32 @@ func main() {
 		os.Exit(1)
 	}
 
+	brokerConnectionReconciler := controllers.NewActiveMQArtemisBrokerConnectionReconciler(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		brokerReconciler,
+		ctrl.Log.WithName("ActiveMQArtemisBrokerConnectionReconciler"))
+
+	if err = brokerConnectionReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ActiveMQArtemisBrokerConnection")
+		os.Exit(1)
+	}
+
+	federationReconciler := controllers.NewActiveMQArtemisFederationReconciler(
+		mgr.GetClient(),
+		mgr.GetScheme(),
+		brokerReconciler,
+		ctrl.Log.WithName("ActiveMQArtemisFederationReconciler"))
+
+	if err = federationReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ActiveMQArtemisFederation")
+		os.Exit(1)
+	}
+
 	enableWebhooks := os.Getenv("ENABLE_WEBHOOKS")
 	if enableWebhooks != "false" {
 		setupLog.Info("Setting up webhook functions", "ENABLE_WEBHOOKS", enableWebhooks)
+		brokerv1beta1.SetActiveMQArtemisSpecValidator(controllers.NewActiveMQArtemisSpecValidator(mgr.GetClient()))
 		if err = (&brokerv1beta1.ActiveMQArtemis{}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "ActiveMQArtemis")
 			os.Exit(1)
@@ -16,12 +16,26 @@ limitations under the License.
 package draincontroller
 
 import (
-	"encoding/json"
+	"context"
 	"testing"
+	"time"
 
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestDrainController(t *testing.T) {
@@ -29,21 +43,632 @@ func TestDrainController(t *testing.T) {
 	RunSpecs(t, "Drain Controller Suite")
 }
 
+// newTestScaledown is the scaledown CR a broker CR named br creates
+func newTestScaledown() *brokerv1beta1.ActiveMQArtemisScaledown {
+	return &brokerv1beta1.ActiveMQArtemisScaledown{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "test",
+			Name:        "br",
+			Annotations: map[string]string{"CRNAME": "br", "CRNAMESPACE": "test"},
+		},
+		Spec: brokerv1beta1.ActiveMQArtemisScaledownSpec{LocalOnly: true},
+	}
+}
+
+// newTestClient serves the objects the way the cache of the manager does
+func newTestClient(objects ...client.Object) client.WithWatch {
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+	Expect(brokerv1beta1.AddToScheme(scheme)).Should(Succeed())
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).WithStatusSubresource(&brokerv1beta1.ActiveMQArtemisScaledown{}).Build()
+}
+
 var _ = Describe("Drain Controller Test", func() {
-	Context("Global template test", func() {
-		It("testing global template has correct openshift ping dns service port", func() {
-			pod := corev1.Pod{}
-			err := json.Unmarshal([]byte(globalPodTemplateJson), &pod)
+	Context("Drain pod test", func() {
+		It("testing drain pod has correct openshift ping dns service port", func() {
+			ssKey := types.NamespacedName{Namespace: "test", Name: "br-ss"}
+			c := &Controller{client: fake.NewClientBuilder().Build(), log: ctrl.Log.WithName("drain_test")}
+			scaledown := newTestScaledown()
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ssKey.Namespace, Name: ssKey.Name},
+				Spec: appsv1.StatefulSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Image: "broker"}}},
+					},
+				},
+			}
+
+			pod, err := c.newPod(sts, scaledown, 1)
 			Expect(err).Should(Succeed())
 
-			var servicePort string
-			envVars := pod.Spec.Containers[0].Env
-			for _, v := range envVars {
-				if v.Name == "OPENSHIFT_DNS_PING_SERVICE_PORT" {
-					servicePort = v.Value
+			Expect(pod.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "OPENSHIFT_DNS_PING_SERVICE_PORT", Value: "7800"}))
+		})
+
+		It("mounts separate directory volumes under the data dir", func() {
+			ssKey := types.NamespacedName{Namespace: "test", Name: "br-ss"}
+			c := &Controller{client: fake.NewClientBuilder().Build(), log: ctrl.Log.WithName("drain_test")}
+			scaledown := newTestScaledown()
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ssKey.Namespace, Name: ssKey.Name},
+				Spec: appsv1.StatefulSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Image: "broker"}}},
+					},
+					VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+						{ObjectMeta: metav1.ObjectMeta{Name: "br"}},
+						{ObjectMeta: metav1.ObjectMeta{Name: "br-journal"}},
+					},
+				},
+			}
+
+			pod, err := c.newPod(sts, scaledown, 1)
+			Expect(err).Should(Succeed())
+
+			Expect(pod.Spec.Volumes).To(HaveLen(2))
+			Expect(pod.Spec.Volumes[1].PersistentVolumeClaim.ClaimName).To(Equal("br-journal-br-ss-1"))
+			Expect(pod.Spec.Containers[0].VolumeMounts).To(ConsistOf(
+				corev1.VolumeMount{Name: "br", MountPath: "/opt/br/data"},
+				corev1.VolumeMount{Name: "br-journal", MountPath: "/opt/br/data/journal"},
+			))
+		})
+	})
+
+	Context("Drain pod template test", func() {
+		It("lays the drain pod template over the broker pod template", func() {
+			ssKey := types.NamespacedName{Namespace: "test", Name: "br-ss"}
+			nonRoot := true
+			noEscalation := false
+			memory := resource.MustParse("512Mi")
+			scaledown := newTestScaledown()
+			scaledown.Spec = brokerv1beta1.ActiveMQArtemisScaledownSpec{
+				LocalOnly: true,
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+				DrainPodTemplate: &brokerv1beta1.DrainPodTemplateType{
+					Image:                    "drainer",
+					Resources:                &corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: memory}},
+					Tolerations:              []corev1.Toleration{{Key: "drain", Operator: corev1.TolerationOpExists}},
+					NodeSelector:             map[string]string{"disk": "fast"},
+					PodSecurityContext:       &corev1.PodSecurityContext{RunAsNonRoot: &nonRoot},
+					ContainerSecurityContext: &corev1.SecurityContext{AllowPrivilegeEscalation: &noEscalation},
+					Env:                      []corev1.EnvVar{{Name: "AMQ_GLOBAL_MAX_SIZE", Value: "200mb"}, {Name: "JAVA_OPTS", Value: "-Xmx256m"}},
+				},
+			}
+			c := &Controller{client: fake.NewClientBuilder().Build(), log: ctrl.Log.WithName("drain_test")}
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ssKey.Namespace, Name: ssKey.Name},
+				Spec: appsv1.StatefulSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers:       []corev1.Container{{Image: "broker", SecurityContext: &corev1.SecurityContext{}}},
+							Tolerations:      []corev1.Toleration{{Key: "broker", Operator: corev1.TolerationOpExists}},
+							NodeSelector:     map[string]string{"disk": "any", "zone": "a"},
+							ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+							SecurityContext:  &corev1.PodSecurityContext{},
+						},
+					},
+				},
+			}
+
+			// without a template the drain pod runs like the broker pods
+			pod, err := c.newPod(sts, newTestScaledown(), 1)
+			Expect(err).Should(Succeed())
+			Expect(pod.Spec.Containers[0].Image).To(Equal("broker"))
+			Expect(pod.Spec.Tolerations).To(Equal(sts.Spec.Template.Spec.Tolerations))
+			Expect(pod.Spec.NodeSelector).To(Equal(sts.Spec.Template.Spec.NodeSelector))
+			Expect(pod.Spec.ImagePullSecrets).To(Equal(sts.Spec.Template.Spec.ImagePullSecrets))
+			Expect(pod.Spec.SecurityContext).To(BeIdenticalTo(sts.Spec.Template.Spec.SecurityContext))
+			Expect(pod.Spec.Containers[0].SecurityContext).To(BeIdenticalTo(sts.Spec.Template.Spec.Containers[0].SecurityContext))
+
+			pod, err = c.newPod(sts, scaledown, 1)
+			Expect(err).Should(Succeed())
+			container := pod.Spec.Containers[0]
+			Expect(container.Image).To(Equal("drainer"))
+			Expect(container.Resources.Limits).To(Equal(corev1.ResourceList{corev1.ResourceMemory: memory}))
+			Expect(container.SecurityContext.AllowPrivilegeEscalation).To(Equal(&noEscalation))
+			Expect(container.Env).To(ContainElements(
+				corev1.EnvVar{Name: "AMQ_GLOBAL_MAX_SIZE", Value: "200mb"},
+				corev1.EnvVar{Name: "JAVA_OPTS", Value: "-Xmx256m"},
+			))
+			Expect(container.Env).NotTo(ContainElement(corev1.EnvVar{Name: "AMQ_GLOBAL_MAX_SIZE", Value: "100mb"}))
+			Expect(pod.Spec.SecurityContext.RunAsNonRoot).To(Equal(&nonRoot))
+			Expect(pod.Spec.Tolerations).To(HaveLen(2))
+			Expect(sts.Spec.Template.Spec.Tolerations).To(HaveLen(1))
+			Expect(pod.Spec.NodeSelector).To(Equal(map[string]string{"disk": "fast", "zone": "a"}))
+			Expect(sts.Spec.Template.Spec.NodeSelector["disk"]).To(Equal("any"))
+		})
+	})
+
+	Context("Scaledown status test", func() {
+		It("reports the drain of each orphaned ordinal", func() {
+			scaledown := newTestScaledown()
+			client := newTestClient(scaledown)
+
+			messages := int64(10)
+			ssKey := types.NamespacedName{Namespace: "test", Name: "br-ss"}
+			c := &Controller{
+				client: client,
+				countMessages: func(pod *corev1.Pod) (int64, error) {
+					return messages, nil
+				},
+				log: ctrl.Log.WithName("drain_test"),
+			}
+
+			replicas := int32(1)
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ssKey.Namespace, Name: ssKey.Name},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+			}
+			claims := map[int][]*corev1.PersistentVolumeClaim{0: {}, 1: {}, 2: {}}
+			started := metav1.NewTime(time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC))
+			drainPod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         ssKey.Namespace,
+					Name:              "br-ss-2",
+					CreationTimestamp: started,
+					Annotations:       map[string]string{AnnotationStatefulSet: ssKey.Name},
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			}
+			Expect(client.Create(context.TODO(), drainPod)).Should(Succeed())
+			// the removed broker of ordinal 1 is still stopping
+			Expect(client.Create(context.TODO(), &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: ssKey.Namespace, Name: "br-ss-1"}})).Should(Succeed())
+
+			status := func() brokerv1beta1.ActiveMQArtemisScaledownStatus {
+				instance := &brokerv1beta1.ActiveMQArtemisScaledown{}
+				Expect(client.Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: "br"}, instance)).Should(Succeed())
+				c.updateScaledownStatus(sts, instance, claims)
+				Expect(client.Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: "br"}, instance)).Should(Succeed())
+				return instance.Status
+			}
+
+			current := status()
+			Expect(current.Drains).To(HaveLen(2))
+			Expect(current.Drains[0].Ordinal).To(Equal(int32(1)))
+			Expect(current.Drains[0].Result).To(Equal(brokerv1beta1.ScaledownDrainPending))
+			Expect(current.Drains[1].PodName).To(Equal("br-ss-2"))
+			Expect(current.Drains[1].Result).To(Equal(brokerv1beta1.ScaledownDrainDraining))
+			Expect(current.Drains[1].Phase).To(Equal(corev1.PodRunning))
+			Expect(current.Drains[1].StartTime.Equal(&started)).To(BeTrue())
+			Expect(*current.Drains[1].MessagesToMigrate).To(Equal(int64(10)))
+			Expect(*current.Drains[1].MessagesMigrated).To(Equal(int64(0)))
+			condition := meta.FindStatusCondition(current.Conditions, brokerv1beta1.MigrationCompleteConditionType)
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(brokerv1beta1.MigrationCompleteConditionInProgressReason))
+			Expect(condition.Message).To(Equal("waiting for the drain of ordinal 1, 2"))
+
+			// the drain broker empties as the messages move to ordinal 0
+			messages = 4
+			current = status()
+			Expect(*current.Drains[1].MessagesToMigrate).To(Equal(int64(10)))
+			Expect(*current.Drains[1].MessagesMigrated).To(Equal(int64(6)))
+
+			finished := metav1.NewTime(started.Add(time.Minute))
+			drainPod.Status = corev1.PodStatus{
+				Phase: corev1.PodSucceeded,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: finished}}},
+				},
+			}
+			Expect(client.Status().Update(context.TODO(), drainPod)).Should(Succeed())
+			current = status()
+			Expect(current.Drains[1].Result).To(Equal(brokerv1beta1.ScaledownDrainSucceeded))
+			Expect(current.Drains[1].EndTime.Equal(&finished)).To(BeTrue())
+			Expect(*current.Drains[1].MessagesMigrated).To(Equal(int64(10)))
+
+			// a succeeded drain stays once its volumes and pod are removed
+			Expect(client.Delete(context.TODO(), drainPod)).Should(Succeed())
+			delete(claims, 2)
+			current = status()
+			Expect(current.Drains).To(HaveLen(2))
+			Expect(current.Drains[1].Result).To(Equal(brokerv1beta1.ScaledownDrainSucceeded))
+
+			// a scale up takes the ordinal that didn't drain back
+			replicas = 2
+			current = status()
+			Expect(current.Drains).To(HaveLen(1))
+			Expect(current.Drains[0].Ordinal).To(Equal(int32(2)))
+			condition = meta.FindStatusCondition(current.Conditions, brokerv1beta1.MigrationCompleteConditionType)
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal(brokerv1beta1.MigrationCompleteConditionCompleteReason))
+		})
+	})
+
+	Context("Scale down to zero test", func() {
+		It("reports that there is no drain target", func() {
+			scaledown := newTestScaledown()
+			client := newTestClient(scaledown)
+			c := &Controller{client: client, log: ctrl.Log.WithName("drain_test")}
+			replicas := int32(0)
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "br-ss"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+			}
+
+			requeueAfter, err := c.processStatefulSet(sts, scaledown)
+			Expect(err).Should(Succeed())
+			Expect(requeueAfter).To(BeZero())
+
+			instance := &brokerv1beta1.ActiveMQArtemisScaledown{}
+			Expect(client.Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: "br"}, instance)).Should(Succeed())
+			condition := meta.FindStatusCondition(instance.Status.Conditions, brokerv1beta1.MigrationCompleteConditionType)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(brokerv1beta1.MigrationCompleteConditionNoTargetReason))
+		})
+	})
+
+	Context("Drain retry test", func() {
+		It("bounds each drain attempt by the deadline of the scaledown CR", func() {
+			ssKey := types.NamespacedName{Namespace: "test", Name: "br-ss"}
+			deadline := int64(600)
+			scaledown := newTestScaledown()
+			scaledown.Spec.DrainDeadlineSeconds = &deadline
+			c := &Controller{client: fake.NewClientBuilder().Build(), log: ctrl.Log.WithName("drain_test")}
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: ssKey.Namespace, Name: ssKey.Name},
+				Spec: appsv1.StatefulSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Image: "broker"}}},
+					},
+				},
+			}
+
+			pod, err := c.newPod(sts, scaledown, 1)
+			Expect(err).Should(Succeed())
+
+			Expect(pod.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+			Expect(*pod.Spec.ActiveDeadlineSeconds).To(Equal(deadline))
+		})
+
+		It("numbers the attempts of a retrying drain", func() {
+			scaledown := &brokerv1beta1.ActiveMQArtemisScaledown{
+				Status: brokerv1beta1.ActiveMQArtemisScaledownStatus{
+					Drains: []brokerv1beta1.ScaledownDrainStatus{
+						{Ordinal: 1, Attempts: 2, Result: brokerv1beta1.ScaledownDrainRetrying},
+						{Ordinal: 2, Attempts: 4, Result: brokerv1beta1.ScaledownDrainFailed},
+					},
+				},
+			}
+
+			Expect(nextDrainAttempt(scaledown, 1)).To(Equal(int32(3)))
+			Expect(nextDrainAttempt(scaledown, 2)).To(Equal(int32(1)))
+			Expect(nextDrainAttempt(nil, 1)).To(Equal(int32(1)))
+			Expect(drainRetries(scaledown)).To(Equal(int32(3)))
+			Expect(drainRetryDelay(1)).To(Equal(5 * time.Second))
+			Expect(drainRetryDelay(3)).To(Equal(20 * time.Second))
+			Expect(drainRetryDelay(10)).To(Equal(300 * time.Second))
+		})
+
+		It("replaces a failed drain pod till the retries are used up", func() {
+			retries := int32(1)
+			scaledown := &brokerv1beta1.ActiveMQArtemisScaledown{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "br"},
+				Spec:       brokerv1beta1.ActiveMQArtemisScaledownSpec{DrainRetries: &retries},
+			}
+			sts := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "br-ss"}}
+			finished := metav1.NewTime(time.Now().Add(-time.Minute))
+			failedPod := func(attempt string, finishedAt metav1.Time) *corev1.Pod {
+				return &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:   "test",
+						Name:        "br-ss-1",
+						Annotations: map[string]string{AnnotationStatefulSet: sts.Name, AnnotationDrainAttempt: attempt},
+					},
+					Status: corev1.PodStatus{
+						Phase:   corev1.PodFailed,
+						Reason:  "DeadlineExceeded",
+						Message: "Pod was active on the node longer than the specified deadline",
+						ContainerStatuses: []corev1.ContainerStatus{
+							{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: finishedAt}}},
+						},
+					},
 				}
 			}
-			Expect(servicePort).To(Equal("7800"))
+			recorder := record.NewFakeRecorder(10)
+			podKey := types.NamespacedName{Namespace: "test", Name: "br-ss-1"}
+
+			// the first attempt is retried once its backoff is over
+			pod := failedPod("1", finished)
+			c := &Controller{client: newTestClient(pod), recorder: recorder, log: ctrl.Log.WithName("drain_test")}
+			wait, retried, err := c.handleFailedDrainPod(sts, pod, scaledown)
+			Expect(err).Should(Succeed())
+			Expect(wait).To(BeZero())
+			Expect(retried).To(BeFalse())
+			Expect(errors.IsNotFound(c.client.Get(context.TODO(), podKey, &corev1.Pod{}))).To(BeTrue())
+
+			// an attempt that just failed waits for its backoff
+			pod = failedPod("1", metav1.Now())
+			c.client = newTestClient(pod)
+			wait, retried, err = c.handleFailedDrainPod(sts, pod, scaledown)
+			Expect(err).Should(Succeed())
+			Expect(wait).To(BeNumerically(">", 0))
+			Expect(wait).To(BeNumerically("<=", drainRetryDelay(1)))
+			Expect(c.client.Get(context.TODO(), podKey, &corev1.Pod{})).Should(Succeed())
+
+			// the last attempt is kept with a warning
+			pod = failedPod("2", finished)
+			c.client = newTestClient(pod)
+			wait, retried, err = c.handleFailedDrainPod(sts, pod, scaledown)
+			Expect(err).Should(Succeed())
+			Expect(wait).To(BeZero())
+			Expect(c.client.Get(context.TODO(), podKey, &corev1.Pod{})).Should(Succeed())
+			Expect(recorder.Events).To(Receive(ContainSubstring("Warning DrainFailed drain Pod br-ss-1 in StatefulSet br-ss failed after 2 attempts")))
+
+			// the warning is not repeated for a drain already reported failed
+			scaledown.Status.Drains = []brokerv1beta1.ScaledownDrainStatus{{Ordinal: 1, PodName: pod.Name, Result: brokerv1beta1.ScaledownDrainFailed}}
+			_, retried, err = c.handleFailedDrainPod(sts, pod, scaledown)
+			Expect(err).Should(Succeed())
+			Expect(recorder.Events).NotTo(Receive())
+
+			// the retry annotation restarts a failed drain
+			scaledown.Annotations = map[string]string{AnnotationRetryDrain: "true"}
+			_, retried, err = c.handleFailedDrainPod(sts, pod, scaledown)
+			Expect(err).Should(Succeed())
+			Expect(retried).To(BeTrue())
+			Expect(errors.IsNotFound(c.client.Get(context.TODO(), podKey, &corev1.Pod{}))).To(BeTrue())
+		})
+
+		It("clears the retry annotation once the failed drain pod is deleted", func() {
+			replicas := int32(2)
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "br-ss"},
+				Spec: appsv1.StatefulSetSpec{
+					Replicas:             &replicas,
+					VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "br"}}},
+				},
+			}
+			claim := func(ordinal string) *corev1.PersistentVolumeClaim {
+				return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "br-br-ss-" + ordinal}}
+			}
+			failedPod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "test",
+					Name:        "br-ss-1",
+					Annotations: map[string]string{AnnotationStatefulSet: sts.Name, AnnotationDrainAttempt: "4"},
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "DeadlineExceeded"},
+			}
+			scaledown := newTestScaledown()
+			scaledown.Annotations[AnnotationRetryDrain] = "true"
+			client := newTestClient(scaledown, claim("0"), claim("1"), failedPod)
+			c := &Controller{client: client, recorder: record.NewFakeRecorder(10), log: ctrl.Log.WithName("drain_test")}
+			podKey := types.NamespacedName{Namespace: "test", Name: "br-ss-1"}
+			scaledownKey := types.NamespacedName{Namespace: "test", Name: "br"}
+
+			// scaled back up, the failed drain pod makes way for the broker pod
+			// and the retry request is left for a drain that is retried
+			_, err := c.processStatefulSet(sts, scaledown)
+			Expect(err).Should(Succeed())
+			Expect(errors.IsNotFound(client.Get(context.TODO(), podKey, &corev1.Pod{}))).To(BeTrue())
+			instance := &brokerv1beta1.ActiveMQArtemisScaledown{}
+			Expect(client.Get(context.TODO(), scaledownKey, instance)).Should(Succeed())
+			Expect(instance.Annotations).To(HaveKey(AnnotationRetryDrain))
+
+			// scaled down again, the failed drain of ordinal 1 is retried on request
+			replicas = 1
+			failedPod.ResourceVersion = ""
+			Expect(client.Create(context.TODO(), failedPod)).Should(Succeed())
+			_, err = c.processStatefulSet(sts, instance)
+			Expect(err).Should(Succeed())
+			Expect(errors.IsNotFound(client.Get(context.TODO(), podKey, &corev1.Pod{}))).To(BeTrue())
+			Expect(client.Get(context.TODO(), scaledownKey, instance)).Should(Succeed())
+			Expect(instance.Annotations).NotTo(HaveKey(AnnotationRetryDrain))
+		})
+
+		It("reports a drain as failed once its retries are used up", func() {
+			c := &Controller{client: newTestClient(), log: ctrl.Log.WithName("drain_test")}
+			replicas := int32(1)
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "br-ss"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+			}
+			claims := map[int][]*corev1.PersistentVolumeClaim{0: {}, 1: {}}
+			drainPod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         "test",
+					Name:              "br-ss-1",
+					CreationTimestamp: metav1.NewTime(time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)),
+					Annotations:       map[string]string{AnnotationStatefulSet: sts.Name, AnnotationDrainAttempt: "1"},
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "DeadlineExceeded", Message: "too slow"},
+			}
+			Expect(c.client.Create(context.TODO(), drainPod)).Should(Succeed())
+
+			drains := c.observeDrains(sts, claims, nil, 1)
+			Expect(drains).To(HaveLen(1))
+			Expect(drains[0].Result).To(Equal(brokerv1beta1.ScaledownDrainRetrying))
+			Expect(drains[0].Attempts).To(Equal(int32(1)))
+			Expect(drains[0].Message).To(Equal("DeadlineExceeded: too slow"))
+
+			// the retrying drain stays while the pod of the next attempt is created
+			Expect(c.client.Delete(context.TODO(), drainPod)).Should(Succeed())
+			drains = c.observeDrains(sts, claims, drains, 1)
+			Expect(drains[0].Result).To(Equal(brokerv1beta1.ScaledownDrainRetrying))
+
+			drainPod = drainPod.DeepCopy()
+			drainPod.ResourceVersion = ""
+			drainPod.CreationTimestamp = metav1.NewTime(drainPod.CreationTimestamp.Add(time.Minute))
+			drainPod.Annotations[AnnotationDrainAttempt] = "2"
+			Expect(c.client.Create(context.TODO(), drainPod)).Should(Succeed())
+			drains = c.observeDrains(sts, claims, drains, 1)
+			Expect(drains[0].Result).To(Equal(brokerv1beta1.ScaledownDrainFailed))
+			Expect(drains[0].Attempts).To(Equal(int32(2)))
+
+			condition := migrationCompleteCondition(drains)
+			Expect(condition.Reason).To(Equal(brokerv1beta1.MigrationCompleteConditionFailedReason))
+		})
+	})
+
+	Context("Drain key test", func() {
+		It("maps a scaledown CR to its statefulset and a pod back to the scaledown CR", func() {
+			scaledown := newTestScaledown()
+			Expect(StatefulSetKey(scaledown)).To(Equal(types.NamespacedName{Namespace: "test", Name: "br-ss"}))
+			scaledown.Annotations = nil
+			Expect(StatefulSetKey(scaledown)).To(Equal(types.NamespacedName{Namespace: "test", Name: "br-ss"}))
+
+			isController := true
+			brokerPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "test",
+				Name:            "br-ss-0",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "br-ss", Controller: &isController}},
+			}}
+			Expect(ScaledownKey(brokerPod)).To(Equal(&types.NamespacedName{Namespace: "test", Name: "br"}))
+
+			drainPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "test",
+				Name:        "br-ss-1",
+				Annotations: map[string]string{AnnotationStatefulSet: "br-ss"},
+			}}
+			Expect(ScaledownKey(drainPod)).To(Equal(&types.NamespacedName{Namespace: "test", Name: "br"}))
+
+			Expect(ScaledownKey(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "other"}})).To(BeNil())
+		})
+	})
+
+	Context("Drain target test", func() {
+		readyPod := func(name string, ip string, ready corev1.ConditionStatus) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: name},
+				Status: corev1.PodStatus{
+					Phase:      corev1.PodRunning,
+					PodIP:      ip,
+					Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+				},
+			}
+		}
+
+		It("drains to any ready broker of the statefulset", func() {
+			c := &Controller{client: newTestClient(), log: ctrl.Log.WithName("drain_test")}
+			replicas := int32(2)
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "br-ss"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+			}
+			Expect(c.client.Create(context.TODO(), readyPod("br-ss-0", "10.0.0.1", corev1.ConditionFalse))).Should(Succeed())
+
+			target, err := c.findDrainTarget(sts, nil)
+			Expect(err).Should(Succeed())
+			Expect(target).To(BeNil())
+
+			// ordinal 0 doesn't have to be the one
+			Expect(c.client.Create(context.TODO(), readyPod("br-ss-1", "10.0.0.2", corev1.ConditionTrue))).Should(Succeed())
+			target, err = c.findDrainTarget(sts, nil)
+			Expect(err).Should(Succeed())
+			Expect(target).To(Equal(&drainTarget{name: "br-ss-1", ip: "10.0.0.2", port: 61616}))
+		})
+
+		It("drains to the drain target on a scale down to zero", func() {
+			c := &Controller{client: newTestClient(), log: ctrl.Log.WithName("drain_test")}
+			replicas := int32(0)
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "br-ss"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+			}
+			scaledown := &brokerv1beta1.ActiveMQArtemisScaledown{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "br"}}
+
+			target, err := c.findDrainTarget(sts, scaledown)
+			Expect(err).Should(Succeed())
+			Expect(target).To(BeNil())
+
+			scaledown.Spec.DrainTarget = &brokerv1beta1.DrainTargetType{BrokerName: "next"}
+			Expect(c.client.Create(context.TODO(), readyPod("next-ss-0", "10.0.1.1", corev1.ConditionFalse))).Should(Succeed())
+			target, err = c.findDrainTarget(sts, scaledown)
+			Expect(err).Should(Succeed())
+			Expect(target).To(BeNil())
+
+			Expect(c.client.Create(context.TODO(), readyPod("next-ss-1", "10.0.1.2", corev1.ConditionTrue))).Should(Succeed())
+			target, err = c.findDrainTarget(sts, scaledown)
+			Expect(err).Should(Succeed())
+			Expect(target).To(Equal(&drainTarget{name: "next-ss-1", ip: "10.0.1.2", port: 61616, credentialsSecret: "next-credentials-secret"}))
+
+			scaledown.Spec.DrainTarget = &brokerv1beta1.DrainTargetType{Host: "192.168.1.10"}
+			target, err = c.findDrainTarget(sts, scaledown)
+			Expect(err).Should(Succeed())
+			Expect(target).To(Equal(&drainTarget{name: "192.168.1.10", ip: "192.168.1.10", port: 61616}))
+
+			scaledown.Spec.DrainTarget = &brokerv1beta1.DrainTargetType{Host: "192.168.1.10", Port: 61617, CredentialsSecret: "remote-credentials"}
+			target, err = c.findDrainTarget(sts, scaledown)
+			Expect(err).Should(Succeed())
+			Expect(target).To(Equal(&drainTarget{name: "192.168.1.10", ip: "192.168.1.10", port: 61617, credentialsSecret: "remote-credentials"}))
+
+			scaledown.Spec.DrainTarget = &brokerv1beta1.DrainTargetType{Host: "broker"}
+			_, err = c.findDrainTarget(sts, scaledown)
+			Expect(err).Should(MatchError(ContainSubstring("has to be an IP address or a fully qualified host name")))
+
+			scaledown.Spec.DrainTarget = &brokerv1beta1.DrainTargetType{}
+			_, err = c.findDrainTarget(sts, scaledown)
+			Expect(err).Should(HaveOccurred())
+		})
+
+		It("points the drain pod at a service holding its target", func() {
+			c := &Controller{client: newTestClient(), log: ctrl.Log.WithName("drain_test")}
+			serviceKey := types.NamespacedName{Namespace: "test", Name: "br-ss-1-drain-target"}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "br-ss-1", Annotations: map[string]string{}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Env: []corev1.EnvVar{{Name: "HEADLESS_SVC_NAME", Value: "br-hdls-svc"}},
+				}}},
+			}
+
+			Expect(c.ensureDrainTargetService(pod, &drainTarget{name: "br-ss-0", ip: "10.0.0.1", port: 61616})).Should(Succeed())
+			// the next attempt can pick another target
+			Expect(c.ensureDrainTargetService(pod, &drainTarget{name: "br-ss-2", ip: "10.0.0.3", port: 61616})).Should(Succeed())
+			Expect(c.useDrainTarget(pod, &drainTarget{name: "br-ss-2", ip: "10.0.0.3", port: 61616})).Should(Succeed())
+
+			Expect(pod.Annotations[AnnotationDrainTarget]).To(Equal("br-ss-2"))
+			Expect(pod.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "HEADLESS_SVC_NAME", Value: "br-ss-1-drain-target"}))
+			service := &corev1.Service{}
+			Expect(c.client.Get(context.TODO(), serviceKey, service)).Should(Succeed())
+			Expect(service.Spec.Selector).To(BeEmpty())
+			endpoints := &corev1.Endpoints{}
+			Expect(c.client.Get(context.TODO(), serviceKey, endpoints)).Should(Succeed())
+			Expect(endpoints.Subsets).To(HaveLen(1))
+			Expect(endpoints.Subsets[0].Addresses).To(Equal([]corev1.EndpointAddress{{IP: "10.0.0.3"}}))
+			Expect(endpoints.Subsets[0].Ports[0].Port).To(Equal(int32(61616)))
+
+			Expect(c.deleteDrainTargetService("test", pod.Name)).Should(Succeed())
+			Expect(errors.IsNotFound(c.client.Get(context.TODO(), serviceKey, &corev1.Service{}))).To(BeTrue())
+			Expect(errors.IsNotFound(c.client.Get(context.TODO(), serviceKey, &corev1.Endpoints{}))).To(BeTrue())
+		})
+
+		It("gives the drain pod the port and cluster credentials of its target", func() {
+			c := &Controller{client: newTestClient(), log: ctrl.Log.WithName("drain_test")}
+			serviceKey := types.NamespacedName{Namespace: "test", Name: "br-ss-1-drain-target"}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "br-ss-1", Annotations: map[string]string{}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Env: []corev1.EnvVar{
+						{Name: "HEADLESS_SVC_NAME", Value: "br-hdls-svc"},
+						{Name: "AMQ_CLUSTER_USER", Value: "br-user"},
+						{Name: "AMQ_CLUSTER_PASSWORD", Value: "br-password"},
+					},
+				}}},
+			}
+			target := &drainTarget{name: "broker.example.com", ip: "192.168.1.10", port: 61617, credentialsSecret: "remote-credentials"}
+
+			Expect(c.useDrainTarget(pod, target)).Should(MatchError(ContainSubstring("unable to get the credentials secret remote-credentials")))
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "remote-credentials"},
+				Data:       map[string][]byte{"AMQ_CLUSTER_USER": []byte("remote-user")},
+			}
+			Expect(c.client.Create(context.TODO(), secret)).Should(Succeed())
+			Expect(c.useDrainTarget(pod, target)).Should(MatchError(ContainSubstring("has no AMQ_CLUSTER_PASSWORD key")))
+
+			secret.Data["AMQ_CLUSTER_PASSWORD"] = []byte("remote-password")
+			Expect(c.client.Update(context.TODO(), secret)).Should(Succeed())
+			Expect(c.useDrainTarget(pod, target)).Should(Succeed())
+			Expect(c.ensureDrainTargetService(pod, target)).Should(Succeed())
+
+			Expect(pod.Spec.Containers[0].Env).To(ContainElements(
+				corev1.EnvVar{Name: "AMQ_CLUSTER_USER", Value: "remote-user"},
+				corev1.EnvVar{Name: "AMQ_CLUSTER_PASSWORD", Value: "remote-password"}))
+			service := &corev1.Service{}
+			Expect(c.client.Get(context.TODO(), serviceKey, service)).Should(Succeed())
+			Expect(service.Spec.Ports[0].Port).To(Equal(int32(61617)))
+			endpoints := &corev1.Endpoints{}
+			Expect(c.client.Get(context.TODO(), serviceKey, endpoints)).Should(Succeed())
+			Expect(endpoints.Subsets[0].Ports[0].Port).To(Equal(int32(61617)))
 		})
 	})
 })
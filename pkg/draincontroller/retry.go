@@ -0,0 +1,158 @@
+package draincontroller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Each attempt to drain an ordinal is a drain pod that doesn't restart, a
+// deadline fails it through its activeDeadlineSeconds. A failed pod is
+// replaced after a backoff till the retries are used up, then it is kept with
+// the claims of the ordinal till the retry annotation is set on the scaledown
+// CR.
+
+const (
+	AnnotationDrainAttempt = "broker.amq.io/drain-attempt"
+	AnnotationRetryDrain   = "broker.amq.io/retry-drain"
+
+	DrainFailed           = "DrainFailed"
+	MessageDrainPodFailed = "drain Pod %s in StatefulSet %s failed after %d attempts, its claims are kept: %s"
+
+	defaultDrainRetries = 3
+	drainRetryBaseDelay = 5 * time.Second
+	drainRetryMaxDelay  = 300 * time.Second
+)
+
+func drainRetries(scaledown *brokerv1beta1.ActiveMQArtemisScaledown) int32 {
+	if scaledown != nil && scaledown.Spec.DrainRetries != nil {
+		return *scaledown.Spec.DrainRetries
+	}
+	return defaultDrainRetries
+}
+
+func drainAttempt(pod *corev1.Pod) int32 {
+	attempt, err := strconv.Atoi(pod.Annotations[AnnotationDrainAttempt])
+	if err != nil || attempt < 1 {
+		return 1
+	}
+	return int32(attempt)
+}
+
+// drainRetryDelay doubles with each failed attempt like the rate limiter of
+// the workqueue
+func drainRetryDelay(attempt int32) time.Duration {
+	delay := drainRetryBaseDelay
+	for i := int32(1); i < attempt && delay < drainRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > drainRetryMaxDelay {
+		return drainRetryMaxDelay
+	}
+	return delay
+}
+
+func drainFailureMessage(pod *corev1.Pod) string {
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason + ": " + pod.Status.Message
+	}
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if terminated := containerStatus.State.Terminated; terminated != nil {
+			return fmt.Sprintf("%s: exit code %d", terminated.Reason, terminated.ExitCode)
+		}
+	}
+	return "drain pod failed"
+}
+
+func drainFinishTime(pod *corev1.Pod) time.Time {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if terminated := containerStatus.State.Terminated; terminated != nil {
+			return terminated.FinishedAt.Time
+		}
+	}
+	if pod.Status.StartTime != nil {
+		return pod.Status.StartTime.Time
+	}
+	return pod.CreationTimestamp.Time
+}
+
+// nextDrainAttempt numbers the drain pod about to be created, a drain that
+// is not retrying starts over
+func nextDrainAttempt(scaledown *brokerv1beta1.ActiveMQArtemisScaledown, ordinal int) int32 {
+	if scaledown != nil {
+		for _, drain := range scaledown.Status.Drains {
+			if drain.Ordinal == int32(ordinal) && drain.Result == brokerv1beta1.ScaledownDrainRetrying {
+				return drain.Attempts + 1
+			}
+		}
+	}
+	return 1
+}
+
+// handleFailedDrainPod removes a failed drain pod once its backoff is over so
+// that the next attempt can start, the pod of the last attempt is kept. It
+// gives how long the backoff still lasts, and tells if the pod of the last
+// attempt was removed on request.
+func (c *Controller) handleFailedDrainPod(sts *appsv1.StatefulSet, pod *corev1.Pod, scaledown *brokerv1beta1.ActiveMQArtemisScaledown) (time.Duration, bool, error) {
+	attempt := drainAttempt(pod)
+	requested := false
+	if attempt > drainRetries(scaledown) {
+		if scaledown == nil || scaledown.Annotations[AnnotationRetryDrain] == "" {
+			alreadyFailed := false
+			if scaledown != nil {
+				for _, drain := range scaledown.Status.Drains {
+					if drain.PodName == pod.Name && drain.Result == brokerv1beta1.ScaledownDrainFailed {
+						alreadyFailed = true
+					}
+				}
+			}
+			if !alreadyFailed {
+				c.recorder.Event(sts, corev1.EventTypeWarning, DrainFailed, fmt.Sprintf(MessageDrainPodFailed, pod.Name, sts.Name, attempt, drainFailureMessage(pod)))
+			}
+			return 0, false, nil
+		}
+		c.log.V(1).Info("Retrying failed drain on request", "pod", pod.Name)
+		requested = true
+	} else if wait := time.Until(drainFinishTime(pod).Add(drainRetryDelay(attempt))); wait > 0 {
+		c.log.V(2).Info("Waiting to retry drain", "pod", pod.Name, "attempt", attempt, "wait", wait)
+		return wait, false, nil
+	}
+
+	c.log.V(1).Info("Deleting failed drain pod " + pod.Name + " to retry")
+	err := c.client.Delete(context.TODO(), pod)
+	if err != nil && !errors.IsNotFound(err) {
+		return 0, false, err
+	}
+	return 0, requested, nil
+}
+
+// removeFailedDrainPod deletes the failed drain pod of an ordinal the
+// statefulset was scaled back up to, the broker pod of the ordinal can't be
+// created while it holds the name
+func (c *Controller) removeFailedDrainPod(sts *appsv1.StatefulSet, pod *corev1.Pod, scaledown *brokerv1beta1.ActiveMQArtemisScaledown) error {
+	defer c.cleanupDrainRBACResources(sts.Namespace, scaledown.Spec.LocalOnly)
+
+	c.log.V(1).Info("Deleting failed drain pod " + pod.Name + " of an ordinal in use again")
+	if err := c.client.Delete(context.TODO(), pod); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return c.deleteDrainTargetService(sts.Namespace, pod.Name)
+}
+
+// clearRetryDrain removes the retry annotation once the failed drains it
+// asked for are deleted
+func (c *Controller) clearRetryDrain(scaledown *brokerv1beta1.ActiveMQArtemisScaledown) {
+	if scaledown == nil || scaledown.Annotations[AnnotationRetryDrain] == "" {
+		return
+	}
+	delete(scaledown.Annotations, AnnotationRetryDrain)
+	if err := c.client.Update(context.TODO(), scaledown); err != nil {
+		c.log.Error(err, "unable to remove the retry annotation of scaledown "+scaledown.Name)
+	}
+}
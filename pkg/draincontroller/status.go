@@ -0,0 +1,211 @@
+package draincontroller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/resources"
+	mgmt "github.com/artemiscloud/activemq-artemis-operator/pkg/utils/artemis"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The status of a scaledown CR lists the ordinals its statefulset was scaled
+// down from while their volumes still hold data. A drain pod runs a broker on
+// the volumes of an ordinal that scales its messages down to its target, its
+// jolokia tells how many messages are left.
+
+// jolokiaMessageCount reads how many messages the broker of a drain pod holds
+func jolokiaMessageCount(pod *corev1.Pod) (int64, error) {
+	if pod.Status.PodIP == "" {
+		return 0, fmt.Errorf("drain pod %s has no IP yet", pod.Name)
+	}
+	env := map[string]string{}
+	for _, envVar := range pod.Spec.Containers[0].Env {
+		env[envVar.Name] = envVar.Value
+	}
+	artemis := mgmt.GetArtemis(pod.Status.PodIP, "8161", env["AMQ_NAME"], env["AMQ_USER"], env["AMQ_PASSWORD"], "http")
+	return artemis.GetTotalMessageCount()
+}
+
+// updateScaledownStatus records the drains of the orphaned ordinals of the
+// statefulset in the status of its scaledown CR
+func (c *Controller) updateScaledownStatus(sts *appsv1.StatefulSet, instance *brokerv1beta1.ActiveMQArtemisScaledown, claimsGroupedByOrdinal map[int][]*corev1.PersistentVolumeClaim) {
+	current := instance.Status.DeepCopy()
+	instance.Status.Drains = c.observeDrains(sts, claimsGroupedByOrdinal, instance.Status.Drains, drainRetries(instance))
+	meta.SetStatusCondition(&instance.Status.Conditions, migrationCompleteCondition(instance.Status.Drains))
+	if !reflect.DeepEqual(current, &instance.Status) {
+		resources.UpdateStatus(c.client, instance)
+	}
+}
+
+// updateNoDrainTargetStatus tells that a scale down to zero leaves the
+// messages in the volumes of the brokers, there is no broker to drain them to
+func (c *Controller) updateNoDrainTargetStatus(instance *brokerv1beta1.ActiveMQArtemisScaledown) {
+	current := instance.Status.DeepCopy()
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:    brokerv1beta1.MigrationCompleteConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  brokerv1beta1.MigrationCompleteConditionNoTargetReason,
+		Message: "scaled down to zero without a drainTarget, the messages stay in the volumes of the brokers",
+	})
+	if !reflect.DeepEqual(current, &instance.Status) {
+		resources.UpdateStatus(c.client, instance)
+	}
+}
+
+// observeDrains gives the drain of each orphaned ordinal, the drains that
+// finished stay after their volumes are removed
+func (c *Controller) observeDrains(sts *appsv1.StatefulSet, claimsGroupedByOrdinal map[int][]*corev1.PersistentVolumeClaim, previous []brokerv1beta1.ScaledownDrainStatus, retries int32) []brokerv1beta1.ScaledownDrainStatus {
+	orphaned := map[int32]bool{}
+	for ordinal := range claimsGroupedByOrdinal {
+		// ordinal 0 is only drained on a scale down to zero
+		if int32(ordinal) >= *sts.Spec.Replicas {
+			orphaned[int32(ordinal)] = true
+		}
+	}
+
+	var drains []brokerv1beta1.ScaledownDrainStatus
+	for _, drain := range previous {
+		if orphaned[drain.Ordinal] {
+			continue
+		}
+		// the volumes of a drain that didn't succeed are gone or back in use
+		if drain.Result == brokerv1beta1.ScaledownDrainSucceeded {
+			drains = append(drains, drain)
+		}
+	}
+
+	for ordinal := range orphaned {
+		drain := brokerv1beta1.ScaledownDrainStatus{Ordinal: ordinal}
+		for _, previousDrain := range previous {
+			if previousDrain.Ordinal == ordinal {
+				drain = previousDrain
+			}
+		}
+		podName := getPodName(sts, int(ordinal))
+		pod, err := c.getPod(sts.Namespace, podName)
+		if err != nil {
+			c.log.V(1).Info("unable to get drain pod", "pod", podName, "error", err)
+		}
+		drains = append(drains, c.observeDrain(drain, podName, pod, retries))
+	}
+
+	sort.Slice(drains, func(i, j int) bool {
+		return drains[i].Ordinal < drains[j].Ordinal
+	})
+	return drains
+}
+
+func (c *Controller) observeDrain(drain brokerv1beta1.ScaledownDrainStatus, podName string, pod *corev1.Pod, retries int32) brokerv1beta1.ScaledownDrainStatus {
+	if !isDrainPod(pod) {
+		// the pod of the next attempt is not created yet
+		if drain.Result == brokerv1beta1.ScaledownDrainRetrying {
+			return drain
+		}
+		// the removed broker is still stopping or no broker is ready to drain to
+		return brokerv1beta1.ScaledownDrainStatus{
+			Ordinal: drain.Ordinal,
+			PodName: podName,
+			Result:  brokerv1beta1.ScaledownDrainPending,
+		}
+	}
+
+	// each attempt has a drain pod of its own
+	if drain.StartTime == nil || !drain.StartTime.Equal(&pod.CreationTimestamp) {
+		startTime := pod.CreationTimestamp
+		drain = brokerv1beta1.ScaledownDrainStatus{
+			Ordinal:   drain.Ordinal,
+			PodName:   podName,
+			StartTime: &startTime,
+			Target:    pod.Annotations[AnnotationDrainTarget],
+			Attempts:  drainAttempt(pod),
+		}
+	}
+	drain.Phase = pod.Status.Phase
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		drain.Result = brokerv1beta1.ScaledownDrainSucceeded
+		drain.EndTime = drainEndTime(drain, pod)
+		// the broker of the drain pod is empty once it completes
+		if drain.MessagesToMigrate != nil {
+			migrated := *drain.MessagesToMigrate
+			drain.MessagesMigrated = &migrated
+		}
+	case corev1.PodFailed:
+		drain.Result = brokerv1beta1.ScaledownDrainRetrying
+		if drain.Attempts > retries {
+			drain.Result = brokerv1beta1.ScaledownDrainFailed
+		}
+		drain.EndTime = drainEndTime(drain, pod)
+		drain.Message = drainFailureMessage(pod)
+	case corev1.PodRunning:
+		drain.Result = brokerv1beta1.ScaledownDrainDraining
+		count, err := c.countMessages(pod)
+		if err != nil {
+			c.log.V(2).Info("unable to count the messages of drain pod", "pod", podName, "error", err)
+			break
+		}
+		if drain.MessagesToMigrate == nil || count > *drain.MessagesToMigrate {
+			drain.MessagesToMigrate = &count
+		}
+		migrated := *drain.MessagesToMigrate - count
+		drain.MessagesMigrated = &migrated
+	default:
+		drain.Result = brokerv1beta1.ScaledownDrainDraining
+	}
+	return drain
+}
+
+func drainEndTime(drain brokerv1beta1.ScaledownDrainStatus, pod *corev1.Pod) *metav1.Time {
+	if drain.EndTime != nil {
+		return drain.EndTime
+	}
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Terminated != nil {
+			return &containerStatus.State.Terminated.FinishedAt
+		}
+	}
+	now := metav1.Now()
+	return &now
+}
+
+func migrationCompleteCondition(drains []brokerv1beta1.ScaledownDrainStatus) metav1.Condition {
+	var failed, inProgress []string
+	for _, drain := range drains {
+		switch drain.Result {
+		case brokerv1beta1.ScaledownDrainFailed:
+			failed = append(failed, fmt.Sprint(drain.Ordinal))
+		case brokerv1beta1.ScaledownDrainPending, brokerv1beta1.ScaledownDrainDraining, brokerv1beta1.ScaledownDrainRetrying:
+			inProgress = append(inProgress, fmt.Sprint(drain.Ordinal))
+		}
+	}
+
+	if len(failed) > 0 {
+		return metav1.Condition{
+			Type:    brokerv1beta1.MigrationCompleteConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  brokerv1beta1.MigrationCompleteConditionFailedReason,
+			Message: "the drain of ordinal " + strings.Join(failed, ", ") + " failed",
+		}
+	}
+	if len(inProgress) > 0 {
+		return metav1.Condition{
+			Type:    brokerv1beta1.MigrationCompleteConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  brokerv1beta1.MigrationCompleteConditionInProgressReason,
+			Message: "waiting for the drain of ordinal " + strings.Join(inProgress, ", "),
+		}
+	}
+	return metav1.Condition{
+		Type:   brokerv1beta1.MigrationCompleteConditionType,
+		Status: metav1.ConditionTrue,
+		Reason: brokerv1beta1.MigrationCompleteConditionCompleteReason,
+	}
+}
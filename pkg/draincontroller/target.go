@@ -0,0 +1,207 @@
+package draincontroller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	brokerv1beta1 "github.com/artemiscloud/activemq-artemis-operator/api/v1beta1"
+	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/namer"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// drain.sh scales the messages down to a broker it finds in the endpoints of
+// the service named by HEADLESS_SVC_NAME. Each drain pod gets a service
+// without selector whose endpoints hold the one target the controller picked:
+// a ready broker of the statefulset, or the drain target of the scaledown CR
+// once the statefulset is scaled down to zero.
+
+const (
+	AnnotationDrainTarget = "broker.amq.io/drain-target"
+
+	// the CORE acceptor port of the brokers, the scale down connector of
+	// drain.sh uses it
+	drainTargetPort = 61616
+)
+
+type drainTarget struct {
+	// what the status of the scaledown CR reports
+	name string
+	ip   string
+	port int32
+	// the secret with the cluster credentials of the target, empty for the
+	// credentials of the drained deployment
+	credentialsSecret string
+}
+
+func credentialsSecretName(crName string) string {
+	secretNamer := namer.NamerData{}
+	secretNamer.Prefix(crName).Base("credentials").Suffix("secret").Generate()
+	return secretNamer.Name()
+}
+
+func drainTargetServiceName(podName string) string {
+	return podName + "-drain-target"
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+		return false
+	}
+	for _, podCondition := range pod.Status.Conditions {
+		if podCondition.Type == corev1.PodReady {
+			return podCondition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// readyBroker gives the broker pod as a target when it is ready, found is
+// false when there is no such pod
+func (c *Controller) readyBroker(namespace string, podName string) (target *drainTarget, found bool, err error) {
+	pod, err := c.getPod(namespace, podName)
+	if pod == nil || err != nil {
+		return nil, false, err
+	}
+	if isDrainPod(pod) || !isPodReady(pod) {
+		c.log.V(2).Info("Pod " + podName + " is not Ready to drain to")
+		return nil, true, nil
+	}
+	return &drainTarget{name: podName, ip: pod.Status.PodIP, port: drainTargetPort}, true, nil
+}
+
+// findDrainTarget picks where the messages of the orphaned ordinals go, nil
+// when no target is ready yet
+func (c *Controller) findDrainTarget(sts *appsv1.StatefulSet, scaledown *brokerv1beta1.ActiveMQArtemisScaledown) (*drainTarget, error) {
+	if *sts.Spec.Replicas > 0 {
+		for ordinal := 0; ordinal < int(*sts.Spec.Replicas); ordinal++ {
+			target, _, err := c.readyBroker(sts.Namespace, getPodName(sts, ordinal))
+			if target != nil || err != nil {
+				return target, err
+			}
+		}
+		return nil, nil
+	}
+
+	if scaledown == nil || scaledown.Spec.DrainTarget == nil {
+		return nil, nil
+	}
+	spec := scaledown.Spec.DrainTarget
+	if spec.BrokerName != "" {
+		// the pods of a statefulset are numbered without gaps
+		for ordinal := 0; ; ordinal++ {
+			target, found, err := c.readyBroker(sts.Namespace, namer.CrToSS(spec.BrokerName)+"-"+strconv.Itoa(ordinal))
+			if target != nil {
+				target.credentialsSecret = credentialsSecretName(spec.BrokerName)
+			}
+			if target != nil || !found || err != nil {
+				return target, err
+			}
+		}
+	}
+	if spec.Host == "" {
+		return nil, fmt.Errorf("the drain target of scaledown %s has neither brokerName nor host", scaledown.Name)
+	}
+	port := spec.Port
+	if port == 0 {
+		port = drainTargetPort
+	}
+	if ip := net.ParseIP(spec.Host); ip != nil {
+		return &drainTarget{name: spec.Host, ip: ip.String(), port: port, credentialsSecret: spec.CredentialsSecret}, nil
+	}
+	// the endpoints hold an address, the operator resolves the host with its
+	// own search domains so a short name could be another broker
+	if !strings.Contains(strings.TrimSuffix(spec.Host, "."), ".") {
+		return nil, fmt.Errorf("the drain target %s of scaledown %s has to be an IP address or a fully qualified host name", spec.Host, scaledown.Name)
+	}
+	ips, err := net.LookupIP(spec.Host)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve drain target %s: %s", spec.Host, err)
+	}
+	return &drainTarget{name: spec.Host, ip: ips[0].String(), port: port, credentialsSecret: spec.CredentialsSecret}, nil
+}
+
+// useDrainTarget points the drain pod at the service of its target and gives
+// it the cluster credentials of the target
+func (c *Controller) useDrainTarget(pod *corev1.Pod, target *drainTarget) error {
+	values := map[string]string{"HEADLESS_SVC_NAME": drainTargetServiceName(pod.Name)}
+	if target.credentialsSecret != "" {
+		secret := &corev1.Secret{}
+		if err := c.client.Get(context.TODO(), types.NamespacedName{Namespace: pod.Namespace, Name: target.credentialsSecret}, secret); err != nil {
+			return fmt.Errorf("unable to get the credentials secret %s of drain target %s: %s", target.credentialsSecret, target.name, err)
+		}
+		for _, key := range []string{"AMQ_CLUSTER_USER", "AMQ_CLUSTER_PASSWORD"} {
+			value, found := secret.Data[key]
+			if !found {
+				return fmt.Errorf("the credentials secret %s of drain target %s has no %s key", target.credentialsSecret, target.name, key)
+			}
+			values[key] = string(value)
+		}
+	}
+
+	pod.Annotations[AnnotationDrainTarget] = target.name
+	env := pod.Spec.Containers[0].Env
+	for i := range env {
+		if value, found := values[env[i].Name]; found {
+			env[i].Value = value
+		}
+	}
+	return nil
+}
+
+// ensureDrainTargetService creates or updates the service and endpoints that
+// hold the target of the drain pod
+func (c *Controller) ensureDrainTargetService(pod *corev1.Pod, target *drainTarget) error {
+	objectMeta := metav1.ObjectMeta{
+		Name:            drainTargetServiceName(pod.Name),
+		Namespace:       pod.Namespace,
+		Labels:          map[string]string{LabelDrainPod: pod.Name},
+		OwnerReferences: pod.OwnerReferences,
+	}
+	ports := []corev1.ServicePort{{Name: "core", Port: target.port}}
+	service := &corev1.Service{
+		ObjectMeta: objectMeta,
+		Spec:       corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone, Ports: ports},
+	}
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: objectMeta,
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: target.ip}},
+			Ports:     []corev1.EndpointPort{{Name: "core", Port: target.port}},
+		}},
+	}
+
+	if err := c.client.Create(context.TODO(), service); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	c.log.V(1).Info("Drain pod "+pod.Name+" drains to "+target.name, "ip", target.ip)
+	existing := &corev1.Endpoints{}
+	err := c.client.Get(context.TODO(), types.NamespacedName{Namespace: endpoints.Namespace, Name: endpoints.Name}, existing)
+	if errors.IsNotFound(err) {
+		return c.client.Create(context.TODO(), endpoints)
+	}
+	if err != nil {
+		return err
+	}
+	existing.Subsets = endpoints.Subsets
+	return c.client.Update(context.TODO(), existing)
+}
+
+// deleteDrainTargetService removes the service of a drain pod that is done
+func (c *Controller) deleteDrainTargetService(namespace string, podName string) error {
+	objectMeta := metav1.ObjectMeta{Namespace: namespace, Name: drainTargetServiceName(podName)}
+	for _, obj := range []client.Object{&corev1.Service{ObjectMeta: objectMeta}, &corev1.Endpoints{ObjectMeta: objectMeta}} {
+		if err := c.client.Delete(context.TODO(), obj); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
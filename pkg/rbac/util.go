@@ -18,139 +18,91 @@ package rbacutil
 import (
 	"context"
 
+	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func CreateServiceAccount(name string, namespace string, kubeclientset kubernetes.Interface) (*corev1.ServiceAccount, error) {
+// The objects are created and deleted without reading them first, a read
+// through the manager client would start watching every role of the
+// namespaces the operator watches.
+
+func CreateServiceAccount(name string, namespace string, c client.Client) (*corev1.ServiceAccount, error) {
 	log := ctrl.Log.WithName("rbac")
-	getOps := metav1.GetOptions{}
-	result, err := kubeclientset.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), name, getOps)
-	if err == nil {
-		log.V(1).Info("Service account already exist", "name", name, "namespace", namespace)
-		return result, nil
-	}
-	if !errors.IsNotFound(err) {
-		log.Error(err, "Error in finding service account, will create it anyway", "name", name, "namespace", namespace)
-	}
 	serviceAccount := &corev1.ServiceAccount{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "ServiceAccount",
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
+			Name:      name,
+			Namespace: namespace,
 		},
 	}
 
 	log.V(1).Info("Creating service account", "name", name, "namespace", namespace)
-	result, err = kubeclientset.CoreV1().ServiceAccounts(namespace).Create(context.TODO(), serviceAccount, metav1.CreateOptions{})
-	if err != nil {
+	if err := c.Create(context.TODO(), serviceAccount); err != nil {
+		if errors.IsAlreadyExists(err) {
+			log.V(1).Info("Service account already exist", "name", name, "namespace", namespace)
+			return serviceAccount, nil
+		}
 		log.Error(err, "Failed to create service account", "name", name, "namespace", namespace)
 		return nil, err
 	}
-	return result, nil
+	return serviceAccount, nil
 }
 
-func DeleteServiceAccount(name string, namespace string, kubeclientset kubernetes.Interface) error {
+func DeleteServiceAccount(name string, namespace string, c client.Client) error {
 	log := ctrl.Log.WithName("rbac")
-	getOps := metav1.GetOptions{}
-	_, err := kubeclientset.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), name, getOps)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			log.V(1).Info("No such service account")
-			return nil
-		}
-		log.Error(err, "Error in finding service account")
-		// will try delete anyway
-	}
-	deletePeriod := int64(0)
-	options := metav1.DeleteOptions{
-		GracePeriodSeconds: &deletePeriod,
-	}
-
-	if err := kubeclientset.CoreV1().ServiceAccounts(namespace).Delete(context.TODO(), name, options); err != nil {
-		log.Error(err, "Failed to delete drain service account", "name", name, "namespace", namespace)
-	}
-	return err
+	serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	return deleteObject(c, serviceAccount, log.WithValues("service account", name, "namespace", namespace))
 }
 
-func CreateRole(name string, namespace string, rules []rbacv1.PolicyRule, kubeclientset kubernetes.Interface) (*rbacv1.Role, error) {
+func CreateRole(name string, namespace string, rules []rbacv1.PolicyRule, c client.Client) (*rbacv1.Role, error) {
 	log := ctrl.Log.WithName("rbac")
-	getOps := metav1.GetOptions{}
-	result, err := kubeclientset.RbacV1().Roles(namespace).Get(context.TODO(), name, getOps)
-	if err == nil {
-		log.V(1).Info("Role already exist", "name", name, "namespace", namespace)
-		return result, nil
-	}
-	if !errors.IsNotFound(err) {
-		log.Error(err, "Error in finding role, will create it anyway", "name", name, "namespace", namespace)
-	}
-
 	role := &rbacv1.Role{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "Role",
 			APIVersion: "rbac.authorization.k8s.io/v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
+			Name:      name,
+			Namespace: namespace,
 		},
 		Rules: rules,
 	}
 	log.V(1).Info("Creating role", "name", name, "namespace", namespace)
-	result, err = kubeclientset.RbacV1().Roles(namespace).Create(context.TODO(), role, metav1.CreateOptions{})
-	if err != nil {
+	if err := c.Create(context.TODO(), role); err != nil {
+		if errors.IsAlreadyExists(err) {
+			log.V(1).Info("Role already exist", "name", name, "namespace", namespace)
+			return role, nil
+		}
 		log.Error(err, "Failed to create role", "role", name, "namespace", namespace)
 		return nil, err
 	}
-	return result, nil
+	return role, nil
 }
 
-func DeleteRole(name string, namespace string, kubeclientset kubernetes.Interface) error {
+func DeleteRole(name string, namespace string, c client.Client) error {
 	log := ctrl.Log.WithName("rbac")
-	getOps := metav1.GetOptions{}
-	_, err := kubeclientset.RbacV1().Roles(namespace).Get(context.TODO(), name, getOps)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			log.V(1).Info("No such role", "role", name, "namespace", namespace)
-			return nil
-		}
-		log.Error(err, "Error in role", "role", name, "namespace", namespace)
-		// will try delete anyway
-	}
-	deletePeriod := int64(0)
-	options := metav1.DeleteOptions{
-		GracePeriodSeconds: &deletePeriod,
-	}
-
-	if err := kubeclientset.RbacV1().Roles(namespace).Delete(context.TODO(), name, options); err != nil {
-		log.Error(err, "Failed to delete role", "name", name, "namespace", namespace)
-	}
-	return err
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	return deleteObject(c, role, log.WithValues("role", name, "namespace", namespace))
 }
 
-func CreateServiceAccountRoleBinding(serviceAccountName string, roleName string, name string, namespace string, kubeclientset kubernetes.Interface) (*rbacv1.RoleBinding, error) {
+func CreateServiceAccountRoleBinding(serviceAccountName string, roleName string, name string, namespace string, c client.Client) (*rbacv1.RoleBinding, error) {
 	log := ctrl.Log.WithName("rbac")
-	getOps := metav1.GetOptions{}
-	result, err := kubeclientset.RbacV1().RoleBindings(namespace).Get(context.TODO(), name, getOps)
-	if err == nil {
-		log.V(1).Info("RoleBinding already exist", "name", name, "namespace", namespace)
-		return result, nil
-	}
-	if !errors.IsNotFound(err) {
-		log.Error(err, "Error in finding RoleBinding, will create it anyway", "name", name, "namespace", namespace)
-	}
 	roleBinding := &rbacv1.RoleBinding{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "RoleBinding",
 			APIVersion: "rbac.authorization.k8s.io/v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
+			Name:      name,
+			Namespace: namespace,
 		},
 		Subjects: []rbacv1.Subject{
 			{
@@ -165,33 +117,32 @@ func CreateServiceAccountRoleBinding(serviceAccountName string, roleName string,
 		},
 	}
 	log.V(1).Info("Creating role binding", "name", name, "namespace", namespace)
-	result, err = kubeclientset.RbacV1().RoleBindings(namespace).Create(context.TODO(), roleBinding, metav1.CreateOptions{})
-	if err != nil {
+	if err := c.Create(context.TODO(), roleBinding); err != nil {
+		if errors.IsAlreadyExists(err) {
+			log.V(1).Info("RoleBinding already exist", "name", name, "namespace", namespace)
+			return roleBinding, nil
+		}
 		log.Error(err, "Failed to create rolebinding", "name", name, "namespace", namespace)
 		return nil, err
 	}
-	return result, nil
+	return roleBinding, nil
 }
 
-func DeleteRoleBinding(name string, namespace string, kubeclientset kubernetes.Interface) error {
+func DeleteRoleBinding(name string, namespace string, c client.Client) error {
 	log := ctrl.Log.WithName("rbac")
-	getOps := metav1.GetOptions{}
-	_, err := kubeclientset.RbacV1().RoleBindings(namespace).Get(context.TODO(), name, getOps)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			log.V(1).Info("No such rolebinding to delete", "name", name, "namespace", namespace)
-			return nil
-		}
-		log.Error(err, "Error in getting rolebinding", "name", name, "namespace", namespace)
-		// will try delete anyway
-	}
+	roleBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	return deleteObject(c, roleBinding, log.WithValues("rolebinding", name, "namespace", namespace))
+}
+
+func deleteObject(c client.Client, obj client.Object, log logr.Logger) error {
 	deletePeriod := int64(0)
-	options := metav1.DeleteOptions{
-		GracePeriodSeconds: &deletePeriod,
+	err := c.Delete(context.TODO(), obj, &client.DeleteOptions{GracePeriodSeconds: &deletePeriod})
+	if errors.IsNotFound(err) {
+		log.V(1).Info("Nothing to delete")
+		return nil
 	}
-
-	if err := kubeclientset.RbacV1().RoleBindings(namespace).Delete(context.TODO(), name, options); err != nil {
-		log.Error(err, "Failed to delete rolebinding", "name", name, "namespace", namespace)
+	if err != nil {
+		log.Error(err, "Failed to delete")
 	}
 	return err
 }
@@ -1,7 +1,9 @@
 package artemis
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/artemiscloud/activemq-artemis-operator/pkg/utils/jolokia"
@@ -30,6 +32,11 @@ func GetCreationError(jdata *jolokia.ResponseData) string {
 	return UNKNOWN_ERROR
 }
 
+// IsInstanceNotFound tells from a read response that the mbean doesn't exist
+func IsInstanceNotFound(jdata *jolokia.ResponseData) bool {
+	return jdata != nil && strings.Contains(jdata.ErrorType, "InstanceNotFoundException")
+}
+
 type IArtemis interface {
 	NewArtemis(_ip string, _jolokiaPort string, _name string, _userName string, _password string) *Artemis
 	Uptime() (*jolokia.ResponseData, error)
@@ -86,6 +93,142 @@ func (artemis *Artemis) GetStatus() (string, error) {
 	return resp.Value, nil
 }
 
+// IsActive reports whether the broker is serving clients, a backup is not
+// active till it takes over from its primary
+func (artemis *Artemis) IsActive() (bool, error) {
+	url := "org.apache.activemq.artemis:broker=\"" + artemis.name + "\"/Active"
+	resp, err := artemis.jolokia.Read(url)
+	if err != nil {
+		return false, err
+	}
+	if resp == nil {
+		// an unreachable broker is not a passive backup
+		return false, fmt.Errorf("no response reading the active state of broker %s", artemis.name)
+	}
+	if resp.Status != 200 {
+		return false, fmt.Errorf("unable to retrieve active state %v", resp.Error)
+	}
+	return resp.Value == "true", nil
+}
+
+// ListBrokerConnections returns a json array with the name, protocol and
+// started state of each broker connection
+func (artemis *Artemis) ListBrokerConnections() (*jolokia.ResponseData, error) {
+	url := "org.apache.activemq.artemis:broker=\\\"" + artemis.name + "\\\""
+	jsonStr := `{ "type":"EXEC","mbean":"` + url + `","operation":"listBrokerConnections()","arguments":[]` + ` }`
+	data, err := artemis.jolokia.Exec(url, jsonStr)
+
+	return data, err
+}
+
+// ListConnections returns a json array with the clientAddress and other
+// details of each connection to the broker
+func (artemis *Artemis) ListConnections() (*jolokia.ResponseData, error) {
+	url := "org.apache.activemq.artemis:broker=\\\"" + artemis.name + "\\\""
+	jsonStr := `{ "type":"EXEC","mbean":"` + url + `","operation":"listConnectionsAsJSON()","arguments":[]` + ` }`
+	data, err := artemis.jolokia.Exec(url, jsonStr)
+
+	return data, err
+}
+
+// CountUserConnections returns how many connections to the broker have a
+// session of the user
+func (artemis *Artemis) CountUserConnections(user string) (int64, error) {
+	return artemis.countListed("listConnections", "users", user)
+}
+
+// CountUserQueues returns how many queues of the broker the user created
+func (artemis *Artemis) CountUserQueues(user string) (int64, error) {
+	return artemis.countListed("listQueues", "user", user)
+}
+
+// countListed filters a paged list of the broker on a field, the page has the
+// count of all the matches so one entry is enough
+func (artemis *Artemis) countListed(operation string, field string, value string) (int64, error) {
+	url := "org.apache.activemq.artemis:broker=\\\"" + artemis.name + "\\\""
+	options, _ := json.Marshal(map[string]string{"field": field, "operation": "EQUALS", "value": value})
+	parameter, _ := json.Marshal(string(options))
+	jsonStr := `{ "type":"EXEC","mbean":"` + url + `","operation":"` + operation + `(java.lang.String,int,int)","arguments":[` + string(parameter) + `,1,1]` + ` }`
+	resp, err := artemis.jolokia.Exec(url, jsonStr)
+	if err != nil || resp == nil {
+		return 0, err
+	}
+	if resp.Status != 200 {
+		return 0, fmt.Errorf("unable to retrieve %s count %v", operation, resp.Error)
+	}
+	page := struct {
+		Count int64 `json:"count"`
+	}{}
+	err = json.Unmarshal([]byte(resp.Value), &page)
+	return page.Count, err
+}
+
+func (artemis *Artemis) DestroyDivert(divertName string) (*jolokia.ResponseData, error) {
+	url := "org.apache.activemq.artemis:broker=\\\"" + artemis.name + "\\\""
+	parameters := `"` + divertName + `"`
+	jsonStr := `{ "type":"EXEC","mbean":"` + url + `","operation":"destroyDivert(java.lang.String)","arguments":[` + parameters + `]` + ` }`
+	data, err := artemis.jolokia.Exec(url, jsonStr)
+
+	return data, err
+}
+
+func (artemis *Artemis) DestroyBridge(bridgeName string) (*jolokia.ResponseData, error) {
+	url := "org.apache.activemq.artemis:broker=\\\"" + artemis.name + "\\\""
+	parameters := `"` + bridgeName + `"`
+	jsonStr := `{ "type":"EXEC","mbean":"` + url + `","operation":"destroyBridge(java.lang.String)","arguments":[` + parameters + `]` + ` }`
+	data, err := artemis.jolokia.Exec(url, jsonStr)
+
+	return data, err
+}
+
+// GetTotalMessageCount returns how many messages all the queues of the broker hold
+func (artemis *Artemis) GetTotalMessageCount() (int64, error) {
+	return artemis.readCount("org.apache.activemq.artemis:broker=\"" + artemis.name + "\"/TotalMessageCount")
+}
+
+func (artemis *Artemis) GetQueueMessageCount(addressName string, queueName string, routingType string) (int64, error) {
+	return artemis.readCount(artemis.queueMBean(addressName, queueName, routingType) + "/MessageCount")
+}
+
+func (artemis *Artemis) GetQueueConsumerCount(addressName string, queueName string, routingType string) (int64, error) {
+	return artemis.readCount(artemis.queueMBean(addressName, queueName, routingType) + "/ConsumerCount")
+}
+
+func (artemis *Artemis) GetAddressMessageCount(addressName string) (int64, error) {
+	return artemis.readCount(artemis.addressMBean(addressName) + "/MessageCount")
+}
+
+// GetQueueAttribute reads an attribute of a queue
+func (artemis *Artemis) GetQueueAttribute(addressName string, queueName string, routingType string, attribute string) (*jolokia.ResponseData, error) {
+	return artemis.jolokia.Read(artemis.queueMBean(addressName, queueName, routingType) + "/" + attribute)
+}
+
+// GetAddressAttribute reads an attribute of an address
+func (artemis *Artemis) GetAddressAttribute(addressName string, attribute string) (*jolokia.ResponseData, error) {
+	return artemis.jolokia.Read(artemis.addressMBean(addressName) + "/" + attribute)
+}
+
+func (artemis *Artemis) addressMBean(addressName string) string {
+	return "org.apache.activemq.artemis:broker=\"" + artemis.name + "\",component=addresses,address=\"" + addressName + "\""
+}
+
+func (artemis *Artemis) queueMBean(addressName string, queueName string, routingType string) string {
+	return artemis.addressMBean(addressName) + ",subcomponent=queues,routing-type=\"" + strings.ToLower(routingType) + "\",queue=\"" + queueName + "\""
+}
+
+func (artemis *Artemis) readCount(url string) (int64, error) {
+	resp, err := artemis.jolokia.Read(url)
+	if err != nil || resp == nil {
+		return 0, err
+	}
+	if resp.Status != 200 {
+		return 0, fmt.Errorf("unable to retrieve count %v", resp.Error)
+	}
+	// jolokia decodes numbers as float64, large counts come back in exponent form
+	count, err := strconv.ParseFloat(resp.Value, 64)
+	return int64(count), err
+}
+
 func (artemis *Artemis) CreateQueue(addressName string, queueName string, routingType string) (*jolokia.ResponseData, error) {
 
 	url := "org.apache.activemq.artemis:broker=\\\"" + artemis.name + "\\\""
@@ -145,6 +288,16 @@ func (artemis *Artemis) DeleteQueue(queueName string) (*jolokia.ResponseData, er
 	return data, err
 }
 
+// MoveMessages moves all the messages of a queue to the address of another queue
+func (artemis *Artemis) MoveMessages(addressName string, queueName string, routingType string, otherQueueName string) (*jolokia.ResponseData, error) {
+	url := strings.ReplaceAll(artemis.queueMBean(addressName, queueName, routingType), `"`, `\"`)
+	parameters := `"","` + otherQueueName + `"`
+	jsonStr := `{ "type":"EXEC","mbean":"` + url + `","operation":"moveMessages(java.lang.String,java.lang.String)","arguments":[` + parameters + `]` + ` }`
+	data, err := artemis.jolokia.Exec(url, jsonStr)
+
+	return data, err
+}
+
 func (artemis *Artemis) ListBindingsForAddress(addressName string) (*jolokia.ResponseData, error) {
 
 	url := "org.apache.activemq.artemis:broker=\\\"" + artemis.name + "\\\""
@@ -36,6 +36,178 @@ func TestGetStatus(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestIsActive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	j := jolokia.NewMockIJolokia(ctrl)
+
+	artemis := createMockArtemis(j)
+
+	for _, value := range []string{"true", "false"} {
+		j.
+			EXPECT().
+			Read(gomock.Eq("org.apache.activemq.artemis:broker=\"someBroker\"/Active")).
+			DoAndReturn(func(_ string) (*jolokia.ResponseData, error) {
+				return &jolokia.ResponseData{
+					Status:    200,
+					Value:     value,
+					ErrorType: "",
+					Error:     "",
+				}, nil
+			})
+		active, err := artemis.IsActive()
+
+		assert.Equal(t, value == "true", active)
+		assert.Nil(t, err)
+	}
+}
+
+func TestIsActiveWithNilResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	j := jolokia.NewMockIJolokia(ctrl)
+
+	artemis := createMockArtemis(j)
+
+	j.
+		EXPECT().
+		Read(gomock.Eq("org.apache.activemq.artemis:broker=\"someBroker\"/Active")).
+		DoAndReturn(func(_ string) (*jolokia.ResponseData, error) {
+			return nil, nil
+		})
+	active, err := artemis.IsActive()
+
+	assert.False(t, active)
+	assert.Error(t, err)
+}
+
+func TestGetQueueMessageCount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	j := jolokia.NewMockIJolokia(ctrl)
+
+	artemis := createMockArtemis(j)
+
+	j.
+		EXPECT().
+		Read(gomock.Eq("org.apache.activemq.artemis:broker=\"someBroker\",component=addresses,address=\"$ACTIVEMQ_ARTEMIS_MIRROR_dr\",subcomponent=queues,routing-type=\"anycast\",queue=\"$ACTIVEMQ_ARTEMIS_MIRROR_dr\"/MessageCount")).
+		DoAndReturn(func(_ string) (*jolokia.ResponseData, error) {
+			return &jolokia.ResponseData{
+				Status:    200,
+				Value:     "1.2e+07",
+				ErrorType: "",
+				Error:     "",
+			}, nil
+		})
+	count, err := artemis.GetQueueMessageCount("$ACTIVEMQ_ARTEMIS_MIRROR_dr", "$ACTIVEMQ_ARTEMIS_MIRROR_dr", "ANYCAST")
+
+	assert.Equal(t, int64(12000000), count)
+	assert.Nil(t, err)
+}
+
+func TestGetQueueConsumerCount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	j := jolokia.NewMockIJolokia(ctrl)
+
+	artemis := createMockArtemis(j)
+
+	j.
+		EXPECT().
+		Read(gomock.Eq("org.apache.activemq.artemis:broker=\"someBroker\",component=addresses,address=\"orders\",subcomponent=queues,routing-type=\"multicast\",queue=\"audit\"/ConsumerCount")).
+		DoAndReturn(func(_ string) (*jolokia.ResponseData, error) {
+			return &jolokia.ResponseData{
+				Status:    200,
+				Value:     "3",
+				ErrorType: "",
+				Error:     "",
+			}, nil
+		})
+	count, err := artemis.GetQueueConsumerCount("orders", "audit", "MULTICAST")
+
+	assert.Equal(t, int64(3), count)
+	assert.Nil(t, err)
+}
+
+func TestGetTotalMessageCount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	j := jolokia.NewMockIJolokia(ctrl)
+
+	artemis := createMockArtemis(j)
+
+	j.
+		EXPECT().
+		Read(gomock.Eq("org.apache.activemq.artemis:broker=\"someBroker\"/TotalMessageCount")).
+		DoAndReturn(func(_ string) (*jolokia.ResponseData, error) {
+			return &jolokia.ResponseData{
+				Status:    200,
+				Value:     "42",
+				ErrorType: "",
+				Error:     "",
+			}, nil
+		})
+	count, err := artemis.GetTotalMessageCount()
+
+	assert.Equal(t, int64(42), count)
+	assert.Nil(t, err)
+}
+
+func TestGetAddressMessageCountWithError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	j := jolokia.NewMockIJolokia(ctrl)
+
+	artemis := createMockArtemis(j)
+
+	j.
+		EXPECT().
+		Read(gomock.Eq("org.apache.activemq.artemis:broker=\"someBroker\",component=addresses,address=\"orders\"/MessageCount")).
+		DoAndReturn(func(_ string) (*jolokia.ResponseData, error) {
+			return &jolokia.ResponseData{
+				Status:    404,
+				Value:     "",
+				ErrorType: "javax.management.InstanceNotFoundException",
+				Error:     "javax.management.InstanceNotFoundException : org.apache.activemq.artemis:address=\"orders\"",
+			}, nil
+		})
+	_, err := artemis.GetAddressMessageCount("orders")
+
+	assert.NotNil(t, err)
+}
+
+func TestGetQueueAttributeNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	j := jolokia.NewMockIJolokia(ctrl)
+
+	artemis := createMockArtemis(j)
+
+	j.
+		EXPECT().
+		Read(gomock.Eq("org.apache.activemq.artemis:broker=\"someBroker\",component=addresses,address=\"orders\",subcomponent=queues,routing-type=\"anycast\",queue=\"orders\"/MaxConsumers")).
+		DoAndReturn(func(_ string) (*jolokia.ResponseData, error) {
+			return &jolokia.ResponseData{
+				Status:    404,
+				Value:     "",
+				ErrorType: "javax.management.InstanceNotFoundException",
+				Error:     "javax.management.InstanceNotFoundException : org.apache.activemq.artemis:queue=\"orders\"",
+			}, fmt.Errorf("Error response code 404")
+		})
+	response, err := artemis.GetQueueAttribute("orders", "orders", "ANYCAST", "MaxConsumers")
+
+	assert.NotNil(t, err)
+	assert.True(t, IsInstanceNotFound(response))
+	assert.False(t, IsInstanceNotFound(nil))
+}
+
 func TestGetStatusWithError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -117,3 +289,74 @@ func createMockArtemis(j jolokia.IJolokia) Artemis {
 		jolokia:     j,
 	}
 }
+
+func TestMoveMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	j := jolokia.NewMockIJolokia(ctrl)
+
+	artemis := createMockArtemis(j)
+
+	mbean := `org.apache.activemq.artemis:broker=\"someBroker\",component=addresses,address=\"orders\",subcomponent=queues,routing-type=\"anycast\",queue=\"orders\"`
+	j.
+		EXPECT().
+		Exec(gomock.Eq(mbean), gomock.Eq(`{ "type":"EXEC","mbean":"`+mbean+`","operation":"moveMessages(java.lang.String,java.lang.String)","arguments":["","orders.archive"] }`)).
+		DoAndReturn(func(_ string, _ string) (*jolokia.ResponseData, error) {
+			return &jolokia.ResponseData{
+				Status: 200,
+				Value:  "42",
+			}, nil
+		})
+	response, err := artemis.MoveMessages("orders", "orders", "ANYCAST", "orders.archive")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "42", response.Value)
+}
+
+func TestCountUserConnections(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	j := jolokia.NewMockIJolokia(ctrl)
+
+	artemis := createMockArtemis(j)
+
+	mbean := `org.apache.activemq.artemis:broker=\"someBroker\"`
+	j.
+		EXPECT().
+		Exec(gomock.Eq(mbean), gomock.Eq(`{ "type":"EXEC","mbean":"`+mbean+`","operation":"listConnections(java.lang.String,int,int)","arguments":["{\"field\":\"users\",\"operation\":\"EQUALS\",\"value\":\"bob\"}",1,1] }`)).
+		DoAndReturn(func(_ string, _ string) (*jolokia.ResponseData, error) {
+			return &jolokia.ResponseData{
+				Status: 200,
+				Value:  `{"data":[{"connectionID":"1","users":"bob"}],"count":3}`,
+			}, nil
+		})
+	count, err := artemis.CountUserConnections("bob")
+
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestCountUserQueuesWithError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	j := jolokia.NewMockIJolokia(ctrl)
+
+	artemis := createMockArtemis(j)
+
+	j.
+		EXPECT().
+		Exec(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ string, _ string) (*jolokia.ResponseData, error) {
+			return &jolokia.ResponseData{
+				Status: 403,
+				Error:  "access denied",
+			}, nil
+		})
+	count, err := artemis.CountUserQueues("bob")
+
+	assert.NotNil(t, err)
+	assert.Equal(t, int64(0), count)
+}
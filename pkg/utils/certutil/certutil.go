@@ -233,12 +233,30 @@ func GetSslArgumentsFromSecret(sslSecret *corev1.Secret, keyStoreType string, tr
 		}
 	}
 
+	if err := setTrustArguments(&sslArgs, trustStoreType, trustSecret, sep); err != nil {
+		return nil, err
+	}
+
+	return &sslArgs, nil
+}
+
+// GetTrustArgumentsFromSecret gives the trust store arguments of a client
+// connection that verifies its server with the trust secret mounted in the pod
+func GetTrustArgumentsFromSecret(trustSecret *corev1.Secret, trustStoreType string) (*SslArguments, error) {
+	sslArgs := SslArguments{}
+	if err := setTrustArguments(&sslArgs, trustStoreType, trustSecret, "/"); err != nil {
+		return nil, err
+	}
+	return &sslArgs, nil
+}
+
+func setTrustArguments(sslArgs *SslArguments, trustStoreType string, trustSecret *corev1.Secret, sep string) error {
 	isBundleSecret := isSecretFromBundle(trustSecret)
 
 	if isBundleSecret {
 		if trustStoreType != "" {
 			if trustStoreType != "PEM" {
-				return nil, fmt.Errorf("ca bundle secret must have PEM trust store type")
+				return fmt.Errorf("ca bundle secret must have PEM trust store type")
 			}
 		}
 		sslArgs.TrustStoreType = "PEM"
@@ -258,20 +276,20 @@ func GetSslArgumentsFromSecret(sslSecret *corev1.Secret, keyStoreType string, tr
 		sslArgs.TrustStorePassword = &defaultKeyStorePassword
 		sslArgs.TrustStorePath = trustVolumeDir + sep + "client.ts"
 		if trustPassword := string(trustSecret.Data["trustStorePassword"]); trustPassword != "" {
-			if !isConsole {
+			if !sslArgs.IsConsole {
 				trustPassword = strings.ReplaceAll(trustPassword, "/", sep)
 			}
 			sslArgs.TrustStorePassword = &trustPassword
 		}
 		if trustStorePath := string(trustSecret.Data["trustStorePath"]); trustStorePath != "" {
-			if !isConsole {
+			if !sslArgs.IsConsole {
 				trustStorePath = strings.ReplaceAll(trustStorePath, "/", sep)
 			}
 			sslArgs.TrustStorePath = trustStorePath
 		}
 	}
 
-	return &sslArgs, nil
+	return nil
 }
 
 func CfgToSecretName(cfgFileName string) string {
@@ -57,6 +57,10 @@ var theManager manager.Manager
 
 var resyncPeriod time.Duration = DEFAULT_RESYNC_PERIOD
 
+var addressDriftRepairPeriod time.Duration = DEFAULT_RESYNC_PERIOD
+
+var addressTargetNamespaces map[string][]string = map[string][]string{}
+
 var jaasConfigSyntaxMatchRegEx = JaasConfigSyntaxMatchRegExDefault
 
 var ClusterDomain *string
@@ -84,6 +88,16 @@ func init() {
 		resyncPeriod = DEFAULT_RESYNC_PERIOD
 	}
 
+	// zero turns the drift repair of addresses off
+	addressDriftRepairPeriod = resyncPeriod
+	if period, defined := os.LookupEnv("ADDRESS_DRIFT_REPAIR_PERIOD"); defined {
+		if value, err := time.ParseDuration(period); err == nil {
+			addressDriftRepairPeriod = value
+		}
+	}
+
+	addressTargetNamespaces = ParseAddressTargetNamespaces(os.Getenv("ADDRESS_TARGET_NAMESPACES"))
+
 	if regEx, defined := os.LookupEnv("JAAS_CONFIG_SYNTAX_MATCH_REGEX"); defined {
 		jaasConfigSyntaxMatchRegEx = regEx
 	} else {
@@ -99,6 +113,49 @@ func GetReconcileResyncPeriod() time.Duration {
 	return resyncPeriod
 }
 
+// ParseAddressTargetNamespaces reads the namespaces that the address CRs of a
+// namespace may target, from rules like source=target1,target2;source2=*
+// where * stands for any namespace
+func ParseAddressTargetNamespaces(value string) map[string][]string {
+	allowed := map[string][]string{}
+	for _, rule := range strings.Split(value, ";") {
+		source, targets, found := strings.Cut(rule, "=")
+		source = strings.TrimSpace(source)
+		if !found || source == "" {
+			continue
+		}
+		for _, target := range strings.Split(targets, ",") {
+			if target = strings.TrimSpace(target); target != "" {
+				allowed[source] = append(allowed[source], target)
+			}
+		}
+	}
+	return allowed
+}
+
+// IsAddressTargetNamespaceAllowed tells if the address CRs of the source
+// namespace may apply to the brokers of the target namespace, a namespace can
+// always target itself
+func IsAddressTargetNamespaceAllowed(source string, target string) bool {
+	if source == target {
+		return true
+	}
+	for _, rule := range []string{source, "*"} {
+		for _, allowed := range addressTargetNamespaces[rule] {
+			if allowed == "*" || allowed == target {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetAddressDriftRepairPeriod is how often the address controller checks the
+// brokers for addresses and queues that no longer match their CR
+func GetAddressDriftRepairPeriod() time.Duration {
+	return addressDriftRepairPeriod
+}
+
 type ActiveMQArtemisConfigHandler interface {
 	GetCRName() string
 	IsApplicableFor(brokerNamespacedName types.NamespacedName) bool
@@ -261,6 +318,17 @@ func GetClusterDomain() string {
 	return *ClusterDomain
 }
 
+// ServiceFQDN is the cluster DNS name of a service
+func ServiceFQDN(serviceName string, namespace string) string {
+	return serviceName + "." + namespace + ".svc." + GetClusterDomain()
+}
+
+// OrdinalFQDN is the DNS name the headless service gives the broker pod of an
+// ordinal, see https://kubernetes.io/docs/concepts/services-networking/dns-pod-service/#a-aaaa-records
+func OrdinalFQDN(namers *Namers, namespace string, ordinal int32) string {
+	return namers.SsNameBuilder.Name() + "-" + strconv.Itoa(int(ordinal)) + "." + ServiceFQDN(namers.SvcHeadlessNameBuilder.Name(), namespace)
+}
+
 func DetermineCompactVersionToUse(customResource *brokerv1beta1.ActiveMQArtemis) (string, error) {
 	log := ctrl.Log.WithName("util_common")
 	resolvedFullVersion, err := ResolveBrokerVersionFromCR(customResource)
@@ -42,3 +42,23 @@ var _ = Describe("Common Resync Test", func() {
 
 	})
 })
+
+func TestAddressTargetNamespaces(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(ParseAddressTargetNamespaces("")).To(BeEmpty())
+	g.Expect(ParseAddressTargetNamespaces(" messaging = payments, payments-dr ;platform=*;=orphan;broken")).To(Equal(map[string][]string{
+		"messaging": {"payments", "payments-dr"},
+		"platform":  {"*"},
+	}))
+
+	defer func(previous map[string][]string) { addressTargetNamespaces = previous }(addressTargetNamespaces)
+
+	addressTargetNamespaces = ParseAddressTargetNamespaces("messaging=payments,payments-dr;platform=*;*=shared")
+	g.Expect(IsAddressTargetNamespaceAllowed("messaging", "messaging")).To(BeTrue())
+	g.Expect(IsAddressTargetNamespaceAllowed("messaging", "payments-dr")).To(BeTrue())
+	g.Expect(IsAddressTargetNamespaceAllowed("messaging", "orders")).To(BeFalse())
+	g.Expect(IsAddressTargetNamespaceAllowed("platform", "orders")).To(BeTrue())
+	g.Expect(IsAddressTargetNamespaceAllowed("orders", "shared")).To(BeTrue())
+	g.Expect(IsAddressTargetNamespaceAllowed("orders", "payments")).To(BeFalse())
+}
@@ -38,6 +38,7 @@ import (
 type JkInfo struct {
 	Artemis *mgmt.Artemis
 	IP      string
+	CrName  string
 	Ordinal string
 }
 
@@ -101,6 +102,7 @@ func GetBrokersFromDNS(crName string, namespace string, size int32, client rtcli
 			jkInfo := JkInfo{
 				Artemis: artemis,
 				IP:      ordinalFqdn,
+				CrName:  crName,
 				Ordinal: strconv.FormatInt(int64(i), 10),
 			}
 			artemisArray = append(artemisArray, &jkInfo)
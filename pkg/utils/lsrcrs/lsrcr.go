@@ -35,6 +35,14 @@ type LastSuccessfulReconciledCR struct {
 func StoreLastSuccessfulReconciledCR(owner v1.Object,
 	name string, namespace string, crType string, cr string, data string, checksum string,
 	labels map[string]string, client client.Client, scheme *runtime.Scheme) error {
+	return StoreLastSuccessfulReconciledCRWithFiles(owner, name, namespace, crType, cr, data, checksum, nil, labels, client, scheme)
+}
+
+// StoreLastSuccessfulReconciledCRWithFiles also stores files generated from
+// the CR, each under its own key next to Data
+func StoreLastSuccessfulReconciledCRWithFiles(owner v1.Object,
+	name string, namespace string, crType string, cr string, data string, checksum string, files map[string]string,
+	labels map[string]string, client client.Client, scheme *runtime.Scheme) error {
 	log := ctrl.Log.WithName("lsrcr")
 
 	secretName := "secret-" + crType + "-" + name
@@ -47,6 +55,9 @@ func StoreLastSuccessfulReconciledCR(owner v1.Object,
 	secretData["Data"] = data
 	secretData["Checksum"] = checksum
 	secretData["Timestamp"] = time.Now().String()
+	for key, value := range files {
+		secretData[key] = value
+	}
 	err := secrets.CreateOrUpdate(owner, secretNn, secretData, labels, client, scheme)
 	if err != nil {
 		log.Error(err, "failed to save lsrcr", "for cr", name, "secret", secretName, "ns", namespace)